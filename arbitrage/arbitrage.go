@@ -0,0 +1,383 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package arbitrage monitors the spreads of a product between the exchanges.
+package arbitrage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/risk"
+	"github.com/euskadi31/go-eventemitter"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// Events dispatched by the service
+const (
+	EventOpportunity = "arbitrage-opportunity"
+	EventExecution   = "arbitrage-execution"
+)
+
+// Defaults of Options
+const (
+	DefaultMaxPriceAge = time.Minute
+	DefaultCooldown    = time.Minute
+)
+
+var hundred = decimal.New(100, 0)
+
+// Errors
+var (
+	ErrOrdersNotSupported = errors.New("provider does not support orders")
+	ErrInvalidSize        = errors.New("arbitrage size must be positive")
+)
+
+// Feed subscribes the tickers of products on provider
+type Feed interface {
+	Subscribe(provider string, products ...exchanges.Product) error
+}
+
+// Options of Service
+type Options struct {
+	// Products compared between the providers
+	Products []exchanges.Product
+	// Providers compared, all the providers of the manager when empty
+	Providers []string
+	// Threshold of the spread in percent net of fees
+	Threshold decimal.Decimal
+	// MaxPriceAge after which the last price of a provider is ignored
+	MaxPriceAge time.Duration
+	// Execute places the offsetting orders of the opportunities
+	Execute bool
+	// Size in base currency of the executed orders
+	Size decimal.Decimal
+	// Cooldown between two executions of the same opportunity
+	Cooldown time.Duration
+}
+
+// Opportunity to buy product on a provider and sell it on another
+type Opportunity struct {
+	Product      string          `json:"product"`
+	BuyProvider  string          `json:"buy_provider"`
+	SellProvider string          `json:"sell_provider"`
+	BuyPrice     decimal.Decimal `json:"buy_price"`
+	SellPrice    decimal.Decimal `json:"sell_price"`
+	BuyFeeRate   decimal.Decimal `json:"buy_fee_rate"`
+	SellFeeRate  decimal.Decimal `json:"sell_fee_rate"`
+	// Spread by unit of base currency net of fees
+	Spread        decimal.Decimal `json:"spread"`
+	SpreadPercent decimal.Decimal `json:"spread_percent"`
+	DetectedAt    time.Time       `json:"detected_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Key of the opportunity
+func (o Opportunity) Key() string {
+	return o.Product + ":" + o.BuyProvider + ":" + o.SellProvider
+}
+
+// Execution of an opportunity
+type Execution struct {
+	Opportunity *Opportunity    `json:"opportunity"`
+	Size        decimal.Decimal `json:"size"`
+	BuyOrderID  string          `json:"buy_order_id,omitempty"`
+	SellOrderID string          `json:"sell_order_id,omitempty"`
+	Unhedged    bool            `json:"unhedged"`
+	Error       string          `json:"error,omitempty"`
+	Time        time.Time       `json:"time"`
+}
+
+// quote of a provider
+type quote struct {
+	price decimal.Decimal
+	time  time.Time
+}
+
+// Service struct
+type Service struct {
+	mu            sync.Mutex
+	options       *Options
+	providers     exchanges.Manager
+	fees          exchanges.FeeSchedules
+	risk          *risk.Manager
+	emitter       eventemitter.EventEmitter
+	quotes        map[string]map[string]*quote
+	opportunities map[string]*Opportunity
+	executedAt    map[string]time.Time
+	executing     map[string]bool
+	executions    []*Execution
+	listeners     map[string]func(event *exchanges.TickerEvent)
+	now           func() time.Time
+}
+
+// NewService arbitrage
+func NewService(
+	providers exchanges.Manager,
+	fees exchanges.FeeSchedules,
+	riskManager *risk.Manager,
+	emitter eventemitter.EventEmitter,
+	options *Options,
+) *Service {
+	if options == nil {
+		options = &Options{}
+	}
+
+	if options.MaxPriceAge <= 0 {
+		options.MaxPriceAge = DefaultMaxPriceAge
+	}
+
+	if options.Cooldown <= 0 {
+		options.Cooldown = DefaultCooldown
+	}
+
+	return &Service{
+		options:       options,
+		providers:     providers,
+		fees:          fees,
+		risk:          riskManager,
+		emitter:       emitter,
+		quotes:        make(map[string]map[string]*quote),
+		opportunities: make(map[string]*Opportunity),
+		executedAt:    make(map[string]time.Time),
+		executing:     make(map[string]bool),
+		executions:    []*Execution{},
+		listeners:     make(map[string]func(event *exchanges.TickerEvent)),
+		now:           time.Now,
+	}
+}
+
+// Options of Service
+func (s *Service) Options() *Options {
+	return s.options
+}
+
+// names of the compared providers
+func (s *Service) names() []string {
+	if len(s.options.Providers) > 0 {
+		return s.options.Providers
+	}
+
+	names := []string{}
+
+	for name := range s.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Start subscribes the products on every provider and listens to their tickers
+func (s *Service) Start(feed Feed) error {
+	for _, name := range s.names() {
+		if _, err := s.providers.Get(name); err != nil {
+			return fmt.Errorf("arbitrage provider %s: %v", name, err)
+		}
+
+		if err := feed.Subscribe(name, s.options.Products...); err != nil {
+			return err
+		}
+
+		for _, product := range s.options.Products {
+			provider := name
+			key := fmt.Sprintf("ticker-%s-%s", provider, product.String())
+
+			listener := func(event *exchanges.TickerEvent) {
+				s.Update(provider, event)
+			}
+
+			s.mu.Lock()
+			s.listeners[key] = listener
+			s.mu.Unlock()
+
+			s.emitter.Subscribe(key, listener)
+		}
+	}
+
+	return nil
+}
+
+// Stop listening to the tickers
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, listener := range s.listeners {
+		s.emitter.Unsubscribe(key, listener)
+	}
+
+	s.listeners = make(map[string]func(event *exchanges.TickerEvent))
+}
+
+// takerRate of provider, the opportunities are taken with market orders
+func (s *Service) takerRate(provider string) decimal.Decimal {
+	return s.fees.Get(provider).Rate(decimal.Zero, false)
+}
+
+// Update the last price of product on provider and compare it with the other providers
+func (s *Service) Update(provider string, event *exchanges.TickerEvent) {
+	if event.Price.Sign() <= 0 {
+		return
+	}
+
+	product := event.Product.String()
+	now := s.now()
+
+	s.mu.Lock()
+
+	if _, ok := s.quotes[product]; ok == false {
+		s.quotes[product] = make(map[string]*quote)
+	}
+
+	s.quotes[product][provider] = &quote{
+		price: event.Price,
+		time:  now,
+	}
+
+	detected := []*Opportunity{}
+
+	for other, q := range s.quotes[product] {
+		if other == provider {
+			continue
+		}
+
+		if now.Sub(q.time) > s.options.MaxPriceAge {
+			s.remove(product, provider, other)
+			s.remove(product, other, provider)
+
+			continue
+		}
+
+		for _, o := range []*Opportunity{
+			s.compare(product, provider, event.Price, other, q.price, now),
+			s.compare(product, other, q.price, provider, event.Price, now),
+		} {
+			if o != nil {
+				detected = append(detected, o)
+			}
+		}
+	}
+
+	toExecute := []*Opportunity{}
+
+	for _, o := range detected {
+		if s.options.Execute && s.executable(o, now) {
+			toExecute = append(toExecute, o)
+		}
+	}
+
+	s.mu.Unlock()
+
+	for _, o := range detected {
+		log.Info().
+			Str("buy", o.BuyProvider).
+			Str("sell", o.SellProvider).
+			Str("spread_percent", o.SpreadPercent.StringFixed(2)).
+			Msgf("Arbitrage opportunity on %s", o.Product)
+
+		s.emitter.Dispatch(EventOpportunity, o)
+	}
+
+	for _, o := range toExecute {
+		s.execute(o)
+	}
+}
+
+// remove the opportunity of product from buy to sell provider
+func (s *Service) remove(product string, buy string, sell string) {
+	delete(s.opportunities, product+":"+buy+":"+sell)
+}
+
+// compare the price of buying product on a provider and selling it on another,
+// it returns the opportunity when it is new
+func (s *Service) compare(product string, buy string, buyPrice decimal.Decimal, sell string, sellPrice decimal.Decimal, now time.Time) *Opportunity {
+	o := &Opportunity{
+		Product:      product,
+		BuyProvider:  buy,
+		SellProvider: sell,
+		BuyPrice:     buyPrice,
+		SellPrice:    sellPrice,
+		BuyFeeRate:   s.takerRate(buy),
+		SellFeeRate:  s.takerRate(sell),
+		DetectedAt:   now,
+		UpdatedAt:    now,
+	}
+
+	cost := buyPrice.Mul(decimal.New(1, 0).Add(o.BuyFeeRate))
+	proceeds := sellPrice.Mul(decimal.New(1, 0).Sub(o.SellFeeRate))
+
+	o.Spread = proceeds.Sub(cost)
+	o.SpreadPercent = o.Spread.Mul(hundred).Div(cost)
+
+	key := o.Key()
+
+	if o.SpreadPercent.LessThan(s.options.Threshold) || o.Spread.Sign() <= 0 {
+		delete(s.opportunities, key)
+
+		return nil
+	}
+
+	if current, ok := s.opportunities[key]; ok {
+		o.DetectedAt = current.DetectedAt
+		s.opportunities[key] = o
+
+		return nil
+	}
+
+	s.opportunities[key] = o
+
+	return o
+}
+
+// executable checks the cooldown of the opportunity and reserves its execution
+func (s *Service) executable(o *Opportunity, now time.Time) bool {
+	key := o.Key()
+
+	if s.executing[key] || now.Sub(s.executedAt[key]) < s.options.Cooldown {
+		return false
+	}
+
+	s.executing[key] = true
+
+	return true
+}
+
+// Opportunities above the threshold sorted by spread
+func (s *Service) Opportunities() []*Opportunity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opportunities := []*Opportunity{}
+
+	for _, o := range s.opportunities {
+		opportunities = append(opportunities, o)
+	}
+
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].SpreadPercent.GreaterThan(opportunities[j].SpreadPercent)
+	})
+
+	return opportunities
+}
+
+// Executions of the opportunities, the last one first
+func (s *Service) Executions() []*Execution {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	executions := make([]*Execution, len(s.executions))
+
+	for i, execution := range s.executions {
+		executions[len(s.executions)-1-i] = execution
+	}
+
+	return executions
+}
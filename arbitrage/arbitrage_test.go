@@ -0,0 +1,283 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package arbitrage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/exchanges/paper"
+	"github.com/euskadi31/cryptotrader/risk"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// emitterMock records the dispatched events and calls the ticker listeners
+type emitterMock struct {
+	mu        sync.Mutex
+	listeners map[string]func(event *exchanges.TickerEvent)
+	events    map[string][]interface{}
+}
+
+func newEmitterMock() *emitterMock {
+	return &emitterMock{
+		listeners: make(map[string]func(event *exchanges.TickerEvent)),
+		events:    make(map[string][]interface{}),
+	}
+}
+
+func (e *emitterMock) Subscribe(name string, fn interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.listeners[name] = fn.(func(event *exchanges.TickerEvent))
+}
+
+func (e *emitterMock) Unsubscribe(name string, fn interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.listeners, name)
+}
+
+func (e *emitterMock) Dispatch(name string, args ...interface{}) {
+	e.mu.Lock()
+	listener, ok := e.listeners[name]
+	e.events[name] = append(e.events[name], args...)
+	e.mu.Unlock()
+
+	if ok {
+		listener(args[0].(*exchanges.TickerEvent))
+	}
+}
+
+func (e *emitterMock) Wait() {}
+
+func (e *emitterMock) count(name string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.events[name])
+}
+
+// feedMock records the subscribed products
+type feedMock map[string][]exchanges.Product
+
+func (f feedMock) Subscribe(provider string, products ...exchanges.Product) error {
+	f[provider] = append(f[provider], products...)
+
+	return nil
+}
+
+var btceur = exchanges.NewProduct("BTC", "EUR")
+
+func tick(price string) *exchanges.TickerEvent {
+	return &exchanges.TickerEvent{
+		Product: btceur,
+		Price:   decimal.RequireFromString(price),
+		Size:    decimal.New(1, 0),
+		Time:    time.Now(),
+	}
+}
+
+func newTestService(options *Options) (*Service, *emitterMock, exchanges.Manager) {
+	providers := exchanges.NewManager()
+
+	providers.Add(paper.NewPaper("gdax", nil, map[string]decimal.Decimal{
+		"EUR": decimal.New(10000, 0),
+	}))
+
+	providers.Add(paper.NewPaper("kraken", nil, map[string]decimal.Decimal{
+		"BTC": decimal.New(1, 0),
+	}))
+
+	fees := exchanges.FeeSchedules{
+		"gdax": exchanges.NewFeeSchedule(exchanges.FeeTier{
+			Taker: decimal.New(25, -4),
+		}),
+		"kraken": exchanges.NewFeeSchedule(exchanges.FeeTier{
+			Taker: decimal.New(26, -4),
+		}),
+	}
+
+	emitter := newEmitterMock()
+
+	options.Products = []exchanges.Product{btceur}
+
+	return NewService(providers, fees, risk.NewManager(nil), emitter, options), emitter, providers
+}
+
+func TestServiceStart(t *testing.T) {
+	s, emitter, _ := newTestService(&Options{})
+
+	feed := feedMock{}
+
+	assert.NoError(t, s.Start(feed))
+	assert.Equal(t, []exchanges.Product{btceur}, feed["gdax"])
+	assert.Equal(t, []exchanges.Product{btceur}, feed["kraken"])
+	assert.Equal(t, 2, len(emitter.listeners))
+
+	emitter.Dispatch("ticker-gdax-BTC-EUR", tick("10000"))
+
+	s.mu.Lock()
+	assert.Equal(t, "10000", s.quotes["BTC-EUR"]["gdax"].price.String())
+	s.mu.Unlock()
+
+	s.Stop()
+	assert.Equal(t, 0, len(emitter.listeners))
+
+	s.options.Providers = []string{"binance"}
+	assert.Error(t, s.Start(feed))
+}
+
+func TestServiceOpportunities(t *testing.T) {
+	s, emitter, _ := newTestService(&Options{
+		Threshold: decimal.New(1, 0),
+	})
+
+	s.Update("gdax", tick("10000"))
+	s.Update("kraken", tick("10100"))
+
+	// 1% gross spread is below the threshold net of fees
+	assert.Equal(t, 0, len(s.Opportunities()))
+
+	s.Update("kraken", tick("10200"))
+
+	opportunities := s.Opportunities()
+	assert.Equal(t, 1, len(opportunities))
+
+	o := opportunities[0]
+	assert.Equal(t, "gdax", o.BuyProvider)
+	assert.Equal(t, "kraken", o.SellProvider)
+	// 10200 * (1 - 0.0026) - 10000 * (1 + 0.0025)
+	assert.Equal(t, "148.48", o.Spread.String())
+	assert.Equal(t, "1.48", o.SpreadPercent.StringFixed(2))
+	assert.Equal(t, 1, emitter.count(EventOpportunity))
+
+	// the opportunity is dispatched once while it stays above the threshold
+	s.Update("kraken", tick("10250"))
+	assert.Equal(t, 1, emitter.count(EventOpportunity))
+	assert.Equal(t, "10250", s.Opportunities()[0].SellPrice.String())
+
+	s.Update("gdax", tick("10200"))
+	assert.Equal(t, 0, len(s.Opportunities()))
+}
+
+func TestServiceStalePrices(t *testing.T) {
+	s, _, _ := newTestService(&Options{
+		Threshold: decimal.New(1, 0),
+	})
+
+	now := time.Now()
+	s.now = func() time.Time {
+		return now
+	}
+
+	s.Update("gdax", tick("10000"))
+
+	now = now.Add(2 * DefaultMaxPriceAge)
+
+	s.Update("kraken", tick("11000"))
+	assert.Equal(t, 0, len(s.Opportunities()))
+}
+
+func TestServiceExecute(t *testing.T) {
+	s, emitter, providers := newTestService(&Options{
+		Threshold: decimal.New(1, 0),
+		Execute:   true,
+		Size:      decimal.RequireFromString("0.5"),
+	})
+
+	s.Update("gdax", tick("10000"))
+	s.Update("kraken", tick("10200"))
+
+	executions := s.Executions()
+	assert.Equal(t, 1, len(executions))
+	assert.Empty(t, executions[0].Error)
+	assert.Equal(t, "0.5", executions[0].Size.String())
+	assert.Equal(t, 1, emitter.count(EventExecution))
+
+	btc, err := providers.Balance("gdax", "BTC")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.5", btc.String())
+
+	// the fills include the taker fees
+	eur, err := providers.Balance("gdax", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "4987.5", eur.String())
+
+	eur, err = providers.Balance("kraken", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "5086.74", eur.String())
+
+	assert.Equal(t, 2, s.risk.State().OrdersCount)
+
+	// the opportunity is not executed again during the cooldown
+	s.Update("gdax", tick("10200"))
+	s.Update("gdax", tick("10000"))
+	assert.Equal(t, 1, len(s.Executions()))
+}
+
+func TestServiceExecuteRejected(t *testing.T) {
+	s, _, providers := newTestService(&Options{
+		Threshold: decimal.New(1, 0),
+		Execute:   true,
+		Size:      decimal.New(2, 0),
+	})
+
+	s.Update("gdax", tick("10000"))
+	s.Update("kraken", tick("10200"))
+
+	executions := s.Executions()
+	assert.Equal(t, 1, len(executions))
+	assert.Contains(t, executions[0].Error, "gdax EUR balance of 10000 is below 20050")
+
+	// no leg is placed when a check fails
+	_, err := providers.Balance("gdax", "BTC")
+	assert.Equal(t, exchanges.ErrBalanceNotFound, err)
+	assert.Equal(t, 0, s.risk.State().OrdersCount)
+}
+
+func TestServiceExecuteHedged(t *testing.T) {
+	s, _, _ := newTestService(&Options{
+		Threshold: decimal.New(1, 0),
+		Execute:   true,
+		Size:      decimal.RequireFromString("0.1"),
+	})
+
+	// the exposure allows two unhedged buys of 0.1 BTC
+	s.risk = risk.NewManager(&risk.Limits{
+		MaxExposure: map[string]decimal.Decimal{
+			"EUR": decimal.New(2500, 0),
+		},
+	})
+
+	o := &Opportunity{
+		Product:      "BTC-EUR",
+		BuyProvider:  "gdax",
+		SellProvider: "kraken",
+		BuyPrice:     decimal.New(10000, 0),
+		SellPrice:    decimal.New(10200, 0),
+	}
+
+	for i := 0; i < 5; i++ {
+		s.execute(o)
+	}
+
+	executions := s.Executions()
+	assert.Equal(t, 5, len(executions))
+
+	for _, execution := range executions {
+		assert.Empty(t, execution.Error)
+		assert.False(t, execution.Unhedged)
+	}
+
+	state := s.risk.State()
+	assert.Equal(t, 10, state.OrdersCount)
+	assert.Equal(t, 0, len(state.Positions))
+	assert.Equal(t, 0, len(state.Exposures))
+}
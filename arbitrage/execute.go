@@ -0,0 +1,198 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package arbitrage
+
+import (
+	"fmt"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/go-std"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// maxExecutions kept in memory
+const maxExecutions = 100
+
+// execute the offsetting orders of the opportunity and records the execution
+func (s *Service) execute(o *Opportunity) {
+	execution := &Execution{
+		Opportunity: o,
+		Time:        s.now(),
+	}
+
+	if err := s.place(o, execution); err != nil {
+		execution.Error = err.Error()
+
+		log.Warn().
+			Str("buy", o.BuyProvider).
+			Str("sell", o.SellProvider).
+			Msgf("Arbitrage on %s not executed: %v", o.Product, err)
+	} else {
+		log.Warn().Msgf("Arbitrage %s %s bought on %s and sold on %s", execution.Size, o.Product, o.BuyProvider, o.SellProvider)
+	}
+
+	key := o.Key()
+
+	s.mu.Lock()
+
+	delete(s.executing, key)
+
+	s.executedAt[key] = execution.Time
+	s.executions = append(s.executions, execution)
+
+	if len(s.executions) > maxExecutions {
+		s.executions = s.executions[len(s.executions)-maxExecutions:]
+	}
+
+	s.mu.Unlock()
+
+	s.emitter.Dispatch(EventExecution, execution)
+}
+
+// roundSize of the orders to the base increment of the product on both providers
+func (s *Service) roundSize(product string, size decimal.Decimal, providers ...string) (decimal.Decimal, error) {
+	for _, provider := range providers {
+		info, err := s.providers.Product(provider, product)
+		if err == exchanges.ErrProductsNotSupported {
+			continue
+		} else if err != nil {
+			return decimal.Zero, fmt.Errorf("%s: %v", provider, err)
+		}
+
+		if info.IsTrading() == false {
+			return decimal.Zero, fmt.Errorf("%s: product %s is %s", provider, product, info.Status)
+		}
+
+		size = info.RoundSize(size)
+
+		if err := info.ValidateSize(size); err != nil {
+			return decimal.Zero, fmt.Errorf("%s: %v", provider, err)
+		}
+	}
+
+	return size, nil
+}
+
+func (s *Service) newOrder(provider string, side exchanges.SideType, product string, size decimal.Decimal, price decimal.Decimal) *entity.Order {
+	order := &entity.Order{
+		Provider:  provider,
+		Side:      side,
+		ProductID: product,
+		Size:      size,
+		Price:     size.Mul(price),
+		FeeRate:   s.takerRate(provider),
+		CreatedAt: std.DateTimeFrom(s.now().UTC()),
+	}
+
+	order.Fee = order.Price.Mul(order.FeeRate)
+
+	return order
+}
+
+// checkBalance of currency on provider for amount
+func (s *Service) checkBalance(provider string, currency string, amount decimal.Decimal) error {
+	balance, err := s.providers.Balance(provider, currency)
+	if err != nil && err != exchanges.ErrBalanceNotFound {
+		return fmt.Errorf("%s %s balance: %v", provider, currency, err)
+	}
+
+	if balance.LessThan(amount) {
+		return fmt.Errorf("%s %s balance of %s is below %s", provider, currency, balance, amount)
+	}
+
+	return nil
+}
+
+// place the buy and sell orders of the opportunity, both legs are checked before placing the first one
+func (s *Service) place(o *Opportunity, execution *Execution) error {
+	if s.options.Size.Sign() <= 0 {
+		return ErrInvalidSize
+	}
+
+	product := exchanges.NewProductFromString(o.Product)
+
+	size, err := s.roundSize(o.Product, s.options.Size, o.BuyProvider, o.SellProvider)
+	if err != nil {
+		return err
+	}
+
+	execution.Size = size
+
+	buy := s.newOrder(o.BuyProvider, exchanges.SideTypeBuy, o.Product, size, o.BuyPrice)
+	sell := s.newOrder(o.SellProvider, exchanges.SideTypeSell, o.Product, size, o.SellPrice)
+
+	for _, order := range []*entity.Order{buy, sell} {
+		if err := s.risk.Check(order); err != nil {
+			return err
+		}
+	}
+
+	if err := s.checkBalance(o.BuyProvider, product.To, buy.GetCost()); err != nil {
+		return err
+	}
+
+	if err := s.checkBalance(o.SellProvider, product.From, size); err != nil {
+		return err
+	}
+
+	execution.BuyOrderID, err = s.placeOrder(buy)
+	if err != nil {
+		return fmt.Errorf("buy on %s: %v", o.BuyProvider, err)
+	}
+
+	execution.SellOrderID, err = s.placeOrder(sell)
+	if err != nil {
+		// the bought volume is held without offsetting sell, it is a position of the buy provider
+		execution.Unhedged = true
+
+		s.risk.Filled(buy)
+
+		log.Error().
+			Str("buy", o.BuyProvider).
+			Str("sell", o.SellProvider).
+			Msgf("Arbitrage unhedged position of %s %s on %s", size, o.Product, o.BuyProvider)
+
+		return fmt.Errorf("sell on %s after buy %s: %v", o.SellProvider, execution.BuyOrderID, err)
+	}
+
+	s.risk.Hedged(buy, sell)
+
+	return nil
+}
+
+// placeOrder on its provider, the orders of the virtual balances are emulated
+func (s *Service) placeOrder(order *entity.Order) (string, error) {
+	exchange, err := s.providers.Get(order.Provider)
+	if err != nil {
+		return "", err
+	}
+
+	if trader, ok := exchange.(exchanges.TradeProvider); ok {
+		placed, err := trader.PlaceOrder(&exchanges.OrderRequest{
+			Product: exchanges.NewProductFromString(order.ProductID),
+			Side:    order.Side,
+			Type:    exchanges.OrderTypeMarket,
+			Size:    order.Size,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return placed.ID, nil
+	}
+
+	if recorder, ok := exchange.Balance().(exchanges.FillRecorder); ok {
+		value := order.GetProceeds()
+		if order.Side == exchanges.SideTypeBuy {
+			value = order.GetCost()
+		}
+
+		return "", recorder.Fill(order.Side, exchanges.NewProductFromString(order.ProductID), order.Size, value)
+	}
+
+	return "", ErrOrdersNotSupported
+}
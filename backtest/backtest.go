@@ -0,0 +1,203 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package backtest replays ticker events through the algorithms signals of a campaign.
+package backtest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultHistorySize of the timeseries used by the algorithms
+const DefaultHistorySize = 5000
+
+const defaultAlgorithm = "trend"
+
+// Errors
+var (
+	ErrNoTicks     = errors.New("backtest without ticks")
+	ErrEmptyVolume = errors.New("backtest campaign volume is empty")
+)
+
+// Trade struct
+type Trade struct {
+	BuyTime       time.Time `json:"buy_time"`
+	BuyPrice      float64   `json:"buy_price"`
+	SellTime      time.Time `json:"sell_time"`
+	SellPrice     float64   `json:"sell_price"`
+	Size          float64   `json:"size"`
+	Profit        float64   `json:"profit"`
+	ProfitPercent float64   `json:"profit_percent"`
+}
+
+// Metrics struct
+type Metrics struct {
+	Profit        float64 `json:"profit"`
+	ReturnPercent float64 `json:"return_percent"`
+	Trades        int     `json:"trades"`
+	WinRate       float64 `json:"win_rate"`
+	MaxDrawdown   float64 `json:"max_drawdown"`
+	OpenProfit    float64 `json:"open_profit"`
+}
+
+// Result struct
+type Result struct {
+	Trades  []*Trade `json:"trades"`
+	Metrics *Metrics `json:"metrics"`
+}
+
+// Backtest struct
+type Backtest struct {
+	algorithms  algorithms.Manager
+	HistorySize int
+	// Fees schedules by provider, the orders pay the taker fee of the first tier
+	Fees exchanges.FeeSchedules
+}
+
+// New Backtest
+func New(algorithms algorithms.Manager) *Backtest {
+	return &Backtest{
+		algorithms:  algorithms,
+		HistorySize: DefaultHistorySize,
+	}
+}
+
+func (b Backtest) signal(name string) (algorithms.Algorithm, error) {
+	if name == "" {
+		name = defaultAlgorithm
+	}
+
+	return b.algorithms.Get(name)
+}
+
+// Run the campaign on ticks, orders are filled at the price of the tick deciding them.
+// The campaign is copied and never modified.
+func (b Backtest) Run(c *entity.Campaign, ticks []*exchanges.TickerEvent) (*Result, error) {
+	if len(ticks) == 0 {
+		return nil, ErrNoTicks
+	}
+
+	if c.Volume.Sign() <= 0 {
+		return nil, ErrEmptyVolume
+	}
+
+	buySignal, err := b.signal(c.BuyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	sellSignal, err := b.signal(c.SellAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := *c
+	campaign.State = entity.CampaignStateBuy
+	campaign.BuyOrder = nil
+	campaign.SellOrder = nil
+
+	ts := timeseries.New(b.HistorySize)
+
+	feeRate := b.Fees.Get(campaign.Provider).Rate(decimal.Zero, false)
+
+	result := &Result{
+		Trades:  []*Trade{},
+		Metrics: &Metrics{},
+	}
+
+	var trade *Trade
+
+	for _, event := range ticks {
+		ts.AddWithVolume(event.Time.Unix(), event.Price.InexactFloat64(), event.Size.InexactFloat64())
+
+		if campaign.IsState(entity.CampaignStateBuy) {
+			if buySignal.BuySignal(event, &campaign, campaign.BuyAlgorithmOptions, ts) == false {
+				continue
+			}
+
+			campaign.BuyOrder = &entity.Order{
+				Provider:  campaign.Provider,
+				Side:      exchanges.SideTypeBuy,
+				ProductID: campaign.ProductID,
+				Size:      campaign.Volume,
+				Price:     campaign.Volume.Mul(event.Price),
+				FeeRate:   feeRate,
+			}
+			campaign.BuyOrder.Fee = campaign.BuyOrder.Price.Mul(feeRate)
+			campaign.State = entity.CampaignStateSell
+
+			trade = &Trade{
+				BuyTime:  event.Time,
+				BuyPrice: event.Price.InexactFloat64(),
+				Size:     campaign.Volume.InexactFloat64(),
+			}
+
+			continue
+		}
+
+		if sellSignal.SellSignal(event, &campaign, campaign.SellAlgorithmOptions, ts) == false {
+			continue
+		}
+
+		trade.SellTime = event.Time
+		trade.SellPrice = event.Price.InexactFloat64()
+		trade.Profit = campaign.BuyOrder.GetMarginInCurrency(event.Price).InexactFloat64()
+		trade.ProfitPercent = campaign.BuyOrder.GetMarginInPercent(event.Price).InexactFloat64()
+
+		result.Trades = append(result.Trades, trade)
+
+		campaign.BuyOrder = nil
+		campaign.State = entity.CampaignStateBuy
+		trade = nil
+	}
+
+	if campaign.BuyOrder != nil {
+		result.Metrics.OpenProfit = campaign.BuyOrder.GetMarginInCurrency(ticks[len(ticks)-1].Price).InexactFloat64()
+	}
+
+	result.Metrics.compute(result.Trades, campaign.Volume.Mul(ticks[0].Price).InexactFloat64())
+
+	return result, nil
+}
+
+// compute the metrics of trades, the return is relative to the capital needed by the first order
+func (m *Metrics) compute(trades []*Trade, capital float64) {
+	m.Trades = len(trades)
+
+	if m.Trades == 0 {
+		return
+	}
+
+	wins := 0
+	peak := float64(0)
+
+	for _, trade := range trades {
+		m.Profit += trade.Profit
+
+		if trade.Profit > 0 {
+			wins++
+		}
+
+		if m.Profit > peak {
+			peak = m.Profit
+		}
+
+		if drawdown := peak - m.Profit; drawdown > m.MaxDrawdown {
+			m.MaxDrawdown = drawdown
+		}
+	}
+
+	m.WinRate = float64(wins) / float64(m.Trades) * 100
+
+	if capital > 0 {
+		m.ReturnPercent = m.Profit / capital * 100
+	}
+}
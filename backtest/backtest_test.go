@@ -0,0 +1,157 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package backtest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTicks(prices ...float64) []*exchanges.TickerEvent {
+	ticks := []*exchanges.TickerEvent{}
+
+	for i, price := range prices {
+		ticks = append(ticks, &exchanges.TickerEvent{
+			Product: exchanges.NewProduct("BTC", "EUR"),
+			Price:   decimal.NewFromFloat(price),
+			Size:    decimal.New(1, 0),
+			Time:    time.Unix(int64(i), 0),
+		})
+	}
+
+	return ticks
+}
+
+func newManager() algorithms.Manager {
+	m := algorithms.NewManager()
+
+	m.Add(algorithms.NewMACross())
+
+	return m
+}
+
+var options = map[string]interface{}{
+	algorithms.MACrossFastSize:      2,
+	algorithms.MACrossSlowSize:      4,
+	algorithms.MACrossCandlePeriod:  1,
+	algorithms.MACrossMinSeparation: 0.0,
+}
+
+func TestBacktestRun(t *testing.T) {
+	b := New(newManager())
+
+	campaign := &entity.Campaign{
+		Volume:               decimal.New(2, 0),
+		BuyAlgorithm:         "ma_cross",
+		BuyAlgorithmOptions:  options,
+		SellAlgorithm:        "ma_cross",
+		SellAlgorithmOptions: options,
+	}
+
+	result, err := b.Run(campaign, newTicks(10, 9, 8, 7, 6, 12, 13, 14, 8, 4, 3, 2))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(result.Trades))
+	assert.Equal(t, float64(13), result.Trades[0].BuyPrice)
+	assert.Equal(t, float64(4), result.Trades[0].SellPrice)
+	assert.Equal(t, float64(-18), result.Trades[0].Profit)
+
+	assert.Equal(t, float64(-18), result.Metrics.Profit)
+	assert.Equal(t, float64(-90), result.Metrics.ReturnPercent)
+	assert.Equal(t, 1, result.Metrics.Trades)
+	assert.Equal(t, float64(0), result.Metrics.WinRate)
+	assert.Equal(t, float64(18), result.Metrics.MaxDrawdown)
+
+	// the campaign is not modified
+	assert.Nil(t, campaign.BuyOrder)
+	assert.Equal(t, entity.CampaignState(""), campaign.State)
+}
+
+func TestBacktestRunWithFees(t *testing.T) {
+	b := New(newManager())
+	b.Fees = exchanges.FeeSchedules{
+		"gdax": exchanges.NewFeeSchedule(exchanges.FeeTier{Taker: decimal.RequireFromString("0.01")}),
+	}
+
+	campaign := &entity.Campaign{
+		Provider:             "gdax",
+		Volume:               decimal.New(2, 0),
+		BuyAlgorithm:         "ma_cross",
+		BuyAlgorithmOptions:  options,
+		SellAlgorithm:        "ma_cross",
+		SellAlgorithmOptions: options,
+	}
+
+	result, err := b.Run(campaign, newTicks(10, 9, 8, 7, 6, 12, 13, 14, 8, 4, 3, 2))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(result.Trades))
+	assert.InDelta(t, -18.34, result.Trades[0].Profit, 0.0000001)
+}
+
+func TestBacktestErrors(t *testing.T) {
+	b := New(newManager())
+
+	_, err := b.Run(&entity.Campaign{Volume: decimal.New(1, 0)}, nil)
+	assert.Equal(t, ErrNoTicks, err)
+
+	_, err = b.Run(&entity.Campaign{}, newTicks(1))
+	assert.Equal(t, ErrEmptyVolume, err)
+
+	_, err = b.Run(&entity.Campaign{Volume: decimal.New(1, 0), BuyAlgorithm: "ma_cross"}, newTicks(1))
+	assert.Equal(t, algorithms.ErrAlgorithmNotFound, err)
+}
+
+func TestMetrics(t *testing.T) {
+	m := &Metrics{}
+
+	m.compute([]*Trade{
+		{Profit: 10},
+		{Profit: -4},
+		{Profit: -2},
+		{Profit: 8},
+	}, 100)
+
+	assert.Equal(t, float64(12), m.Profit)
+	assert.Equal(t, float64(12), m.ReturnPercent)
+	assert.Equal(t, 4, m.Trades)
+	assert.Equal(t, float64(50), m.WinRate)
+	assert.Equal(t, float64(6), m.MaxDrawdown)
+}
+
+func TestReadCSV(t *testing.T) {
+	ticks, err := ReadCSV(exchanges.NewProduct("BTC", "EUR"), strings.NewReader("1,100.5,0.1\n2,101,0.2\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(ticks))
+	assert.Equal(t, "100.5", ticks[0].Price.String())
+	assert.Equal(t, "0.2", ticks[1].Size.String())
+	assert.Equal(t, int64(2), ticks[1].Time.Unix())
+
+	_, err = ReadCSV(exchanges.NewProduct("BTC", "EUR"), strings.NewReader("1,foo,0.1\n"))
+	assert.EqualError(t, err, `line 1: invalid price: can't convert foo to decimal`)
+}
+
+func TestTicksFromTimeseries(t *testing.T) {
+	ts := timeseries.New(10)
+
+	ts.AddWithVolume(1, 100, 0.5)
+	ts.AddWithVolume(2, 101, 0.1)
+
+	ticks := TicksFromTimeseries(exchanges.NewProduct("BTC", "EUR"), ts)
+
+	assert.Equal(t, 2, len(ticks))
+	assert.Equal(t, "101", ticks[1].Price.String())
+	assert.Equal(t, "0.5", ticks[0].Size.String())
+	assert.Equal(t, "BTC-EUR", ticks[0].Product.String())
+}
@@ -0,0 +1,82 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/shopspring/decimal"
+)
+
+// TicksFromTimeseries converts the DataPoint of timeseries to ticker events of product
+func TicksFromTimeseries(product exchanges.Product, ts *timeseries.Timeseries) []*exchanges.TickerEvent {
+	points := ts.All()
+	volumes := ts.Volumes()
+
+	ticks := make([]*exchanges.TickerEvent, 0, len(points))
+
+	for i, point := range points {
+		event := &exchanges.TickerEvent{
+			Product: product,
+			Price:   decimal.NewFromFloat(point.Value),
+			Time:    time.Unix(point.Time, 0).UTC(),
+		}
+
+		if i < len(volumes) {
+			event.Size = decimal.NewFromFloat(volumes[i])
+		}
+
+		ticks = append(ticks, event)
+	}
+
+	return ticks
+}
+
+// ReadCSV reads ticker events of product from csv records formatted as: unix time, price, size
+func ReadCSV(product exchanges.Product, r io.Reader) ([]*exchanges.TickerEvent, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+
+	ticks := []*exchanges.TickerEvent{}
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return ticks, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid time: %v", line, err)
+		}
+
+		price, err := decimal.NewFromString(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price: %v", line, err)
+		}
+
+		size, err := decimal.NewFromString(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid size: %v", line, err)
+		}
+
+		ticks = append(ticks, &exchanges.TickerEvent{
+			Product: product,
+			Price:   price,
+			Size:    size,
+			Time:    time.Unix(t, 0).UTC(),
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+)
+
+// AlgorithmsConfiguration struct
+type AlgorithmsConfiguration struct {
+	// Composites algorithms by name, the definition keys are the composite options without prefix
+	Composites map[string]map[string]interface{}
+}
+
+func newAlgorithmsConfiguration(composites map[string]interface{}) *AlgorithmsConfiguration {
+	cfg := &AlgorithmsConfiguration{
+		Composites: map[string]map[string]interface{}{},
+	}
+
+	for name, definition := range composites {
+		if m, ok := normalize(definition).(map[string]interface{}); ok {
+			cfg.Composites[name] = m
+		}
+	}
+
+	return cfg
+}
+
+// normalize converts the map[interface{}]interface{} decoded from yaml to map[string]interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+
+		for key, val := range v {
+			m[fmt.Sprintf("%v", key)] = normalize(val)
+		}
+
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+
+		for key, val := range v {
+			m[key] = normalize(val)
+		}
+
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+
+		for i, val := range v {
+			s[i] = normalize(val)
+		}
+
+		return s
+	default:
+		return value
+	}
+}
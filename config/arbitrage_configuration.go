@@ -0,0 +1,31 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ArbitrageConfiguration struct
+type ArbitrageConfiguration struct {
+	// Enabled starts the arbitrage monitor
+	Enabled bool
+	// Products compared between the providers
+	Products []string
+	// Providers compared, all the providers when empty
+	Providers []string
+	// Threshold of the spread in percent net of fees
+	Threshold decimal.Decimal
+	// MaxPriceAge after which the last price of a provider is ignored
+	MaxPriceAge time.Duration
+	// Execute places the offsetting orders of the opportunities
+	Execute bool
+	// Size in base currency of the executed orders
+	Size decimal.Decimal
+	// Cooldown between two executions of the same opportunity
+	Cooldown time.Duration
+}
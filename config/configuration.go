@@ -10,10 +10,16 @@ import (
 
 // Configuration struct
 type Configuration struct {
-	Logger    *LoggerConfiguration
-	Server    *ServerConfiguration
-	Database  *DatabaseConfiguration
-	Exchanges *ExchangesConfiguration
+	Logger     *LoggerConfiguration
+	Server     *ServerConfiguration
+	Database   *DatabaseConfiguration
+	Exchanges  ExchangesConfiguration
+	Algorithms *AlgorithmsConfiguration
+	Risk       *RiskConfiguration
+	Engine     *EngineConfiguration
+	Portfolio  *PortfolioConfiguration
+	Arbitrage  *ArbitrageConfiguration
+	Fees       map[string][]FeeTierConfiguration
 }
 
 // NewConfiguration constructor
@@ -24,17 +30,50 @@ func NewConfiguration(options *viper.Viper) *Configuration {
 			LevelName: options.GetString("logger.level"),
 		},
 		Server: &ServerConfiguration{
-			Port:  options.GetInt("server.port"),
-			Debug: options.GetBool("server.debug"),
+			Port:            options.GetInt("server.port"),
+			Debug:           options.GetBool("server.debug"),
+			ShutdownTimeout: options.GetDuration("server.shutdown_timeout"),
 		},
 		Database: &DatabaseConfiguration{
 			Path: options.GetString("database.path"),
 		},
-		Exchanges: &ExchangesConfiguration{
-			GDAX: &GDAXConfiguration{
-				Key:    options.GetString("exchanges.gdax.key"),
-				Secret: options.GetString("exchanges.gdax.secret"),
-			},
+		Exchanges: newExchangesConfiguration(options),
+		Portfolio: &PortfolioConfiguration{
+			Quote:    options.GetString("portfolio.quote"),
+			Interval: options.GetDuration("portfolio.interval"),
+			Products: options.GetStringMapStringSlice("portfolio.products"),
 		},
+		Arbitrage:  newArbitrageConfiguration(options),
+		Fees:       newFeesConfiguration(options.GetStringMap("fees")),
+		Algorithms: newAlgorithmsConfiguration(options.GetStringMap("algorithms.composites")),
+		Engine: &EngineConfiguration{
+			FeedTimeout:       options.GetDuration("engine.feed_timeout"),
+			MaxOrderErrors:    options.GetInt("engine.max_order_errors"),
+			RejectionCooldown: options.GetDuration("engine.rejection_cooldown"),
+		},
+		Risk: &RiskConfiguration{
+			MaxPositionSize:    toDecimalMap(options.GetStringMap("risk.max_position_size")),
+			MaxExposure:        toDecimalMap(options.GetStringMap("risk.max_exposure")),
+			MaxOrdersPerMinute: options.GetInt("risk.max_orders_per_minute"),
+			MaxDailyLoss:       toDecimalMap(options.GetStringMap("risk.max_daily_loss")),
+			MinBalanceReserve:  toDecimalMap(options.GetStringMap("risk.min_balance_reserve")),
+		},
+	}
+}
+
+// newArbitrageConfiguration reads the arbitrage section
+func newArbitrageConfiguration(options *viper.Viper) *ArbitrageConfiguration {
+	threshold, _ := toDecimal(options.Get("arbitrage.threshold"))
+	size, _ := toDecimal(options.Get("arbitrage.size"))
+
+	return &ArbitrageConfiguration{
+		Enabled:     options.GetBool("arbitrage.enabled"),
+		Products:    options.GetStringSlice("arbitrage.products"),
+		Providers:   options.GetStringSlice("arbitrage.providers"),
+		Threshold:   threshold,
+		MaxPriceAge: options.GetDuration("arbitrage.max_price_age"),
+		Execute:     options.GetBool("arbitrage.execute"),
+		Size:        size,
+		Cooldown:    options.GetDuration("arbitrage.cooldown"),
 	}
 }
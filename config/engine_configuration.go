@@ -0,0 +1,19 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"time"
+)
+
+// EngineConfiguration struct
+type EngineConfiguration struct {
+	// FeedTimeout halts the trading when a feed is silent for this duration, 0 disables it
+	FeedTimeout time.Duration
+	// MaxOrderErrors halts the trading after consecutive order errors, 0 disables it
+	MaxOrderErrors int
+	// RejectionCooldown records again an unchanged risk rejection of a campaign after this duration
+	RejectionCooldown time.Duration
+}
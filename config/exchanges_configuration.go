@@ -4,13 +4,78 @@
 
 package config
 
-// ExchangesConfiguration struct
-type ExchangesConfiguration struct {
-	GDAX *GDAXConfiguration
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/viper"
+)
+
+// defaultExchange is registered when no exchange is configured
+const defaultExchange = "gdax"
+
+// ExchangesConfiguration of the exchange instances by name
+type ExchangesConfiguration map[string]*ExchangeConfiguration
+
+// ExchangeConfiguration of an exchange instance
+type ExchangeConfiguration struct {
+	// Type of exchange (gdax, kraken, binance or paper), the instance name by default
+	Type string
+	// Enabled registers the instance, true by default
+	Enabled    bool
+	Key        string
+	Secret     string
+	Passphrase string
+	// Sandbox connects to the test environment of the exchange
+	Sandbox bool
+	// API url of the rest api, the default of the type when empty
+	API string
+	// WebSocketAPI url, the default of the type when empty
+	WebSocketAPI string
+	// Source instance of the ticker of a paper exchange
+	Source string
+	// Balances by currency of a paper exchange
+	Balances map[string]decimal.Decimal
 }
 
-// GDAXConfiguration struct
-type GDAXConfiguration struct {
-	Key    string
-	Secret string
+// newExchangesConfiguration reads the instances of the exchanges section,
+// the fields are read by key to keep the environment variables overrides.
+func newExchangesConfiguration(options *viper.Viper) ExchangesConfiguration {
+	cfg := ExchangesConfiguration{}
+
+	for name := range options.GetStringMap("exchanges") {
+		prefix := "exchanges." + name + "."
+
+		exchange := &ExchangeConfiguration{
+			Type:         strings.ToLower(options.GetString(prefix + "type")),
+			Enabled:      true,
+			Key:          options.GetString(prefix + "key"),
+			Secret:       options.GetString(prefix + "secret"),
+			Passphrase:   options.GetString(prefix + "passphrase"),
+			Sandbox:      options.GetBool(prefix + "sandbox"),
+			API:          options.GetString(prefix + "api"),
+			WebSocketAPI: options.GetString(prefix + "websocket_api"),
+			Source:       options.GetString(prefix + "source"),
+			Balances:     toDecimalMap(options.GetStringMap(prefix + "balances")),
+		}
+
+		if exchange.Type == "" {
+			exchange.Type = name
+		}
+
+		if options.IsSet(prefix + "enabled") {
+			exchange.Enabled = options.GetBool(prefix + "enabled")
+		}
+
+		cfg[name] = exchange
+	}
+
+	if len(cfg) == 0 {
+		cfg[defaultExchange] = &ExchangeConfiguration{
+			Type:    defaultExchange,
+			Enabled: true,
+		}
+	}
+
+	return cfg
 }
@@ -0,0 +1,48 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// FeeTierConfiguration struct
+type FeeTierConfiguration struct {
+	// Volume traded during the last 30 days from which the tier applies
+	Volume decimal.Decimal
+	Maker  decimal.Decimal
+	Taker  decimal.Decimal
+}
+
+// newFeesConfiguration returns the fee tiers by provider
+func newFeesConfiguration(fees map[string]interface{}) map[string][]FeeTierConfiguration {
+	cfg := map[string][]FeeTierConfiguration{}
+
+	for provider, value := range fees {
+		tiers, ok := normalize(value).([]interface{})
+		if ok == false {
+			continue
+		}
+
+		for _, t := range tiers {
+			tier, ok := t.(map[string]interface{})
+			if ok == false {
+				continue
+			}
+
+			volume, _ := toDecimal(tier["volume"])
+			maker, _ := toDecimal(tier["maker"])
+			taker, _ := toDecimal(tier["taker"])
+
+			cfg[provider] = append(cfg[provider], FeeTierConfiguration{
+				Volume: volume,
+				Maker:  maker,
+				Taker:  taker,
+			})
+		}
+	}
+
+	return cfg
+}
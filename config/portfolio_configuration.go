@@ -0,0 +1,19 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"time"
+)
+
+// PortfolioConfiguration struct
+type PortfolioConfiguration struct {
+	// Quote currency of the history
+	Quote string
+	// Interval between two snapshots of the history
+	Interval time.Duration
+	// Products followed by provider to value the assets, including the cross rates
+	Products map[string][]string
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// RiskConfiguration struct
+type RiskConfiguration struct {
+	// MaxPositionSize in base currency by product
+	MaxPositionSize map[string]decimal.Decimal
+	// MaxExposure in quote currency
+	MaxExposure map[string]decimal.Decimal
+	// MaxOrdersPerMinute on all products
+	MaxOrdersPerMinute int
+	// MaxDailyLoss net of the realized pnl of the day in quote currency
+	MaxDailyLoss map[string]decimal.Decimal
+	// MinBalanceReserve kept on the account by currency
+	MinBalanceReserve map[string]decimal.Decimal
+}
+
+// toDecimalMap converts the config map to decimals with upper case keys,
+// the keys are lower cased by the configuration loader.
+func toDecimalMap(values map[string]interface{}) map[string]decimal.Decimal {
+	m := map[string]decimal.Decimal{}
+
+	for key, value := range values {
+		if f, ok := toDecimal(value); ok {
+			m[strings.ToUpper(key)] = f
+		}
+	}
+
+	return m
+}
+
+func toDecimal(value interface{}) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case float64:
+		return decimal.NewFromFloat(v), true
+	case int:
+		return decimal.New(int64(v), 0), true
+	case string:
+		d, err := decimal.NewFromString(v)
+
+		return d, err == nil
+	}
+
+	return decimal.Zero, false
+}
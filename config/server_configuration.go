@@ -4,8 +4,12 @@
 
 package config
 
+import "time"
+
 // ServerConfiguration struct
 type ServerConfiguration struct {
 	Port  int
 	Debug bool
+	// ShutdownTimeout of the graceful shutdown on SIGINT and SIGTERM
+	ShutdownTimeout time.Duration
 }
@@ -0,0 +1,41 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/euskadi31/cryptotrader/arbitrage"
+	"github.com/euskadi31/go-server"
+)
+
+// ArbitrageController struct
+type ArbitrageController struct {
+	arbitrage *arbitrage.Service
+}
+
+// NewArbitrageController constructor
+func NewArbitrageController(arbitrage *arbitrage.Service) *ArbitrageController {
+	return &ArbitrageController{
+		arbitrage: arbitrage,
+	}
+}
+
+// Mount implements server.Controller
+func (c *ArbitrageController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/arbitrage", c.GetArbitrageHandler).Methods(http.MethodGet)
+}
+
+// GetArbitrageHandler endpoint
+func (c *ArbitrageController) GetArbitrageHandler(w http.ResponseWriter, r *http.Request) {
+	options := c.arbitrage.Options()
+
+	server.JSON(w, http.StatusOK, map[string]interface{}{
+		"threshold":     options.Threshold,
+		"execute":       options.Execute,
+		"opportunities": c.arbitrage.Opportunities(),
+		"executions":    c.arbitrage.Executions(),
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/euskadi31/cryptotrader/backtest"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/optimizer"
+	"github.com/euskadi31/cryptotrader/trader"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/euskadi31/go-server"
+	"github.com/rs/zerolog/log"
+)
+
+var errCampaignRequired = errors.New("campaign is required")
+
+// BacktestController struct
+type BacktestController struct {
+	engine    *trader.Engine
+	backtest  *backtest.Backtest
+	optimizer *optimizer.Optimizer
+}
+
+// NewBacktestController constructor
+func NewBacktestController(engine *trader.Engine, algorithms algorithms.Manager) *BacktestController {
+	bt := backtest.New(algorithms)
+	bt.Fees = engine.Fees()
+
+	return &BacktestController{
+		engine:    engine,
+		backtest:  bt,
+		optimizer: optimizer.New(bt),
+	}
+}
+
+// Mount implements server.Controller
+func (c *BacktestController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/backtests", c.PostBacktestHandler).Methods(http.MethodPost)
+	r.AddRouteFunc("/api/v1/backtests/optimizations", c.PostOptimizationHandler).Methods(http.MethodPost)
+}
+
+// ticks returns the ticker events recorded by the engine for the campaign product
+func (c *BacktestController) ticks(campaign *entity.Campaign) ([]*exchanges.TickerEvent, error) {
+	product := exchanges.NewProductFromString(campaign.ProductID)
+
+	ts, err := c.engine.GetTimeserie(fmt.Sprintf("%s-%s", campaign.Provider, product.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return backtest.TicksFromTimeseries(product, ts), nil
+}
+
+// PostBacktestHandler endpoint
+func (c *BacktestController) PostBacktestHandler(w http.ResponseWriter, r *http.Request) {
+	campaign := &entity.Campaign{}
+
+	if err := json.NewDecoder(r.Body).Decode(campaign); err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	ticks, err := c.ticks(campaign)
+	if err != nil {
+		server.FailureFromError(w, http.StatusNotFound, err)
+
+		return
+	}
+
+	result, err := c.backtest.Run(campaign, ticks)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, result)
+}
+
+// PostOptimizationHandler endpoint, the result is a csv table with ?format=csv
+func (c *BacktestController) PostOptimizationHandler(w http.ResponseWriter, r *http.Request) {
+	req := &optimizer.Request{}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if req.Campaign == nil {
+		server.FailureFromError(w, http.StatusBadRequest, errCampaignRequired)
+
+		return
+	}
+
+	ticks, err := c.ticks(req.Campaign)
+	if err != nil {
+		server.FailureFromError(w, http.StatusNotFound, err)
+
+		return
+	}
+
+	result, err := c.optimizer.Run(req, ticks)
+	if err != nil {
+		log.Error().Err(err).Msg("")
+
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		if err := optimizer.WriteCSV(w, result); err != nil {
+			log.Error().Err(err).Msg("Write optimization csv failed")
+		}
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, result)
+}
@@ -11,7 +11,10 @@ import (
 	"time"
 
 	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/euskadi31/cryptotrader/database"
 	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/risk"
 	"github.com/euskadi31/cryptotrader/trader"
 	"github.com/euskadi31/go-server"
 	"github.com/euskadi31/go-std"
@@ -23,6 +26,7 @@ import (
 type CampaignController struct {
 	db     *storm.DB
 	engine *trader.Engine
+	orders *database.OrderRepository
 }
 
 // NewCampaignController constructor
@@ -34,6 +38,7 @@ func NewCampaignController(db *storm.DB, engine *trader.Engine) *CampaignControl
 	return &CampaignController{
 		db:     db,
 		engine: engine,
+		orders: database.NewOrderRepository(db),
 	}
 }
 
@@ -44,6 +49,13 @@ func (c *CampaignController) Mount(r *server.Router) {
 	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}", c.PutCampaignHandler).Methods(http.MethodPut)
 	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}", c.GetCampaignHandler).Methods(http.MethodGet)
 	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}", c.DeleteCampaignHandler).Methods(http.MethodDelete)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/cycles", c.GetCampaignCyclesHandler).Methods(http.MethodGet)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/orders", c.GetCampaignOrdersHandler).Methods(http.MethodGet)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/pause", c.actionHandler(c.engine.PauseCampaign)).Methods(http.MethodPost)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/resume", c.actionHandler(c.engine.ResumeCampaign)).Methods(http.MethodPost)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/buy-now", c.actionHandler(c.engine.BuyCampaign)).Methods(http.MethodPost)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/sell-now", c.actionHandler(c.engine.SellCampaign)).Methods(http.MethodPost)
+	r.AddRouteFunc("/api/v1/campaigns/{id:[0-9]+}/close", c.actionHandler(c.engine.CloseCampaign)).Methods(http.MethodPost)
 }
 
 // GetListCampaignHandler endpoint
@@ -58,13 +70,20 @@ func (c *CampaignController) GetListCampaignHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if err := c.orders.Populate(campaigns...); err != nil {
+		log.Error().Err(err).Msg("Populate campaign orders")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
 	server.JSON(w, http.StatusOK, campaigns)
 }
 
 func (c *CampaignController) saveCampaign(r *http.Request) (*entity.Campaign, error) {
-	campaign := &entity.Campaign{
-		State: entity.CampaignStateBuy,
-	}
+	// the state and the orders of the campaign are set by the engine
+	campaign := &entity.Campaign{}
 
 	id, isEdit := mux.Vars(r)["id"]
 
@@ -81,6 +100,7 @@ func (c *CampaignController) saveCampaign(r *http.Request) (*entity.Campaign, er
 		campaign.ID = i
 		campaign.UpdatedAt = std.DateTimeFrom(time.Now().UTC())
 	} else {
+		campaign.ID = 0
 		campaign.CreatedAt = std.DateTimeFrom(time.Now().UTC())
 	}
 
@@ -95,13 +115,36 @@ func (c *CampaignController) saveCampaign(r *http.Request) (*entity.Campaign, er
 	return campaign, nil
 }
 
+// statusFromError returns the http status of the error returned by the engine
+func statusFromError(err error) int {
+	if _, ok := err.(*trader.ValidationError); ok {
+		return http.StatusBadRequest
+	}
+
+	switch err.(type) {
+	case *trader.StateError, *trader.PriceError:
+		return http.StatusConflict
+	case *risk.Error:
+		return http.StatusUnprocessableEntity
+	}
+
+	switch err {
+	case trader.ErrCampaignNotFound:
+		return http.StatusNotFound
+	case trader.ErrCampaignPositionOpen, trader.ErrTradingHalted:
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
 // PostCampaignHandler endpoint
 func (c *CampaignController) PostCampaignHandler(w http.ResponseWriter, r *http.Request) {
 	campaign, err := c.saveCampaign(r)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 
-		server.FailureFromError(w, http.StatusInternalServerError, err)
+		server.FailureFromError(w, statusFromError(err), err)
 
 		return
 	}
@@ -115,7 +158,7 @@ func (c *CampaignController) PutCampaignHandler(w http.ResponseWriter, r *http.R
 	if err != nil {
 		log.Error().Err(err).Msg("")
 
-		server.FailureFromError(w, http.StatusInternalServerError, err)
+		server.FailureFromError(w, statusFromError(err), err)
 
 		return
 	}
@@ -123,8 +166,12 @@ func (c *CampaignController) PutCampaignHandler(w http.ResponseWriter, r *http.R
 	server.JSON(w, http.StatusOK, campaign)
 }
 
+// fetchCampaign of the route with its orders, an id that is not an integer returns a strconv error
 func (c *CampaignController) fetchCampaign(r *http.Request) (*entity.Campaign, error) {
-	id := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, err
+	}
 
 	campaign := &entity.Campaign{}
 
@@ -132,41 +179,130 @@ func (c *CampaignController) fetchCampaign(r *http.Request) (*entity.Campaign, e
 		return nil, err
 	}
 
+	if err := c.orders.Populate(campaign); err != nil {
+		return nil, err
+	}
+
 	return campaign, nil
 }
 
-// GetCampaignHandler endpoint
-func (c *CampaignController) GetCampaignHandler(w http.ResponseWriter, r *http.Request) {
+// campaignFromRequest fetches the campaign of the route and writes the failure when it cannot
+func (c *CampaignController) campaignFromRequest(w http.ResponseWriter, r *http.Request) (*entity.Campaign, bool) {
 	campaign, err := c.fetchCampaign(r)
+	if _, ok := err.(*strconv.NumError); ok {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return nil, false
+	}
+
+	if err == storm.ErrNotFound {
+		server.FailureFromError(w, http.StatusNotFound, err)
+
+		return nil, false
+	}
+
 	if err != nil {
 		log.Error().Err(err).Msg("")
 
 		server.FailureFromError(w, http.StatusInternalServerError, err)
 
+		return nil, false
+	}
+
+	return campaign, true
+}
+
+// GetCampaignHandler endpoint
+func (c *CampaignController) GetCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	campaign, ok := c.campaignFromRequest(w, r)
+	if ok == false {
 		return
 	}
 
 	server.JSON(w, http.StatusOK, campaign)
 }
 
-// DeleteCampaignHandler endpoint
+// DeleteCampaignHandler endpoint, a campaign with an open position is deleted with ?force=true
 func (c *CampaignController) DeleteCampaignHandler(w http.ResponseWriter, r *http.Request) {
-	campaign, err := c.fetchCampaign(r)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	force := false
+
+	if value := r.URL.Query().Get("force"); value != "" {
+		force, err = strconv.ParseBool(value)
+		if err != nil {
+			server.FailureFromError(w, http.StatusBadRequest, err)
+
+			return
+		}
+	}
+
+	campaign, err := c.engine.DeleteCampaign(id, force)
 	if err != nil {
 		log.Error().Err(err).Msg("")
 
-		server.FailureFromError(w, http.StatusInternalServerError, err)
+		server.FailureFromError(w, statusFromError(err), err)
 
 		return
 	}
 
-	if err := c.db.DeleteStruct(campaign); err != nil {
-		log.Error().Err(err).Msg("")
+	server.JSON(w, http.StatusOK, campaign)
+}
+
+// GetCampaignCyclesHandler endpoint, the completed cycles of the campaign ordered by closing time
+func (c *CampaignController) GetCampaignCyclesHandler(w http.ResponseWriter, r *http.Request) {
+	campaign, ok := c.campaignFromRequest(w, r)
+	if ok == false {
+		return
+	}
+
+	roundTrips := []*entity.RoundTrip{}
+
+	if err := c.db.Select(q.Eq("CampaignID", campaign.ID)).OrderBy("ClosedAt").Find(&roundTrips); err != nil && err != storm.ErrNotFound {
+		log.Error().Err(err).Msg("Find campaign cycles")
 
 		server.FailureFromError(w, http.StatusInternalServerError, err)
 
 		return
 	}
 
-	server.JSON(w, http.StatusOK, campaign)
+	server.JSON(w, http.StatusOK, roundTrips)
+}
+
+// GetCampaignOrdersHandler endpoint, the orders of the campaign ordered by id
+func (c *CampaignController) GetCampaignOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	campaign, ok := c.campaignFromRequest(w, r)
+	if ok == false {
+		return
+	}
+
+	server.JSON(w, http.StatusOK, campaign.Orders)
+}
+
+// actionHandler runs the engine action on the campaign of the route
+func (c *CampaignController) actionHandler(action func(id int) (*entity.Campaign, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			server.FailureFromError(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		campaign, err := action(id)
+		if err != nil {
+			log.Error().Err(err).Int("campaign", id).Msg("Campaign action")
+
+			server.FailureFromError(w, statusFromError(err), err)
+
+			return
+		}
+
+		server.JSON(w, http.StatusOK, campaign)
+	}
 }
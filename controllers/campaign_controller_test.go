@@ -0,0 +1,102 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/risk"
+	"github.com/euskadi31/cryptotrader/trader"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/euskadi31/go-eventemitter"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCampaignRouter(t *testing.T) (*mux.Router, *storm.DB, func()) {
+	dir, err := ioutil.TempDir("", "controllers")
+	assert.NoError(t, err)
+
+	db, err := storm.Open(filepath.Join(dir, "cryptotrader.db"))
+	assert.NoError(t, err)
+	assert.NoError(t, database.Migrate(db))
+
+	engine := trader.NewEngine(db, exchanges.NewManager(), algorithms.NewManager(), risk.NewManager(nil), eventemitter.New(), nil)
+
+	controller := NewCampaignController(db, engine)
+
+	// the id of the routes is not constrained to reach the handlers with an invalid id
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/campaigns/{id}/cycles", controller.GetCampaignCyclesHandler)
+	router.HandleFunc("/api/v1/campaigns/{id}/orders", controller.GetCampaignOrdersHandler)
+
+	return router, db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func serveCampaign(router *mux.Router, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return w
+}
+
+func TestCampaignControllerCyclesAndOrders(t *testing.T) {
+	router, db, cleanup := newTestCampaignRouter(t)
+	defer cleanup()
+
+	assert.NoError(t, db.Save(&entity.Campaign{
+		Provider:  "gdax",
+		ProductID: "BTC-EUR",
+		State:     entity.CampaignStateBuy,
+	}))
+
+	assert.NoError(t, db.Save(&entity.Order{
+		Provider:   "gdax",
+		CampaignID: 1,
+		ProductID:  "BTC-EUR",
+		Side:       exchanges.SideTypeBuy,
+	}))
+
+	assert.NoError(t, db.Save(&entity.RoundTrip{
+		CampaignID: 1,
+		ClosedAt:   1,
+	}))
+
+	w := serveCampaign(router, "/api/v1/campaigns/1/cycles")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	roundTrips := []*entity.RoundTrip{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&roundTrips))
+	assert.Equal(t, 1, len(roundTrips))
+
+	w = serveCampaign(router, "/api/v1/campaigns/1/orders")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	orders := []*entity.Order{}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&orders))
+	assert.Equal(t, 1, len(orders))
+
+	for _, endpoint := range []string{"cycles", "orders"} {
+		w = serveCampaign(router, "/api/v1/campaigns/2/"+endpoint)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serveCampaign(router, "/api/v1/campaigns/foo/"+endpoint)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
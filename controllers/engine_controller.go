@@ -0,0 +1,75 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/euskadi31/cryptotrader/trader"
+	"github.com/euskadi31/go-server"
+	"github.com/rs/zerolog/log"
+)
+
+// EngineController struct
+type EngineController struct {
+	engine *trader.Engine
+}
+
+// NewEngineController constructor
+func NewEngineController(engine *trader.Engine) *EngineController {
+	return &EngineController{
+		engine: engine,
+	}
+}
+
+// Mount implements server.Controller
+func (c *EngineController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/engine", c.GetEngineHandler).Methods(http.MethodGet)
+	r.AddRouteFunc("/api/v1/engine/halt", c.PostHaltHandler).Methods(http.MethodPost)
+	r.AddRouteFunc("/api/v1/engine/resume", c.PostResumeHandler).Methods(http.MethodPost)
+}
+
+// GetEngineHandler endpoint
+func (c *EngineController) GetEngineHandler(w http.ResponseWriter, r *http.Request) {
+	server.JSON(w, http.StatusOK, c.engine.HaltState())
+}
+
+// PostHaltHandler endpoint, the body is optional
+func (c *EngineController) PostHaltHandler(w http.ResponseWriter, r *http.Request) {
+	req := &trader.HaltRequest{}
+
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	result, err := c.engine.Halt(req)
+	if err != nil {
+		log.Error().Err(err).Msg("Halt engine")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, result)
+}
+
+// PostResumeHandler endpoint
+func (c *EngineController) PostResumeHandler(w http.ResponseWriter, r *http.Request) {
+	state, err := c.engine.Resume()
+	if err != nil {
+		log.Error().Err(err).Msg("Resume engine")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, state)
+}
@@ -0,0 +1,32 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+)
+
+// optionsFromQuery converts query string values to algorithm options,
+// numbers and booleans are typed like options decoded from JSON.
+func optionsFromQuery(query url.Values) algorithms.Options {
+	options := algorithms.Options{}
+
+	for key := range query {
+		value := query.Get(key)
+
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			options.Set(key, f)
+		} else if b, err := strconv.ParseBool(value); err == nil {
+			options.Set(key, b)
+		} else {
+			options.Set(key, value)
+		}
+	}
+
+	return options
+}
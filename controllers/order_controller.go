@@ -0,0 +1,89 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/go-server"
+	"github.com/rs/zerolog/log"
+)
+
+// OrderController struct
+type OrderController struct {
+	orders *database.OrderRepository
+}
+
+// NewOrderController constructor
+func NewOrderController(db *storm.DB) *OrderController {
+	if err := db.Init(&entity.Order{}); err != nil {
+		log.Fatal().Err(err).Msg("Initialize bucket for Order")
+	}
+
+	return &OrderController{
+		orders: database.NewOrderRepository(db),
+	}
+}
+
+// Mount implements server.Controller
+func (c *OrderController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/orders", c.GetListOrderHandler).Methods(http.MethodGet)
+}
+
+// GetListOrderHandler endpoint, the orders can be filtered by campaign_id, provider, product_id, side, state
+// and by the from and to timestamps of their creation
+func (c *OrderController) GetListOrderHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := &database.OrderFilter{
+		Provider:  query.Get("provider"),
+		ProductID: strings.ToUpper(query.Get("product_id")),
+		Side:      exchanges.SideType(query.Get("side")),
+		State:     entity.OrderState(query.Get("state")),
+	}
+
+	if value := query.Get("campaign_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			server.FailureFromError(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		filter.CampaignID = id
+	}
+
+	var err error
+
+	if filter.From, err = timeFromQuery(query, "from", time.Time{}); err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	if filter.To, err = timeFromQuery(query, "to", time.Time{}); err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	orders, err := c.orders.Find(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Find orders")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, orders)
+}
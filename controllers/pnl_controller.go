@@ -0,0 +1,86 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/ledger"
+	"github.com/euskadi31/go-server"
+	"github.com/rs/zerolog/log"
+)
+
+// PnLController struct
+type PnLController struct {
+	ledger *ledger.Service
+}
+
+// NewPnLController constructor
+func NewPnLController(db *storm.DB, ledger *ledger.Service) *PnLController {
+	if err := db.Init(&entity.RoundTrip{}); err != nil {
+		log.Fatal().Err(err).Msg("Initialize bucket for RoundTrip")
+	}
+
+	return &PnLController{
+		ledger: ledger,
+	}
+}
+
+// Mount implements server.Controller
+func (c *PnLController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/pnl", c.GetPnLHandler).Methods(http.MethodGet)
+}
+
+// GetPnLHandler endpoint, the round trips are filtered by the from and to timestamps
+// (last 30 days by default) and by campaign_id
+func (c *PnLController) GetPnLHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	to, err := timeFromQuery(query, "to", time.Now())
+	if err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	from, err := timeFromQuery(query, "from", to.AddDate(0, 0, -30))
+	if err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	campaignID := 0
+
+	if value := query.Get("campaign_id"); value != "" {
+		campaignID, err = strconv.Atoi(value)
+		if err != nil {
+			server.FailureFromError(w, http.StatusBadRequest, err)
+
+			return
+		}
+	}
+
+	report, err := c.ledger.Report(from, to, campaignID)
+	if err == storm.ErrNotFound {
+		server.FailureFromError(w, http.StatusNotFound, err)
+
+		return
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("PnL report")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, report)
+}
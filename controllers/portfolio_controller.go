@@ -0,0 +1,101 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/portfolio"
+	"github.com/euskadi31/go-server"
+	"github.com/rs/zerolog/log"
+)
+
+// PortfolioController struct
+type PortfolioController struct {
+	portfolio *portfolio.Service
+}
+
+// NewPortfolioController constructor
+func NewPortfolioController(portfolio *portfolio.Service) *PortfolioController {
+	return &PortfolioController{
+		portfolio: portfolio,
+	}
+}
+
+// Mount implements server.Controller
+func (c *PortfolioController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/portfolio", c.GetPortfolioHandler).Methods(http.MethodGet)
+}
+
+// timeFromQuery parses the unix timestamp of key in query
+func timeFromQuery(query url.Values, key string, value time.Time) (time.Time, error) {
+	param := query.Get(key)
+	if param == "" {
+		return value, nil
+	}
+
+	i, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return value, err
+	}
+
+	return time.Unix(i, 0), nil
+}
+
+// GetPortfolioHandler endpoint, the history is filtered by the from and to timestamps (last 24 hours by default)
+func (c *PortfolioController) GetPortfolioHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	quote := query.Get("quote")
+	if quote == "" {
+		quote = c.portfolio.Quote()
+	}
+
+	to, err := timeFromQuery(query, "to", time.Now())
+	if err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	from, err := timeFromQuery(query, "from", to.Add(-24*time.Hour))
+	if err != nil {
+		server.FailureFromError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	valuation, err := c.portfolio.Value(quote)
+	if err != nil {
+		log.Error().Err(err).Msg("Portfolio value")
+
+		server.FailureFromError(w, http.StatusBadGateway, err)
+
+		return
+	}
+
+	history, err := c.portfolio.History(from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Portfolio history")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, struct {
+		*portfolio.Valuation
+		HistoryQuote string                      `json:"history_quote"`
+		History      []*entity.PortfolioSnapshot `json:"history"`
+	}{
+		Valuation:    valuation,
+		HistoryQuote: c.portfolio.Quote(),
+		History:      history,
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/risk"
+	"github.com/euskadi31/go-server"
+	"github.com/rs/zerolog/log"
+)
+
+// RiskController struct
+type RiskController struct {
+	db   *storm.DB
+	risk *risk.Manager
+}
+
+// NewRiskController constructor
+func NewRiskController(db *storm.DB, riskManager *risk.Manager) *RiskController {
+	if err := db.Init(&entity.Rejection{}); err != nil {
+		log.Fatal().Err(err).Msg("Initialize bucket for Rejection")
+	}
+
+	return &RiskController{
+		db:   db,
+		risk: riskManager,
+	}
+}
+
+// Mount implements server.Controller
+func (c *RiskController) Mount(r *server.Router) {
+	r.AddRouteFunc("/api/v1/risk", c.GetRiskHandler).Methods(http.MethodGet)
+	r.AddRouteFunc("/api/v1/risk/rejections", c.GetListRejectionHandler).Methods(http.MethodGet)
+}
+
+// GetRiskHandler endpoint
+func (c *RiskController) GetRiskHandler(w http.ResponseWriter, r *http.Request) {
+	server.JSON(w, http.StatusOK, map[string]interface{}{
+		"limits": c.risk.Limits(),
+		"state":  c.risk.State(),
+	})
+}
+
+// GetListRejectionHandler endpoint, the rejections can be filtered by campaign_id
+func (c *RiskController) GetListRejectionHandler(w http.ResponseWriter, r *http.Request) {
+	matchers := []q.Matcher{}
+
+	if value := r.URL.Query().Get("campaign_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			server.FailureFromError(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		matchers = append(matchers, q.Eq("CampaignID", id))
+	}
+
+	rejections := []*entity.Rejection{}
+
+	if err := c.db.Select(matchers...).OrderBy("ID").Reverse().Find(&rejections); err != nil && err != storm.ErrNotFound {
+		log.Error().Err(err).Msg("Find rejections")
+
+		server.FailureFromError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, rejections)
+}
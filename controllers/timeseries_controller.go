@@ -14,6 +14,7 @@ import (
 
 	"github.com/euskadi31/cryptotrader/exchanges"
 	"github.com/euskadi31/cryptotrader/trader"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
 	"github.com/euskadi31/go-eventemitter"
 	"github.com/euskadi31/go-server"
 	"github.com/euskadi31/go-sse"
@@ -23,15 +24,17 @@ import (
 
 // TimeseriesController struct
 type TimeseriesController struct {
-	engine  *trader.Engine
-	emitter eventemitter.EventEmitter
+	engine     *trader.Engine
+	algorithms algorithms.Manager
+	emitter    eventemitter.EventEmitter
 }
 
 // NewTimeseriesController constructor
-func NewTimeseriesController(engine *trader.Engine, emitter eventemitter.EventEmitter) *TimeseriesController {
+func NewTimeseriesController(engine *trader.Engine, algorithms algorithms.Manager, emitter eventemitter.EventEmitter) *TimeseriesController {
 	return &TimeseriesController{
-		engine:  engine,
-		emitter: emitter,
+		engine:     engine,
+		algorithms: algorithms,
+		emitter:    emitter,
 	}
 }
 
@@ -42,6 +45,7 @@ func (c *TimeseriesController) Mount(r *server.Router) {
 
 	r.AddRouteFunc("/api/v1/timeseries/{provider:[a-z]+}/{from:[a-z]+}-{to:[a-z]+}", c.GetTimeseriesHandler).Methods(http.MethodGet)
 	r.AddRoute("/api/v1/timeseries/{provider:[a-z]+}/{from:[a-z]+}-{to:[a-z]+}/events", events).Methods(http.MethodGet)
+	r.AddRouteFunc("/api/v1/timeseries/{provider:[a-z]+}/{from:[a-z]+}-{to:[a-z]+}/algorithms/{name:[a-z_]+}", c.GetTimeseriesAlgorithmHandler).Methods(http.MethodGet)
 }
 
 // GetTimeseriesHandler endpoint
@@ -60,6 +64,36 @@ func (c *TimeseriesController) GetTimeseriesHandler(w http.ResponseWriter, r *ht
 	server.JSON(w, http.StatusOK, ts.All())
 }
 
+// GetTimeseriesAlgorithmHandler endpoint
+func (c *TimeseriesController) GetTimeseriesAlgorithmHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	key := fmt.Sprintf("%s-%s-%s", params["provider"], strings.ToUpper(params["from"]), strings.ToUpper(params["to"]))
+
+	ts, err := c.engine.GetTimeserie(key)
+	if err != nil {
+		server.FailureFromError(w, http.StatusNotFound, err)
+
+		return
+	}
+
+	algo, err := c.algorithms.Get(params["name"])
+	if err != nil {
+		server.FailureFromError(w, http.StatusNotFound, err)
+
+		return
+	}
+
+	indicator, ok := algo.(algorithms.Indicator)
+	if ok == false {
+		server.FailureFromError(w, http.StatusBadRequest, fmt.Errorf("algorithm %s does not expose indicators", algo.Name()))
+
+		return
+	}
+
+	server.JSON(w, http.StatusOK, indicator.Indicators(optionsFromQuery(r.URL.Query()), ts))
+}
+
 // GetTimeseriesEventHandler endpoint
 func (c *TimeseriesController) GetTimeseriesEventHandler(rw sse.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
@@ -5,7 +5,10 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/euskadi31/go-std"
+	"github.com/shopspring/decimal"
 )
 
 // CampaignState type
@@ -17,6 +20,8 @@ const (
 	CampaignStateSelling CampaignState = "selling"
 	CampaignStateBuy     CampaignState = "buy"
 	CampaignStateBuying  CampaignState = "buying"
+	CampaignStatePaused  CampaignState = "paused"
+	CampaignStateClosed  CampaignState = "closed"
 )
 
 // Campaign struct
@@ -25,27 +30,58 @@ type Campaign struct {
 	Provider             string                 `storm:"index" json:"provider"`
 	ProviderRef          string                 `json:"provider_ref"`
 	ProductID            string                 `storm:"index" json:"product_id"`
-	Volume               float64                `json:"volume"`
-	BuyLimit             float64                `json:"buy_limit"`
-	SellLimit            float64                `json:"sell_limit"`
+	Volume               decimal.Decimal        `json:"volume"`
+	BuyLimit             decimal.Decimal        `json:"buy_limit"`
+	SellLimit            decimal.Decimal        `json:"sell_limit"`
 	SellLimitUnit        string                 `json:"sell_limit_unit"`
 	CreatedAt            std.DateTime           `json:"created_at"`
 	UpdatedAt            std.DateTime           `json:"updated_at"`
-	BuyOrder             *Order                 `json:"buy_order"`
-	SellOrder            *Order                 `json:"sell_order"`
-	Orders               []*Order               `json:"orders"`
+	BuyOrderID           int                    `json:"buy_order_id"`
+	SellOrderID          int                    `json:"sell_order_id"`
+	BuyOrder             *Order                 `json:"buy_order,omitempty"`
+	SellOrder            *Order                 `json:"sell_order,omitempty"`
+	Orders               []*Order               `json:"orders,omitempty"`
 	State                CampaignState          `storm:"index" json:"state"`
+	PausedState          CampaignState          `json:"paused_state,omitempty"`
 	SellAlgorithm        string                 `json:"sell_algorithm"`
 	SellAlgorithmOptions map[string]interface{} `json:"sell_algorithm_options"`
 	BuyAlgorithm         string                 `json:"buy_algorithm"`
 	BuyAlgorithmOptions  map[string]interface{} `json:"buy_algorithm_options"`
+	MaxCycles            int                    `json:"max_cycles"`
+	EndDate              std.DateTime           `json:"end_date"`
+	ProfitTarget         decimal.Decimal        `json:"profit_target"`
+	Cycles               int                    `json:"cycles"`
+	PnL                  decimal.Decimal        `json:"pnl"`
 }
 
-// AddOrder to Campaign
+// AddOrder to Campaign, the orders are stored in their own bucket and are not saved with the campaign
 func (c *Campaign) AddOrder(order *Order) {
 	c.Orders = append(c.Orders, order)
 }
 
+// SetBuyOrder of the position, the campaign references the order stored in its own bucket
+func (c *Campaign) SetBuyOrder(order *Order) {
+	c.BuyOrder = order
+	c.BuyOrderID = order.ID
+}
+
+// SetSellOrder of the last cycle, the campaign references the order stored in its own bucket
+func (c *Campaign) SetSellOrder(order *Order) {
+	c.SellOrder = order
+	c.SellOrderID = order.ID
+}
+
+// Row returns a copy of the campaign without the orders loaded from their bucket, it is the stored campaign
+func (c *Campaign) Row() *Campaign {
+	row := *c
+
+	row.BuyOrder = nil
+	row.SellOrder = nil
+	row.Orders = nil
+
+	return &row
+}
+
 // IsState check if this state is eq to state param
 func (c Campaign) IsState(state CampaignState) bool {
 	return c.State == state
@@ -60,3 +96,43 @@ func (c *Campaign) IsSelling() bool {
 func (c *Campaign) IsBuying() bool {
 	return c.State == CampaignStateBuying
 }
+
+// IsPaused returns true if campaign is state paused
+func (c *Campaign) IsPaused() bool {
+	return c.State == CampaignStatePaused
+}
+
+// IsClosed returns true if campaign is state closed
+func (c *Campaign) IsClosed() bool {
+	return c.State == CampaignStateClosed
+}
+
+// PositionState returns the state of the position, the state before the pause for a paused campaign
+func (c *Campaign) PositionState() CampaignState {
+	if c.IsPaused() {
+		return c.PausedState
+	}
+
+	return c.State
+}
+
+// Finished returns true if the campaign reached its max cycles (unlimited when zero), its end date
+// or its profit target, the profit target is the realized PnL of the cycles in quote currency
+func (c *Campaign) Finished(now time.Time) bool {
+	if c.MaxCycles > 0 && c.Cycles >= c.MaxCycles {
+		return true
+	}
+
+	if c.EndDate.Valid && now.Before(c.EndDate.Time) == false {
+		return true
+	}
+
+	return c.ProfitTarget.IsPositive() && c.PnL.GreaterThanOrEqual(c.ProfitTarget)
+}
+
+// HasPosition returns true if the campaign has an order in progress or holds the volume bought
+func (c *Campaign) HasPosition() bool {
+	state := c.PositionState()
+
+	return state == CampaignStateBuying || state == CampaignStateSell || state == CampaignStateSelling
+}
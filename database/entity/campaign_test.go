@@ -6,7 +6,10 @@ package entity
 
 import (
 	"testing"
+	"time"
 
+	"github.com/euskadi31/go-std"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,18 +21,39 @@ func TestCampaign(t *testing.T) {
 	assert.True(t, c.IsState(CampaignStateBuy))
 	assert.False(t, c.IsSelling())
 	assert.False(t, c.IsBuying())
+	assert.False(t, c.HasPosition())
 
 	c.State = CampaignStateSelling
 
 	assert.True(t, c.IsState(CampaignStateSelling))
 	assert.True(t, c.IsSelling())
 	assert.False(t, c.IsBuying())
+	assert.True(t, c.HasPosition())
 
 	c.State = CampaignStateBuying
 
 	assert.True(t, c.IsState(CampaignStateBuying))
 	assert.False(t, c.IsSelling())
 	assert.True(t, c.IsBuying())
+	assert.True(t, c.HasPosition())
+
+	c.State = CampaignStatePaused
+	c.PausedState = CampaignStateSell
+
+	assert.True(t, c.IsPaused())
+	assert.False(t, c.IsClosed())
+	assert.Equal(t, CampaignStateSell, c.PositionState())
+	assert.True(t, c.HasPosition())
+
+	c.PausedState = CampaignStateBuy
+
+	assert.False(t, c.HasPosition())
+
+	c.State = CampaignStateClosed
+
+	assert.True(t, c.IsClosed())
+	assert.Equal(t, CampaignStateClosed, c.PositionState())
+	assert.False(t, c.HasPosition())
 
 	assert.Equal(t, 0, len(c.Orders))
 
@@ -37,3 +61,60 @@ func TestCampaign(t *testing.T) {
 
 	assert.Equal(t, 1, len(c.Orders))
 }
+
+func TestCampaignFinished(t *testing.T) {
+	now := time.Now()
+
+	c := &Campaign{}
+
+	assert.False(t, c.Finished(now))
+
+	c.MaxCycles = 2
+	c.Cycles = 1
+
+	assert.False(t, c.Finished(now))
+
+	c.Cycles = 2
+
+	assert.True(t, c.Finished(now))
+
+	c = &Campaign{
+		EndDate: std.DateTimeFrom(now.Add(time.Hour)),
+	}
+
+	assert.False(t, c.Finished(now))
+	assert.True(t, c.Finished(now.Add(time.Hour)))
+
+	c = &Campaign{
+		ProfitTarget: decimal.New(100, 0),
+		PnL:          decimal.New(99, 0),
+	}
+
+	assert.False(t, c.Finished(now))
+
+	c.PnL = decimal.New(100, 0)
+
+	assert.True(t, c.Finished(now))
+}
+
+func TestCampaignOrderReferences(t *testing.T) {
+	campaign := &Campaign{
+		ID: 1,
+	}
+
+	campaign.SetBuyOrder(&Order{ID: 2})
+	campaign.SetSellOrder(&Order{ID: 3})
+	campaign.AddOrder(campaign.BuyOrder)
+
+	assert.Equal(t, 2, campaign.BuyOrderID)
+	assert.Equal(t, 3, campaign.SellOrderID)
+
+	// the stored campaign keeps the references only
+	row := campaign.Row()
+	assert.Nil(t, row.BuyOrder)
+	assert.Nil(t, row.SellOrder)
+	assert.Nil(t, row.Orders)
+	assert.Equal(t, 2, row.BuyOrderID)
+	assert.Equal(t, 3, row.SellOrderID)
+	assert.NotNil(t, campaign.BuyOrder)
+}
@@ -7,38 +7,79 @@ package entity
 import (
 	"github.com/euskadi31/cryptotrader/exchanges"
 	"github.com/euskadi31/go-std"
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.New(100, 0)
+
+// OrderState type
+type OrderState string
+
+// OrderState enum
+const (
+	OrderStatePending  OrderState = "pending"
+	OrderStateFilled   OrderState = "filled"
+	OrderStateCanceled OrderState = "canceled"
 )
 
 // Order struct
 type Order struct {
-	Provider  string             `json:"provider"`
-	ID        int                `storm:"id,increment" json:"id"`
-	TradeID   string             `json:"trade_id"`
-	Side      exchanges.SideType `json:"side"`
-	Size      float64            `json:"size"`
-	ProductID string             `json:"product_id"`
-	Price     float64            `json:"price"`
-	CreatedAt std.DateTime       `json:"created_at"`
-	UpdatedAt std.DateTime       `json:"updated_at"`
-	DeletedAt std.DateTime       `json:"deleted_at"`
+	Provider   string             `storm:"index" json:"provider"`
+	ID         int                `storm:"id,increment" json:"id"`
+	CampaignID int                `storm:"index" json:"campaign_id"`
+	TradeID    string             `json:"trade_id"`
+	Side       exchanges.SideType `json:"side"`
+	State      OrderState         `json:"state"`
+	Size       decimal.Decimal    `json:"size"`
+	ProductID  string             `storm:"index" json:"product_id"`
+	Price      decimal.Decimal    `json:"price"`
+	Fee        decimal.Decimal    `json:"fee"`
+	FeeRate    decimal.Decimal    `json:"fee_rate"`
+	CreatedAt  std.DateTime       `json:"created_at"`
+	UpdatedAt  std.DateTime       `json:"updated_at"`
+	DeletedAt  std.DateTime       `json:"deleted_at"`
 }
 
 // GetBuyingMarketPrice func
-func (o Order) GetBuyingMarketPrice() float64 {
-	return o.Price / o.Size
+func (o Order) GetBuyingMarketPrice() decimal.Decimal {
+	if o.Size.IsZero() {
+		return decimal.Zero
+	}
+
+	return o.Price.Div(o.Size)
 }
 
 // GetCurrentPrice from market price
-func (o Order) GetCurrentPrice(marketPrice float64) float64 {
-	return marketPrice * o.Size
+func (o Order) GetCurrentPrice(marketPrice decimal.Decimal) decimal.Decimal {
+	return marketPrice.Mul(o.Size)
+}
+
+// GetCost of order including fee
+func (o Order) GetCost() decimal.Decimal {
+	return o.Price.Add(o.Fee)
+}
+
+// GetProceeds of order excluding fee
+func (o Order) GetProceeds() decimal.Decimal {
+	return o.Price.Sub(o.Fee)
 }
 
-// GetMarginInCurrency from market price
-func (o Order) GetMarginInCurrency(marketPrice float64) float64 {
-	return o.GetCurrentPrice(marketPrice) - o.Price
+// GetExitFee estimated at the fee rate of order to sell at market price
+func (o Order) GetExitFee(marketPrice decimal.Decimal) decimal.Decimal {
+	return o.GetCurrentPrice(marketPrice).Mul(o.FeeRate)
 }
 
-// GetMarginInPercent from market price
-func (o Order) GetMarginInPercent(marketPrice float64) float64 {
-	return (o.GetCurrentPrice(marketPrice) - o.Price) / o.Price * 100
+// GetMarginInCurrency from market price, net of the fees of both legs
+func (o Order) GetMarginInCurrency(marketPrice decimal.Decimal) decimal.Decimal {
+	return o.GetCurrentPrice(marketPrice).Sub(o.GetExitFee(marketPrice)).Sub(o.GetCost())
+}
+
+// GetMarginInPercent from market price, net of the fees of both legs
+func (o Order) GetMarginInPercent(marketPrice decimal.Decimal) decimal.Decimal {
+	cost := o.GetCost()
+	if cost.IsZero() {
+		return decimal.Zero
+	}
+
+	return o.GetMarginInCurrency(marketPrice).Mul(hundred).Div(cost)
 }
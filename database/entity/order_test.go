@@ -5,28 +5,74 @@
 package entity
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestOrder(t *testing.T) {
 
-	marketPrice := float64(14199)
+	marketPrice := decimal.New(14199, 0)
 
 	o := &Order{
 		Provider:  "gdax",
 		Side:      exchanges.SideTypeBuy,
 		ProductID: "BTC-EUR",
-		Size:      0.04468611,
-		Price:     150,
+		Size:      decimal.RequireFromString("0.04468611"),
+		Price:     decimal.New(150, 0),
 	}
 
-	assert.Equal(t, 3356.7477679305716, o.GetBuyingMarketPrice())
-	assert.Equal(t, 634.49807589, o.GetCurrentPrice(marketPrice))
-	assert.Equal(t, 484.49807589, o.GetMarginInCurrency(marketPrice))
-	assert.Equal(t, 322.99871726, o.GetMarginInPercent(marketPrice))
+	assert.Equal(t, "3356.7477679305717146", o.GetBuyingMarketPrice().String())
+	assert.Equal(t, "634.49807589", o.GetCurrentPrice(marketPrice).String())
+	assert.Equal(t, "484.49807589", o.GetMarginInCurrency(marketPrice).String())
+	assert.Equal(t, "322.99871726", o.GetMarginInPercent(marketPrice).String())
+}
+
+func TestOrderWithFees(t *testing.T) {
+	o := &Order{
+		Provider:  "gdax",
+		Side:      exchanges.SideTypeBuy,
+		ProductID: "BTC-EUR",
+		Size:      decimal.RequireFromString("0.1"),
+		Price:     decimal.New(1000, 0),
+		Fee:       decimal.RequireFromString("2.5"),
+		FeeRate:   decimal.RequireFromString("0.0025"),
+	}
+
+	// 1% gross margin
+	marketPrice := decimal.New(10100, 0)
+
+	assert.Equal(t, "1002.5", o.GetCost().String())
+	assert.Equal(t, "2.525", o.GetExitFee(marketPrice).String())
+	assert.Equal(t, "4.975", o.GetMarginInCurrency(marketPrice).String())
+	assert.Equal(t, "0.4962593516209476", o.GetMarginInPercent(marketPrice).String())
+
+	// the fees of both legs are not covered
+	assert.True(t, o.GetMarginInCurrency(decimal.New(10050, 0)).IsNegative())
+}
+
+func TestOrderWithoutSize(t *testing.T) {
+	o := &Order{}
+
+	assert.True(t, o.GetBuyingMarketPrice().IsZero())
+	assert.True(t, o.GetMarginInPercent(decimal.New(100, 0)).IsZero())
+}
+
+func TestOrderJSON(t *testing.T) {
+	o := &Order{}
+
+	// the orders stored before the decimals are decoded from numbers
+	err := json.Unmarshal([]byte(`{"size":0.04468611,"price":150.1}`), o)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.04468611", o.Size.String())
+	assert.Equal(t, "150.1", o.Price.String())
+
+	b, err := json.Marshal(o.Size)
+	assert.NoError(t, err)
+	assert.Equal(t, `"0.04468611"`, string(b))
 }
 
 func BenchmarkOrderGetBuyingMarketPrice(b *testing.B) {
@@ -34,8 +80,8 @@ func BenchmarkOrderGetBuyingMarketPrice(b *testing.B) {
 		Provider:  "gdax",
 		Side:      exchanges.SideTypeBuy,
 		ProductID: "BTC-EUR",
-		Size:      0.04468611,
-		Price:     150,
+		Size:      decimal.RequireFromString("0.04468611"),
+		Price:     decimal.New(150, 0),
 	}
 
 	for n := 0; n < b.N; n++ {
@@ -44,14 +90,14 @@ func BenchmarkOrderGetBuyingMarketPrice(b *testing.B) {
 }
 
 func BenchmarkOrderGetCurrentPrice(b *testing.B) {
-	marketPrice := float64(14199)
+	marketPrice := decimal.New(14199, 0)
 
 	o := &Order{
 		Provider:  "gdax",
 		Side:      exchanges.SideTypeBuy,
 		ProductID: "BTC-EUR",
-		Size:      0.04468611,
-		Price:     150,
+		Size:      decimal.RequireFromString("0.04468611"),
+		Price:     decimal.New(150, 0),
 	}
 
 	for n := 0; n < b.N; n++ {
@@ -60,14 +106,14 @@ func BenchmarkOrderGetCurrentPrice(b *testing.B) {
 }
 
 func BenchmarkOrderGetMarginInCurrency(b *testing.B) {
-	marketPrice := float64(14199)
+	marketPrice := decimal.New(14199, 0)
 
 	o := &Order{
 		Provider:  "gdax",
 		Side:      exchanges.SideTypeBuy,
 		ProductID: "BTC-EUR",
-		Size:      0.04468611,
-		Price:     150,
+		Size:      decimal.RequireFromString("0.04468611"),
+		Price:     decimal.New(150, 0),
 	}
 
 	for n := 0; n < b.N; n++ {
@@ -76,14 +122,14 @@ func BenchmarkOrderGetMarginInCurrency(b *testing.B) {
 }
 
 func BenchmarkOrderGetMarginInPercent(b *testing.B) {
-	marketPrice := float64(14199)
+	marketPrice := decimal.New(14199, 0)
 
 	o := &Order{
 		Provider:  "gdax",
 		Side:      exchanges.SideTypeBuy,
 		ProductID: "BTC-EUR",
-		Size:      0.04468611,
-		Price:     150,
+		Size:      decimal.RequireFromString("0.04468611"),
+		Price:     decimal.New(150, 0),
 	}
 
 	for n := 0; n < b.N; n++ {
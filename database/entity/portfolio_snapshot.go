@@ -0,0 +1,15 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package entity
+
+// PortfolioSnapshot struct of the portfolio value at a time
+type PortfolioSnapshot struct {
+	ID    int     `storm:"id,increment" json:"id"`
+	Time  int64   `storm:"index" json:"time"`
+	Quote string  `json:"quote"`
+	Total float64 `json:"total"`
+	// Assets value in quote currency by {provider}:{currency}
+	Assets map[string]float64 `json:"assets"`
+}
@@ -0,0 +1,25 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package entity
+
+import (
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/go-std"
+	"github.com/shopspring/decimal"
+)
+
+// Rejection struct of an order refused by the risk manager
+type Rejection struct {
+	ID         int                `storm:"id,increment" json:"id"`
+	CampaignID int                `storm:"index" json:"campaign_id"`
+	Provider   string             `json:"provider"`
+	ProductID  string             `json:"product_id"`
+	Side       exchanges.SideType `json:"side"`
+	Size       decimal.Decimal    `json:"size"`
+	Price      decimal.Decimal    `json:"price"`
+	Rule       string             `storm:"index" json:"rule"`
+	Reason     string             `json:"reason"`
+	CreatedAt  std.DateTime       `json:"created_at"`
+}
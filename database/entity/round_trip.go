@@ -0,0 +1,25 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package entity
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// RoundTrip struct of a position closed by a campaign
+type RoundTrip struct {
+	ID         int             `storm:"id,increment" json:"id"`
+	CampaignID int             `storm:"index" json:"campaign_id"`
+	Cycle      int             `json:"cycle"`
+	Provider   string          `json:"provider"`
+	ProductID  string          `json:"product_id"`
+	Quote      string          `json:"quote"`
+	BuyOrder   *Order          `json:"buy_order"`
+	SellOrder  *Order          `json:"sell_order"`
+	Fees       decimal.Decimal `json:"fees"`
+	PnL        decimal.Decimal `json:"pnl"`
+	PnLPercent decimal.Decimal `json:"pnl_percent"`
+	ClosedAt   int64           `storm:"index" json:"closed_at"`
+}
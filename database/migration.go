@@ -0,0 +1,228 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"sort"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	migrationBucket = "migrations"
+	migrationKey    = "version"
+)
+
+// migrations are applied in order, the schema version is the number of applied migrations
+var migrations = []func(db *storm.DB) error{
+	migrateDecimals,
+	migrateCycles,
+	migrateOrders,
+	migrateOrderReferences,
+}
+
+// Migrate the data stored in db to the last schema version
+func Migrate(db *storm.DB) error {
+	version := 0
+
+	if err := db.Get(migrationBucket, migrationKey, &version); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for ; version < len(migrations); version++ {
+		log.Info().Msgf("Migrate database to version %d", version+1)
+
+		if err := migrations[version](db); err != nil {
+			return err
+		}
+
+		if err := db.Set(migrationBucket, migrationKey, version+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateDecimals saves again the entities stored with float amounts,
+// the decimals decode the json numbers and are encoded as exact strings.
+func migrateDecimals(db *storm.DB) error {
+	var campaigns []*entity.Campaign
+
+	if err := db.All(&campaigns); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, campaign := range campaigns {
+		if err := db.Save(campaign); err != nil {
+			return err
+		}
+	}
+
+	var orders []*entity.Order
+
+	if err := db.All(&orders); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, order := range orders {
+		if err := db.Save(order); err != nil {
+			return err
+		}
+	}
+
+	var rejections []*entity.Rejection
+
+	if err := db.All(&rejections); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, rejection := range rejections {
+		if err := db.Save(rejection); err != nil {
+			return err
+		}
+	}
+
+	var roundTrips []*entity.RoundTrip
+
+	if err := db.All(&roundTrips); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, roundTrip := range roundTrips {
+		if err := db.Save(roundTrip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateCycles numbers the round trips of each campaign by closing time
+// and counts the cycles and the realized PnL of the campaigns.
+func migrateCycles(db *storm.DB) error {
+	var roundTrips []*entity.RoundTrip
+
+	if err := db.All(&roundTrips); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	sort.SliceStable(roundTrips, func(i, j int) bool {
+		return roundTrips[i].ClosedAt < roundTrips[j].ClosedAt
+	})
+
+	cycles := map[int][]*entity.RoundTrip{}
+
+	for _, roundTrip := range roundTrips {
+		cycles[roundTrip.CampaignID] = append(cycles[roundTrip.CampaignID], roundTrip)
+
+		roundTrip.Cycle = len(cycles[roundTrip.CampaignID])
+
+		if err := db.Save(roundTrip); err != nil {
+			return err
+		}
+	}
+
+	var campaigns []*entity.Campaign
+
+	if err := db.All(&campaigns); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, campaign := range campaigns {
+		campaign.Cycles = len(cycles[campaign.ID])
+
+		for _, roundTrip := range cycles[campaign.ID] {
+			campaign.PnL = campaign.PnL.Add(roundTrip.PnL)
+		}
+
+		if err := db.Save(campaign); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateOrders links the orders to the campaigns of their round trips and buy or sell orders,
+// the orders stored before were filled and are saved again to build the new indexes.
+func migrateOrders(db *storm.DB) error {
+	campaigns := map[int]int{}
+
+	var roundTrips []*entity.RoundTrip
+
+	if err := db.All(&roundTrips); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, roundTrip := range roundTrips {
+		for _, order := range []*entity.Order{roundTrip.BuyOrder, roundTrip.SellOrder} {
+			if order != nil {
+				campaigns[order.ID] = roundTrip.CampaignID
+			}
+		}
+	}
+
+	var stored []*entity.Campaign
+
+	if err := db.All(&stored); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, campaign := range stored {
+		for _, order := range []*entity.Order{campaign.BuyOrder, campaign.SellOrder} {
+			if order != nil {
+				campaigns[order.ID] = campaign.ID
+			}
+		}
+	}
+
+	var orders []*entity.Order
+
+	if err := db.All(&orders); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, order := range orders {
+		order.CampaignID = campaigns[order.ID]
+
+		if order.State == "" {
+			order.State = entity.OrderStateFilled
+		}
+
+		if err := db.Save(order); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateOrderReferences replaces the buy and sell orders embedded in the campaigns by the ids of the stored orders.
+func migrateOrderReferences(db *storm.DB) error {
+	var campaigns []*entity.Campaign
+
+	if err := db.All(&campaigns); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, campaign := range campaigns {
+		if campaign.BuyOrder != nil {
+			campaign.BuyOrderID = campaign.BuyOrder.ID
+		}
+
+		if campaign.SellOrder != nil {
+			campaign.SellOrderID = campaign.SellOrder.ID
+		}
+
+		if err := db.Save(campaign.Row()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
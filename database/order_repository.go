@@ -0,0 +1,201 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"sort"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+)
+
+// OrderFilter of the orders, the zero fields do not filter
+type OrderFilter struct {
+	CampaignID int
+	Provider   string
+	ProductID  string
+	Side       exchanges.SideType
+	State      entity.OrderState
+	From       time.Time
+	To         time.Time
+}
+
+// Match returns true if order passes the filter, the dates are compared with the creation date of order
+func (f OrderFilter) Match(order *entity.Order) bool {
+	if f.CampaignID > 0 && order.CampaignID != f.CampaignID {
+		return false
+	}
+
+	if f.Provider != "" && order.Provider != f.Provider {
+		return false
+	}
+
+	if f.ProductID != "" && order.ProductID != f.ProductID {
+		return false
+	}
+
+	if f.Side != "" && order.Side != f.Side {
+		return false
+	}
+
+	if f.State != "" && order.State != f.State {
+		return false
+	}
+
+	if f.From.IsZero() == false && order.CreatedAt.Time.Before(f.From) {
+		return false
+	}
+
+	if f.To.IsZero() == false && order.CreatedAt.Time.After(f.To) {
+		return false
+	}
+
+	return true
+}
+
+// OrderRepository stores the orders in their own bucket indexed by campaign
+type OrderRepository struct {
+	db *storm.DB
+}
+
+// NewOrderRepository constructor
+func NewOrderRepository(db *storm.DB) *OrderRepository {
+	return &OrderRepository{
+		db: db,
+	}
+}
+
+// Save order
+func (r *OrderRepository) Save(order *entity.Order) error {
+	return r.db.Save(order)
+}
+
+// Find the orders passing filter ordered by id
+func (r *OrderRepository) Find(filter *OrderFilter) ([]*entity.Order, error) {
+	if filter == nil {
+		filter = &OrderFilter{}
+	}
+
+	// the indexed fields are queried, the others are matched after
+	matchers := []q.Matcher{}
+
+	if filter.CampaignID > 0 {
+		matchers = append(matchers, q.Eq("CampaignID", filter.CampaignID))
+	}
+
+	if filter.Provider != "" {
+		matchers = append(matchers, q.Eq("Provider", filter.Provider))
+	}
+
+	if filter.ProductID != "" {
+		matchers = append(matchers, q.Eq("ProductID", filter.ProductID))
+	}
+
+	var found []*entity.Order
+
+	if err := r.db.Select(matchers...).OrderBy("ID").Find(&found); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+
+	orders := []*entity.Order{}
+
+	for _, order := range found {
+		if filter.Match(order) {
+			orders = append(orders, order)
+		}
+	}
+
+	return orders, nil
+}
+
+// FindByCampaign returns the orders of the campaign ordered by id
+func (r *OrderRepository) FindByCampaign(campaignID int) ([]*entity.Order, error) {
+	return r.Find(&OrderFilter{
+		CampaignID: campaignID,
+	})
+}
+
+// Resolve the buy and sell orders referenced by the campaigns, the history of the orders is not loaded
+func (r *OrderRepository) Resolve(campaigns ...*entity.Campaign) error {
+	ids := []int{}
+
+	for _, campaign := range campaigns {
+		for _, id := range []int{campaign.BuyOrderID, campaign.SellOrderID} {
+			if id > 0 {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var found []*entity.Order
+
+	if err := r.db.Select(q.In("ID", ids)).Find(&found); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	byID := map[int]*entity.Order{}
+
+	for _, order := range found {
+		byID[order.ID] = order
+	}
+
+	for _, campaign := range campaigns {
+		campaign.BuyOrder = byID[campaign.BuyOrderID]
+		campaign.SellOrder = byID[campaign.SellOrderID]
+	}
+
+	return nil
+}
+
+// Populate the orders of campaigns with their history, the buy and sell orders are resolved
+func (r *OrderRepository) Populate(campaigns ...*entity.Campaign) error {
+	if len(campaigns) == 0 {
+		return nil
+	}
+
+	ids := []int{}
+
+	for _, campaign := range campaigns {
+		ids = append(ids, campaign.ID)
+	}
+
+	var found []*entity.Order
+
+	if err := r.db.Select(q.In("CampaignID", ids)).Find(&found); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].ID < found[j].ID
+	})
+
+	orders := map[int][]*entity.Order{}
+	byID := map[int]*entity.Order{}
+
+	for _, order := range found {
+		orders[order.CampaignID] = append(orders[order.CampaignID], order)
+		byID[order.ID] = order
+	}
+
+	for _, campaign := range campaigns {
+		campaign.Orders = []*entity.Order{}
+
+		for _, order := range orders[campaign.ID] {
+			campaign.AddOrder(order)
+		}
+
+		campaign.BuyOrder = byID[campaign.BuyOrderID]
+		campaign.SellOrder = byID[campaign.SellOrderID]
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/go-std"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderFilterMatch(t *testing.T) {
+	now := time.Now()
+
+	order := &entity.Order{
+		CampaignID: 1,
+		Provider:   "gdax",
+		ProductID:  "BTC-EUR",
+		Side:       exchanges.SideTypeBuy,
+		State:      entity.OrderStateFilled,
+		CreatedAt:  std.DateTimeFrom(now),
+	}
+
+	assert.True(t, OrderFilter{}.Match(order))
+	assert.True(t, OrderFilter{
+		CampaignID: 1,
+		Provider:   "gdax",
+		ProductID:  "BTC-EUR",
+		Side:       exchanges.SideTypeBuy,
+		State:      entity.OrderStateFilled,
+		From:       now.Add(-time.Hour),
+		To:         now,
+	}.Match(order))
+
+	assert.False(t, OrderFilter{CampaignID: 2}.Match(order))
+	assert.False(t, OrderFilter{Provider: "kraken"}.Match(order))
+	assert.False(t, OrderFilter{ProductID: "ETH-EUR"}.Match(order))
+	assert.False(t, OrderFilter{Side: exchanges.SideTypeSell}.Match(order))
+	assert.False(t, OrderFilter{State: entity.OrderStateCanceled}.Match(order))
+	assert.False(t, OrderFilter{From: now.Add(time.Second)}.Match(order))
+	assert.False(t, OrderFilter{To: now.Add(-time.Second)}.Match(order))
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"github.com/asdine/storm"
+	"github.com/shopspring/decimal"
+)
+
+const paperBalanceBucket = "paper_balances"
+
+// PaperBalanceStore persists the virtual balances of the paper exchanges by exchange name
+type PaperBalanceStore struct {
+	db *storm.DB
+}
+
+// NewPaperBalanceStore constructor
+func NewPaperBalanceStore(db *storm.DB) *PaperBalanceStore {
+	return &PaperBalanceStore{
+		db: db,
+	}
+}
+
+// Load the balances of the exchange name, nil when they were never saved
+func (s *PaperBalanceStore) Load(name string) (map[string]decimal.Decimal, error) {
+	balances := map[string]decimal.Decimal{}
+
+	if err := s.db.Get(paperBalanceBucket, name, &balances); err == storm.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// Save the balances of the exchange name
+func (s *PaperBalanceStore) Save(name string, balances map[string]decimal.Decimal) error {
+	return s.db.Set(paperBalanceBucket, name, balances)
+}
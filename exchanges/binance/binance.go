@@ -0,0 +1,465 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package binance provides the Binance exchange, the Binance symbols are the concatenated assets (BTCEUR).
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+// Request weights of the endpoints
+const (
+	weightExchangeInfo = 10
+	weightDepth        = 1
+	weightAccount      = 10
+	weightOrder        = 1
+)
+
+// ErrInvalidOrderID is returned when the order id is not formatted as SYMBOL:orderId
+var ErrInvalidOrderID = errors.New("binance order id is invalid")
+
+// Testnet api of Binance
+const (
+	TestnetAPI          = "https://testnet.binance.vision"
+	TestnetWebSocketAPI = "wss://testnet.binance.vision/stream"
+)
+
+// Options of Binance
+type Options struct {
+	// Name of the instance, binance when empty
+	Name   string
+	Key    string
+	Secret string
+	// Sandbox connects to the testnet when the urls are empty
+	Sandbox bool
+	// API url, DefaultAPI when empty
+	API string
+	// WebSocketAPI url, DefaultWebSocketAPI when empty
+	WebSocketAPI string
+}
+
+// Binance struct
+type Binance struct {
+	name     string
+	client   *Client
+	ws       *WebSocketClient
+	ticker   *Ticker
+	products *exchanges.ProductCatalog
+}
+
+// NewBinance Exchange, the websocket is connected by the first subscription
+func NewBinance(options *Options) (*Binance, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	if options.Name == "" {
+		options.Name = "binance"
+	}
+
+	if options.Sandbox && options.API == "" {
+		options.API = TestnetAPI
+	}
+
+	if options.Sandbox && options.WebSocketAPI == "" {
+		options.WebSocketAPI = TestnetWebSocketAPI
+	}
+
+	client := NewClient(options.Key, options.Secret)
+
+	if options.API != "" {
+		client.BaseURL = strings.TrimRight(options.API, "/")
+	}
+
+	e := &Binance{
+		name:   options.Name,
+		client: client,
+		ws:     NewWebSocketClient(options.WebSocketAPI),
+	}
+
+	e.ticker = newTicker(e.ws)
+
+	e.products = exchanges.NewProductCatalog(e.loadProducts, exchanges.DefaultCatalogTTL)
+
+	return e, nil
+}
+
+// NewProvider factory of the binance instances
+func NewProvider(config *exchanges.ProviderConfig, providers exchanges.Manager) (exchanges.ExchangeProvider, error) {
+	return NewBinance(&Options{
+		Name:         config.Name,
+		Key:          config.Key,
+		Secret:       config.Secret,
+		Sandbox:      config.Sandbox,
+		API:          config.API,
+		WebSocketAPI: config.WebSocketAPI,
+	})
+}
+
+// DefaultFeeSchedule of Binance for a 30 days volume below 1000000 USD
+func DefaultFeeSchedule() *exchanges.FeeSchedule {
+	return exchanges.NewFeeSchedule(exchanges.FeeTier{
+		Volume: decimal.Zero,
+		Maker:  decimal.New(1, -3),
+		Taker:  decimal.New(1, -3),
+	})
+}
+
+// Name of provider
+func (e Binance) Name() string {
+	return e.name
+}
+
+// Weights of the rest api requests
+func (e *Binance) Weights() *Weights {
+	return e.client.Weights
+}
+
+// Ticker of the trades shared by the consumers of the instance
+func (e *Binance) Ticker() exchanges.TickerProvider {
+	return e.ticker
+}
+
+// Balance provider
+func (e *Binance) Balance() exchanges.BalanceProvider {
+	return &Balance{
+		client: e.client,
+	}
+}
+
+// Close the websocket and the listeners of the ticker
+func (e *Binance) Close(ctx context.Context) error {
+	e.ticker.broadcaster.Close()
+
+	if err := e.ws.Close(ctx); err != nil && err != ErrNotConnected {
+		return err
+	}
+
+	return nil
+}
+
+// Products catalog loaded from the exchange info
+func (e *Binance) Products() *exchanges.ProductCatalog {
+	return e.products
+}
+
+// symbolFilter struct of the exchange info, only the price and lot size filters are used
+type symbolFilter struct {
+	FilterType string          `json:"filterType"`
+	TickSize   decimal.Decimal `json:"tickSize"`
+	StepSize   decimal.Decimal `json:"stepSize"`
+	MinQty     decimal.Decimal `json:"minQty"`
+	MaxQty     decimal.Decimal `json:"maxQty"`
+}
+
+// symbolInfo struct of the exchange info
+type symbolInfo struct {
+	Symbol     string          `json:"symbol"`
+	Status     string          `json:"status"`
+	BaseAsset  string          `json:"baseAsset"`
+	QuoteAsset string          `json:"quoteAsset"`
+	Filters    []*symbolFilter `json:"filters"`
+}
+
+// rateLimit struct of the exchange info
+type rateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int    `json:"intervalNum"`
+	Limit         int    `json:"limit"`
+}
+
+// ProductInfo of the symbol
+func (s symbolInfo) ProductInfo() *exchanges.ProductInfo {
+	info := &exchanges.ProductInfo{
+		Product: exchanges.NewProduct(s.BaseAsset, s.QuoteAsset),
+		Status:  exchanges.ProductStatusOffline,
+	}
+
+	switch s.Status {
+	case "TRADING":
+		info.Status = exchanges.ProductStatusOnline
+	case "BREAK":
+		info.Status = exchanges.ProductStatusDelisted
+	}
+
+	for _, filter := range s.Filters {
+		switch filter.FilterType {
+		case "LOT_SIZE":
+			info.BaseIncrement = filter.StepSize
+			info.MinSize = filter.MinQty
+			info.MaxSize = filter.MaxQty
+		case "PRICE_FILTER":
+			info.QuoteIncrement = filter.TickSize
+		}
+	}
+
+	return info
+}
+
+func (e *Binance) loadProducts() ([]*exchanges.ProductInfo, error) {
+	result := &struct {
+		RateLimits []*rateLimit  `json:"rateLimits"`
+		Symbols    []*symbolInfo `json:"symbols"`
+	}{}
+
+	if err := e.client.Request(http.MethodGet, "/api/v3/exchangeInfo", nil, weightExchangeInfo, false, result); err != nil {
+		return nil, err
+	}
+
+	for _, limit := range result.RateLimits {
+		if limit.RateLimitType == "REQUEST_WEIGHT" && limit.Interval == "MINUTE" && limit.IntervalNum <= 1 {
+			e.client.Weights.SetLimit(limit.Limit)
+		}
+	}
+
+	products := []*exchanges.ProductInfo{}
+
+	for _, symbol := range result.Symbols {
+		products = append(products, symbol.ProductInfo())
+	}
+
+	return products, nil
+}
+
+// parseBookEntries formatted as [[price, quantity]]
+func parseBookEntries(rows [][]decimal.Decimal) []*exchanges.BookEntry {
+	entries := []*exchanges.BookEntry{}
+
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+
+		entries = append(entries, &exchanges.BookEntry{
+			Price: row[0],
+			Size:  row[1],
+		})
+	}
+
+	return entries
+}
+
+// OrderBook of product limited to depth levels on each side
+func (e *Binance) OrderBook(product exchanges.Product, depth int) (*exchanges.OrderBook, error) {
+	params := url.Values{}
+	params.Set("symbol", Symbol(product))
+
+	if depth > 0 {
+		params.Set("limit", strconv.Itoa(depth))
+	}
+
+	book := &struct {
+		Asks [][]decimal.Decimal `json:"asks"`
+		Bids [][]decimal.Decimal `json:"bids"`
+	}{}
+
+	if err := e.client.Request(http.MethodGet, "/api/v3/depth", params, weightDepth, false, book); err != nil {
+		return nil, err
+	}
+
+	asks := parseBookEntries(book.Asks)
+	bids := parseBookEntries(book.Bids)
+
+	sort.Slice(asks, func(i, j int) bool {
+		return asks[i].Price.LessThan(asks[j].Price)
+	})
+
+	sort.Slice(bids, func(i, j int) bool {
+		return bids[i].Price.GreaterThan(bids[j].Price)
+	})
+
+	if depth > 0 && len(asks) > depth {
+		asks = asks[:depth]
+	}
+
+	if depth > 0 && len(bids) > depth {
+		bids = bids[:depth]
+	}
+
+	return &exchanges.OrderBook{
+		Product: product,
+		Asks:    asks,
+		Bids:    bids,
+		Time:    time.Now().UTC(),
+	}, nil
+}
+
+// orderID formatted as SYMBOL:orderId, Binance requires the symbol to cancel an order
+func orderID(symbol string, id int64) string {
+	return symbol + ":" + strconv.FormatInt(id, 10)
+}
+
+// parseOrderID formatted as SYMBOL:orderId
+func parseOrderID(id string) (string, string, error) {
+	parts := strings.SplitN(id, ":", 2)
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", ErrInvalidOrderID
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// PlaceOrder on the account
+func (e *Binance) PlaceOrder(order *exchanges.OrderRequest) (*exchanges.PlacedOrder, error) {
+	orderType := order.Type
+
+	if orderType == "" {
+		orderType = exchanges.OrderTypeMarket
+	}
+
+	symbol := Symbol(order.Product)
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("side", strings.ToUpper(string(order.Side)))
+	params.Set("type", strings.ToUpper(string(orderType)))
+	params.Set("quantity", order.Size.String())
+
+	if orderType == exchanges.OrderTypeLimit {
+		params.Set("price", order.Price.String())
+		params.Set("timeInForce", "GTC")
+	}
+
+	result := &struct {
+		Symbol  string `json:"symbol"`
+		OrderID int64  `json:"orderId"`
+		Status  string `json:"status"`
+	}{}
+
+	if err := e.client.Request(http.MethodPost, "/api/v3/order", params, weightOrder, true, result); err != nil {
+		return nil, err
+	}
+
+	return &exchanges.PlacedOrder{
+		ID:          orderID(symbol, result.OrderID),
+		Description: strings.Join([]string{params.Get("side"), params.Get("quantity"), symbol, params.Get("type"), result.Status}, " "),
+	}, nil
+}
+
+// CancelOrder by id formatted as SYMBOL:orderId
+func (e *Binance) CancelOrder(id string) error {
+	symbol, oid, err := parseOrderID(id)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("orderId", oid)
+
+	return e.client.Request(http.MethodDelete, "/api/v3/order", params, weightOrder, true, nil)
+}
+
+// Balance struct
+type Balance struct {
+	client *Client
+}
+
+// Balances of the account, the empty assets are skipped
+func (b Balance) Balances() ([]*exchanges.Balance, error) {
+	account := &struct {
+		Balances []*struct {
+			Asset  string          `json:"asset"`
+			Free   decimal.Decimal `json:"free"`
+			Locked decimal.Decimal `json:"locked"`
+		} `json:"balances"`
+	}{}
+
+	if err := b.client.Request(http.MethodGet, "/api/v3/account", nil, weightAccount, true, account); err != nil {
+		return nil, err
+	}
+
+	balances := []*exchanges.Balance{}
+
+	for _, balance := range account.Balances {
+		total := balance.Free.Add(balance.Locked)
+
+		if total.IsZero() {
+			continue
+		}
+
+		balances = append(balances, &exchanges.Balance{
+			Currency:  balance.Asset,
+			Total:     total,
+			Hold:      balance.Locked,
+			Available: balance.Free,
+		})
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Currency < balances[j].Currency
+	})
+
+	return balances, nil
+}
+
+// Ticker struct
+type Ticker struct {
+	ws          *WebSocketClient
+	broadcaster *exchanges.Broadcaster
+	once        sync.Once
+}
+
+func newTicker(ws *WebSocketClient) *Ticker {
+	return &Ticker{
+		ws:          ws,
+		broadcaster: exchanges.NewBroadcaster(),
+	}
+}
+
+// Subscribe to product
+func (t *Ticker) Subscribe(products ...exchanges.Product) error {
+	return t.ws.Subscribe(products...)
+}
+
+// Unsubscribe to product
+func (t *Ticker) Unsubscribe(products ...exchanges.Product) error {
+	return t.ws.Unsubscribe(products...)
+}
+
+// Channel TickerEvent, it is closed by Close, the listener cannot be closed before the ticker
+// so the events it cannot buffer are dropped instead of blocking the other listeners
+func (t *Ticker) Channel() <-chan *exchanges.TickerEvent {
+	return t.Listen(&exchanges.ListenOptions{
+		Policy: exchanges.DeliveryPolicyDrop,
+	}).C
+}
+
+// Listen to the trades, the listeners are closed by Close
+func (t *Ticker) Listen(options *exchanges.ListenOptions) *exchanges.Listener {
+	t.once.Do(func() {
+		go t.broadcast()
+	})
+
+	return t.broadcaster.Listen(options)
+}
+
+// broadcast the trades of the websocket to the listeners
+func (t *Ticker) broadcast() {
+	defer t.broadcaster.Close()
+
+	for trade := range t.ws.Trades {
+		t.broadcaster.Broadcast(&exchanges.TickerEvent{
+			Product: trade.Product,
+			Price:   trade.Price,
+			Time:    trade.Time,
+			Side:    trade.Side,
+			Size:    trade.Size,
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStandIn starts a local server replaying the Binance rest and websocket api
+func newStandIn(t *testing.T, handlers map[string]http.HandlerFunc) (*httptest.Server, *Binance) {
+	mux := http.NewServeMux()
+
+	for path, handler := range handlers {
+		mux.HandleFunc(path, handler)
+	}
+
+	if _, ok := handlers["/stream"]; ok == false {
+		mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+			upgrader := websocket.Upgrader{}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+
+	b, err := NewBinance(&Options{
+		Key:          "key",
+		Secret:       "secret",
+		API:          srv.URL,
+		WebSocketAPI: "ws" + strings.TrimPrefix(srv.URL, "http") + "/stream",
+	})
+	assert.NoError(t, err)
+
+	return srv, b
+}
+
+// assertSigned checks the signature of the query string
+func assertSigned(t *testing.T, r *http.Request) {
+	query := r.URL.RawQuery
+	i := strings.LastIndex(query, "&signature=")
+
+	assert.True(t, i > 0)
+	assert.Equal(t, "key", r.Header.Get("X-MBX-APIKEY"))
+	assert.Equal(t, sign("secret", query[:i]), query[i+len("&signature="):])
+	assert.NotEmpty(t, r.URL.Query().Get("timestamp"))
+}
+
+func TestSymbol(t *testing.T) {
+	assert.Equal(t, "BTCEUR", Symbol(exchanges.NewProduct("btc", "eur")))
+
+	product, ok := ProductFromSymbol("BTCUSDT")
+	assert.True(t, ok)
+	assert.Equal(t, "BTC-USDT", product.String())
+
+	product, ok = ProductFromSymbol("ethbtc")
+	assert.True(t, ok)
+	assert.Equal(t, "ETH-BTC", product.String())
+
+	_, ok = ProductFromSymbol("FOOBAR")
+	assert.False(t, ok)
+
+	_, ok = ProductFromSymbol("BTC")
+	assert.False(t, ok)
+}
+
+func TestBinanceTicker(t *testing.T) {
+	subscribed := make(chan *WebSocketRequest, 2)
+
+	srv, b := newStandIn(t, map[string]http.HandlerFunc{
+		"/stream": func(w http.ResponseWriter, r *http.Request) {
+			upgrader := websocket.Upgrader{}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			req := &WebSocketRequest{}
+
+			if err := conn.ReadJSON(req); err != nil {
+				return
+			}
+
+			// the reconnections after the test do not block the server
+			select {
+			case subscribed <- req:
+			default:
+			}
+
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"result":null,"id":1}`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"btceur@trade","data":{"e":"trade","E":1534614057322,"s":"BTCEUR","t":12345,"p":"5541.20000000","q":"0.15850568","T":1534614057321,"m":true,"M":true}}`))
+
+			// the connection is dropped after the trade
+		},
+	})
+	defer srv.Close()
+
+	b.ws.delay = 10 * time.Millisecond
+
+	ticker := b.Ticker()
+	events := ticker.Channel()
+
+	assert.NoError(t, ticker.Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	req := <-subscribed
+
+	assert.Equal(t, WebSocketMethodSubscribe, req.Method)
+	assert.Equal(t, []string{"btceur@trade"}, req.Params)
+	assert.Equal(t, int64(1), req.ID)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "BTC-EUR", event.Product.String())
+		assert.Equal(t, "5541.2", event.Price.String())
+		assert.Equal(t, "0.15850568", event.Size.String())
+		assert.Equal(t, exchanges.SideTypeSell, event.Side)
+		assert.Equal(t, int64(1534614057), event.Time.Unix())
+		assert.Equal(t, 321000000, event.Time.Nanosecond())
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received")
+	}
+
+	// the products are subscribed again when the dropped connection is reconnected
+	select {
+	case req := <-subscribed:
+		assert.Equal(t, WebSocketMethodSubscribe, req.Method)
+		assert.Equal(t, []string{"btceur@trade"}, req.Params)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not reconnected")
+	}
+
+	select {
+	case event, ok := <-events:
+		assert.True(t, ok)
+		assert.Equal(t, "BTC-EUR", event.Product.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received after reconnect")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, b.Close(ctx))
+
+	assert.Equal(t, ErrClosed, ticker.Subscribe(exchanges.NewProduct("BTC", "EUR")))
+}
+
+func TestParseTrade(t *testing.T) {
+	c := NewWebSocketClient("")
+
+	trade, err := c.parseTrade([]byte(`{"e":"aggTrade","s":"BTCEUR"}`))
+	assert.NoError(t, err)
+	assert.Nil(t, trade)
+
+	_, err = c.parseTrade([]byte(`{"e":"trade","s":"FOOBAR","p":"1","q":"1","T":0}`))
+	assert.Error(t, err)
+
+	// the subscribed products map the symbols with an unknown quote
+	c.products["FOOBAR"] = exchanges.NewProduct("FOO", "BAR")
+
+	trade, err = c.parseTrade([]byte(`{"e":"trade","s":"FOOBAR","p":"1","q":"2","T":1500,"m":false}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "FOO-BAR", trade.Product.String())
+	assert.Equal(t, exchanges.SideTypeBuy, trade.Side)
+	assert.Equal(t, 500000000, trade.Time.Nanosecond())
+}
+
+func TestBinanceProducts(t *testing.T) {
+	srv, b := newStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/exchangeInfo": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-MBX-USED-WEIGHT-1M", "10")
+
+			fmt.Fprint(w, `{
+				"rateLimits": [
+					{"rateLimitType":"REQUEST_WEIGHT","interval":"MINUTE","intervalNum":1,"limit":6000},
+					{"rateLimitType":"ORDERS","interval":"SECOND","intervalNum":10,"limit":100}
+				],
+				"symbols": [
+					{"symbol":"BTCEUR","status":"TRADING","baseAsset":"BTC","quoteAsset":"EUR","filters":[
+						{"filterType":"PRICE_FILTER","minPrice":"0.01000000","maxPrice":"1000000.00000000","tickSize":"0.01000000"},
+						{"filterType":"LOT_SIZE","minQty":"0.00001000","maxQty":"9000.00000000","stepSize":"0.00001000"}
+					]},
+					{"symbol":"ETHEUR","status":"BREAK","baseAsset":"ETH","quoteAsset":"EUR","filters":[]}
+				]
+			}`)
+		},
+	})
+	defer srv.Close()
+
+	products, err := b.Products().All()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(products))
+
+	product, err := b.Products().Get("BTC-EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.00001", product.BaseIncrement.String())
+	assert.Equal(t, "0.01", product.QuoteIncrement.String())
+	assert.Equal(t, "0.00001", product.MinSize.String())
+	assert.Equal(t, "9000", product.MaxSize.String())
+	assert.True(t, product.IsTrading())
+
+	product, err = b.Products().Get("ETH-EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, exchanges.ProductStatusDelisted, product.Status)
+
+	assert.Equal(t, 6000, b.Weights().Limit())
+	assert.Equal(t, 10, b.Weights().Used())
+}
+
+func TestBinanceOrderBook(t *testing.T) {
+	srv, b := newStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/depth": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "BTCEUR", r.URL.Query().Get("symbol"))
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+
+			fmt.Fprint(w, `{
+				"lastUpdateId": 1027024,
+				"bids": [["5540.00000000","2.00000000"],["5541.00000000","0.10000000"]],
+				"asks": [["5542.00000000","1.00000000"],["5541.50000000","0.50000000"]]
+			}`)
+		},
+	})
+	defer srv.Close()
+
+	book, err := b.OrderBook(exchanges.NewProduct("BTC", "EUR"), 2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "5541.5", book.Asks[0].Price.String())
+	assert.Equal(t, "0.5", book.Asks[0].Size.String())
+	assert.Equal(t, "5541", book.Bids[0].Price.String())
+	assert.Equal(t, "5540", book.Bids[1].Price.String())
+}
+
+func TestBinancePlaceOrder(t *testing.T) {
+	srv, b := newStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/order": func(w http.ResponseWriter, r *http.Request) {
+			assertSigned(t, r)
+
+			params := r.URL.Query()
+
+			if r.Method == http.MethodDelete {
+				assert.Equal(t, "BTCEUR", params.Get("symbol"))
+				assert.Equal(t, "28", params.Get("orderId"))
+
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":-2011,"msg":"Unknown order sent."}`)
+
+				return
+			}
+
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "BTCEUR", params.Get("symbol"))
+			assert.Equal(t, "BUY", params.Get("side"))
+			assert.Equal(t, "LIMIT", params.Get("type"))
+			assert.Equal(t, "GTC", params.Get("timeInForce"))
+			assert.Equal(t, "0.1234", params.Get("quantity"))
+			assert.Equal(t, "5000.5", params.Get("price"))
+
+			fmt.Fprint(w, `{"symbol":"BTCEUR","orderId":28,"status":"NEW"}`)
+		},
+	})
+	defer srv.Close()
+
+	placed, err := b.PlaceOrder(&exchanges.OrderRequest{
+		Product: exchanges.NewProduct("BTC", "EUR"),
+		Side:    exchanges.SideTypeBuy,
+		Type:    exchanges.OrderTypeLimit,
+		Size:    decimal.RequireFromString("0.1234"),
+		Price:   decimal.RequireFromString("5000.5"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "BTCEUR:28", placed.ID)
+
+	assert.EqualError(t, b.CancelOrder(placed.ID), "binance: Unknown order sent. (-2011)")
+	assert.Equal(t, ErrInvalidOrderID, b.CancelOrder("28"))
+}
+
+func TestBinanceBalances(t *testing.T) {
+	srv, b := newStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/account": func(w http.ResponseWriter, r *http.Request) {
+			assertSigned(t, r)
+
+			fmt.Fprint(w, `{"balances":[
+				{"asset":"EUR","free":"1000.50000000","locked":"0.00000000"},
+				{"asset":"BNB","free":"0.00000000","locked":"0.00000000"},
+				{"asset":"BTC","free":"0.40000000","locked":"0.10000000"}
+			]}`)
+		},
+	})
+	defer srv.Close()
+
+	balances, err := b.Balance().Balances()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(balances))
+	assert.Equal(t, "BTC", balances[0].Currency)
+	assert.Equal(t, "0.5", balances[0].Total.String())
+	assert.Equal(t, "0.1", balances[0].Hold.String())
+	assert.Equal(t, "EUR", balances[1].Currency)
+	assert.Equal(t, "1000.5", balances[1].Available.String())
+
+	b.client.Key = ""
+
+	_, err = b.Balance().Balances()
+	assert.Equal(t, ErrMissingCredentials, err)
+}
+
+func TestBinanceRateLimited(t *testing.T) {
+	calls := 0
+
+	srv, b := newStandIn(t, map[string]http.HandlerFunc{
+		"/api/v3/depth": func(w http.ResponseWriter, r *http.Request) {
+			calls++
+
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+	})
+	defer srv.Close()
+
+	_, err := b.OrderBook(exchanges.NewProduct("BTC", "EUR"), 5)
+	assert.IsType(t, &RateLimitError{}, err)
+
+	// the requests are blocked locally until the retry time
+	_, err = b.OrderBook(exchanges.NewProduct("BTC", "EUR"), 5)
+	assert.IsType(t, &RateLimitError{}, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package binance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultAPI of Binance
+const DefaultAPI = "https://api.binance.com"
+
+// Errors
+var (
+	ErrMissingCredentials = errors.New("binance api key and secret are required")
+)
+
+// APIError returned by Binance
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"msg"`
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("binance: %s (%d)", e.Message, e.Code)
+}
+
+// Client of the Binance rest api
+type Client struct {
+	BaseURL    string
+	Key        string
+	Secret     string
+	RecvWindow time.Duration
+	HTTPClient *http.Client
+	Weights    *Weights
+}
+
+// NewClient constructor, the signed requests require key and secret
+func NewClient(key string, secret string) *Client {
+	return &Client{
+		BaseURL:    DefaultAPI,
+		Key:        key,
+		Secret:     secret,
+		RecvWindow: 5 * time.Second,
+		HTTPClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		Weights: NewWeights(DefaultWeightLimit),
+	}
+}
+
+// sign the query string with the secret
+func sign(secret string, query string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(query))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request the path of api, the weight is reserved before sending the request
+func (c *Client) Request(method string, path string, params url.Values, weight int, signed bool, result interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	if signed {
+		if c.Key == "" || c.Secret == "" {
+			return ErrMissingCredentials
+		}
+
+		params.Set("timestamp", strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10))
+		params.Set("recvWindow", strconv.FormatInt(int64(c.RecvWindow/time.Millisecond), 10))
+	}
+
+	query := params.Encode()
+
+	if signed {
+		query += "&signature=" + sign(c.Secret, query)
+	}
+
+	u := c.BaseURL + path
+
+	if query != "" {
+		u += "?" + query
+	}
+
+	if err := c.Weights.Reserve(weight); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+
+	if c.Key != "" {
+		req.Header.Set("X-MBX-APIKEY", c.Key)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if used, err := strconv.Atoi(res.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		c.Weights.Update(used)
+	}
+
+	// 429 is returned when the limit is exceeded and 418 when the ip is banned
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusTeapot {
+		retry, err := strconv.Atoi(res.Header.Get("Retry-After"))
+		if err != nil {
+			retry = 60
+		}
+
+		c.Weights.Block(time.Duration(retry) * time.Second)
+
+		return &RateLimitError{
+			RetryAt: time.Now().Add(time.Duration(retry) * time.Second),
+		}
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{}
+
+		if err := json.NewDecoder(res.Body).Decode(apiErr); err != nil || apiErr.Message == "" {
+			return fmt.Errorf("binance: %s", res.Status)
+		}
+
+		return apiErr
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(result)
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package binance
+
+import (
+	"strings"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+)
+
+// quotes assets of the Binance symbols, the longest codes are tested first
+var quotes = []string{
+	"USDT",
+	"BUSD",
+	"USDC",
+	"TUSD",
+	"BTC",
+	"ETH",
+	"BNB",
+	"EUR",
+	"GBP",
+	"TRY",
+	"AUD",
+	"XRP",
+}
+
+// Symbol of product, the assets are concatenated
+func Symbol(product exchanges.Product) string {
+	return strings.ToUpper(product.From + product.To)
+}
+
+// ProductFromSymbol splits the concatenated symbol on a known quote asset
+func ProductFromSymbol(symbol string) (exchanges.Product, bool) {
+	symbol = strings.ToUpper(symbol)
+
+	for _, quote := range quotes {
+		if len(symbol) > len(quote) && strings.HasSuffix(symbol, quote) {
+			return exchanges.NewProduct(strings.TrimSuffix(symbol, quote), quote), true
+		}
+	}
+
+	return exchanges.Product{}, false
+}
@@ -0,0 +1,445 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultWebSocketAPI of the Binance combined streams
+const DefaultWebSocketAPI = "wss://stream.binance.com:9443/stream"
+
+// WebSocket methods
+const (
+	WebSocketMethodSubscribe   = "SUBSCRIBE"
+	WebSocketMethodUnsubscribe = "UNSUBSCRIBE"
+)
+
+// WebSocketEventTrade is the event of the executed trades
+const WebSocketEventTrade = "trade"
+
+// ErrNotConnected is returned when the websocket is used before Connect
+var ErrNotConnected = errors.New("binance websocket is not connected")
+
+// ErrClosed is returned when the websocket is used after Close
+var ErrClosed = errors.New("binance websocket is closed")
+
+const (
+	// reconnectDelay before the first reconnection of a lost connection, it doubles after each failure
+	reconnectDelay = time.Second
+	// reconnectMaxDelay between two reconnections
+	reconnectMaxDelay = time.Minute
+)
+
+// WebSocketRequest struct
+type WebSocketRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+// WebSocketMessage struct of the combined streams
+type WebSocketMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// WebSocketResponse struct of the requests
+type WebSocketResponse struct {
+	ID    int64     `json:"id"`
+	Error *APIError `json:"error"`
+}
+
+// tradeEvent struct of the trade stream, all the keys are declared because
+// the json decoder matches the keys which differ only by case
+type tradeEvent struct {
+	Event        string          `json:"e"`
+	EventTime    int64           `json:"E"`
+	Symbol       string          `json:"s"`
+	TradeID      int64           `json:"t"`
+	Price        decimal.Decimal `json:"p"`
+	Quantity     decimal.Decimal `json:"q"`
+	TradeTime    int64           `json:"T"`
+	IsBuyerMaker bool            `json:"m"`
+	Ignore       bool            `json:"M"`
+}
+
+// Trade struct received on the trade stream
+type Trade struct {
+	Product exchanges.Product
+	Price   decimal.Decimal
+	Size    decimal.Decimal
+	Time    time.Time
+	Side    exchanges.SideType
+}
+
+// WebSocketClient struct
+type WebSocketClient struct {
+	api          string
+	mu           sync.Mutex
+	ws           *websocket.Conn
+	closed       bool
+	reconnecting bool
+	done         chan struct{}
+	// delay before the first reconnection
+	delay time.Duration
+	id    int64
+	// products subscribed by symbol, they are subscribed again after a reconnection
+	products map[string]exchanges.Product
+	Trades   chan *Trade
+}
+
+// NewWebSocketClient constructor
+func NewWebSocketClient(api string) *WebSocketClient {
+	if api == "" {
+		api = DefaultWebSocketAPI
+	}
+
+	return &WebSocketClient{
+		api:      api,
+		done:     make(chan struct{}),
+		delay:    reconnectDelay,
+		products: make(map[string]exchanges.Product),
+		Trades:   make(chan *Trade, 100),
+	}
+}
+
+// Connect to websocket server, the lost connections are reconnected until Close
+func (c *WebSocketClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.connect()
+}
+
+// dial the websocket server
+func (c *WebSocketClient) dial() (*websocket.Conn, error) {
+	log.Info().Msgf("Binance WebSocket: connecting to %s", c.api)
+
+	ws, _, err := websocket.DefaultDialer.Dial(c.api, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Binance WebSocket: connected to %s", c.api)
+
+	return ws, nil
+}
+
+// connect opens the connection once, the lock is held by the caller
+func (c *WebSocketClient) connect() error {
+	if c.closed {
+		return ErrClosed
+	}
+
+	if c.ws != nil || c.reconnecting {
+		return nil
+	}
+
+	ws, err := c.dial()
+	if err != nil {
+		return err
+	}
+
+	c.ws = ws
+
+	go c.receiver(ws)
+
+	return nil
+}
+
+// Close the connection, a close message is sent until the deadline of ctx
+func (c *WebSocketClient) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	// the receiver closes the trades channel when it stops
+	if c.reconnecting {
+		c.closed = true
+
+		close(c.done)
+
+		return nil
+	}
+
+	if c.ws == nil {
+		return ErrNotConnected
+	}
+
+	c.closed = true
+
+	close(c.done)
+
+	deadline, ok := ctx.Deadline()
+	if ok == false {
+		deadline = time.Now().Add(time.Second)
+	}
+
+	if err := c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline); err != nil {
+		log.Error().Err(err).Msg("Binance WebSocket: close message")
+	}
+
+	return c.ws.Close()
+}
+
+// stream name of the trades of product
+func stream(product exchanges.Product) string {
+	return strings.ToLower(Symbol(product)) + "@" + WebSocketEventTrade
+}
+
+// request of method on the trades of products, the lock is held by the caller
+func (c *WebSocketClient) request(method string, products []exchanges.Product) *WebSocketRequest {
+	c.id++
+
+	req := &WebSocketRequest{
+		Method: method,
+		Params: []string{},
+		ID:     c.id,
+	}
+
+	for _, product := range products {
+		req.Params = append(req.Params, stream(product))
+	}
+
+	return req
+}
+
+// track the products of method, the lock is held by the caller.
+// The products are kept to map the symbols which cannot be split on a known quote.
+func (c *WebSocketClient) track(method string, products []exchanges.Product) {
+	for _, product := range products {
+		if method == WebSocketMethodSubscribe {
+			c.products[Symbol(product)] = product
+		} else {
+			delete(c.products, Symbol(product))
+		}
+	}
+}
+
+// send the method on the connection, it is sent by the reconnection when the connection is lost
+func (c *WebSocketClient) send(method string, products []exchanges.Product) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reconnecting && c.closed == false {
+		c.track(method, products)
+
+		return nil
+	}
+
+	// the connection is opened by the first subscription
+	if c.ws == nil && method == WebSocketMethodUnsubscribe {
+		return nil
+	}
+
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(c.request(method, products))
+	if err != nil {
+		return err
+	}
+
+	// the products are tracked before the trades of the subscription are received
+	c.track(method, products)
+
+	return c.ws.WriteMessage(websocket.TextMessage, b)
+}
+
+// Subscribe to the trades of products
+func (c *WebSocketClient) Subscribe(products ...exchanges.Product) error {
+	return c.send(WebSocketMethodSubscribe, products)
+}
+
+// Unsubscribe to the trades of products
+func (c *WebSocketClient) Unsubscribe(products ...exchanges.Product) error {
+	return c.send(WebSocketMethodUnsubscribe, products)
+}
+
+// product of symbol
+func (c *WebSocketClient) product(symbol string) (exchanges.Product, bool) {
+	c.mu.Lock()
+	product, ok := c.products[strings.ToUpper(symbol)]
+	c.mu.Unlock()
+
+	if ok {
+		return product, true
+	}
+
+	return ProductFromSymbol(symbol)
+}
+
+// reconnect the lost connection with an increasing delay then subscribe again to the products,
+// it returns nil when the client is closed
+func (c *WebSocketClient) reconnect(cause error) *websocket.Conn {
+	c.mu.Lock()
+
+	if c.closed {
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	c.ws = nil
+	c.reconnecting = true
+
+	c.mu.Unlock()
+
+	log.Error().Err(cause).Msg("Binance WebSocket: connection lost")
+
+	delay := c.delay
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-c.done:
+			return nil
+		}
+
+		ws, err := c.dial()
+		if err != nil {
+			log.Error().Err(err).Msgf("Binance WebSocket: reconnect, next attempt in %s", delay)
+
+			if delay *= 2; delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+
+			continue
+		}
+
+		c.mu.Lock()
+
+		if c.closed {
+			c.mu.Unlock()
+
+			ws.Close()
+
+			return nil
+		}
+
+		c.ws = ws
+		c.reconnecting = false
+
+		// a failed subscription loses the connection which is reconnected again
+		if len(c.products) > 0 {
+			products := []exchanges.Product{}
+
+			for _, product := range c.products {
+				products = append(products, product)
+			}
+
+			if err := ws.WriteJSON(c.request(WebSocketMethodSubscribe, products)); err != nil {
+				log.Error().Err(err).Msg("Binance WebSocket: subscribe after reconnect")
+			}
+		}
+
+		c.mu.Unlock()
+
+		return ws
+	}
+}
+
+// receiver reads the messages until the client is closed, the lost connections are reconnected
+func (c *WebSocketClient) receiver(ws *websocket.Conn) {
+	defer close(c.Trades)
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			if ws = c.reconnect(err); ws == nil {
+				return
+			}
+
+			continue
+		}
+
+		if err := c.processMessage(message); err != nil {
+			log.Error().Err(err).Msg("Binance WebSocket")
+		}
+	}
+}
+
+func (c *WebSocketClient) processMessage(message []byte) error {
+	msg := &WebSocketMessage{}
+
+	if err := json.Unmarshal(message, msg); err != nil {
+		return err
+	}
+
+	// the responses of the requests are not wrapped in a stream
+	if msg.Stream == "" {
+		res := &WebSocketResponse{}
+
+		if err := json.Unmarshal(message, res); err != nil {
+			return err
+		}
+
+		if res.Error != nil {
+			return fmt.Errorf("request %d: %s", res.ID, res.Error.Message)
+		}
+
+		return nil
+	}
+
+	trade, err := c.parseTrade(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	if trade != nil {
+		c.Trades <- trade
+	}
+
+	return nil
+}
+
+// parseTrade of a trade stream event, the other events are ignored
+func (c *WebSocketClient) parseTrade(data []byte) (*Trade, error) {
+	event := &tradeEvent{}
+
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, err
+	}
+
+	if event.Event != WebSocketEventTrade {
+		return nil, nil
+	}
+
+	product, ok := c.product(event.Symbol)
+	if ok == false {
+		return nil, fmt.Errorf("unknown symbol %s", event.Symbol)
+	}
+
+	// the buyer is the maker when the taker sold
+	side := exchanges.SideTypeBuy
+
+	if event.IsBuyerMaker {
+		side = exchanges.SideTypeSell
+	}
+
+	return &Trade{
+		Product: product,
+		Price:   event.Price,
+		Size:    event.Quantity,
+		Time:    time.Unix(0, event.TradeTime*int64(time.Millisecond)).UTC(),
+		Side:    side,
+	}, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package binance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultWeightLimit of the requests by minute
+const DefaultWeightLimit = 1200
+
+// RateLimitError is returned when a request would exceed the weight limit
+type RateLimitError struct {
+	RetryAt time.Time
+}
+
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf("binance rate limit reached, retry at %s", e.RetryAt.Format(time.RFC3339))
+}
+
+// Weights tracks the weight of the requests used in the current minute
+type Weights struct {
+	mu      sync.Mutex
+	limit   int
+	used    int
+	window  time.Time
+	retryAt time.Time
+	now     func() time.Time
+}
+
+// NewWeights tracker with the weight limit by minute
+func NewWeights(limit int) *Weights {
+	if limit <= 0 {
+		limit = DefaultWeightLimit
+	}
+
+	return &Weights{
+		limit: limit,
+		now:   time.Now,
+	}
+}
+
+// reset the used weight at the beginning of a new minute
+func (w *Weights) reset(now time.Time) {
+	window := now.Truncate(time.Minute)
+
+	if window.After(w.window) {
+		w.window = window
+		w.used = 0
+	}
+}
+
+// Reserve weight for a request, the weight is not reserved when the limit would be exceeded
+func (w *Weights) Reserve(weight int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+
+	if now.Before(w.retryAt) {
+		return &RateLimitError{
+			RetryAt: w.retryAt,
+		}
+	}
+
+	w.reset(now)
+
+	if w.used+weight > w.limit {
+		return &RateLimitError{
+			RetryAt: w.window.Add(time.Minute),
+		}
+	}
+
+	w.used += weight
+
+	return nil
+}
+
+// Update the used weight with the value reported by Binance
+func (w *Weights) Update(used int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.reset(w.now())
+
+	w.used = used
+}
+
+// Block the requests during d after a rate limit response
+func (w *Weights) Block(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.retryAt = w.now().Add(d)
+}
+
+// SetLimit of the weight by minute
+func (w *Weights) SetLimit(limit int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if limit > 0 {
+		w.limit = limit
+	}
+}
+
+// Used weight in the current minute
+func (w *Weights) Used() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.reset(w.now())
+
+	return w.used
+}
+
+// Limit of the weight by minute
+func (w *Weights) Limit() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.limit
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package binance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeights(t *testing.T) {
+	now := time.Date(2018, 8, 18, 10, 0, 30, 0, time.UTC)
+
+	w := NewWeights(10)
+	w.now = func() time.Time {
+		return now
+	}
+
+	assert.NoError(t, w.Reserve(6))
+	assert.NoError(t, w.Reserve(4))
+	assert.Equal(t, 10, w.Used())
+
+	err := w.Reserve(1)
+	assert.Error(t, err)
+	assert.Equal(t, time.Date(2018, 8, 18, 10, 1, 0, 0, time.UTC), err.(*RateLimitError).RetryAt)
+
+	// the weight is reset each minute
+	now = now.Add(time.Minute)
+
+	assert.Equal(t, 0, w.Used())
+	assert.NoError(t, w.Reserve(1))
+
+	// the weight reported by Binance replaces the local count
+	w.Update(9)
+	assert.Equal(t, 9, w.Used())
+	assert.Error(t, w.Reserve(2))
+
+	w.SetLimit(20)
+	w.SetLimit(0)
+	assert.Equal(t, 20, w.Limit())
+	assert.NoError(t, w.Reserve(2))
+
+	w.Block(30 * time.Second)
+
+	err = w.Reserve(1)
+	assert.Error(t, err)
+	assert.Equal(t, now.Add(30*time.Second), err.(*RateLimitError).RetryAt)
+
+	now = now.Add(30 * time.Second)
+
+	assert.NoError(t, w.Reserve(1))
+}
+
+func TestNewWeightsDefaultLimit(t *testing.T) {
+	assert.Equal(t, DefaultWeightLimit, NewWeights(0).Limit())
+}
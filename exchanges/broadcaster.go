@@ -0,0 +1,184 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultListenerBuffer is the number of events buffered by a listener
+const DefaultListenerBuffer = 100
+
+// DeliveryPolicy of a listener when its buffer is full
+type DeliveryPolicy string
+
+// DeliveryPolicy enum
+const (
+	// DeliveryPolicyBlock waits for the listener to read the event, the other listeners wait too
+	DeliveryPolicyBlock DeliveryPolicy = "block"
+	// DeliveryPolicyDrop discards the event for this listener only
+	DeliveryPolicyDrop DeliveryPolicy = "drop"
+)
+
+// ListenOptions of a listener
+type ListenOptions struct {
+	// Buffer size, DefaultListenerBuffer when zero
+	Buffer int
+	// Policy when the buffer is full, DeliveryPolicyBlock when empty
+	Policy DeliveryPolicy
+}
+
+// Listener of a broadcaster, C is closed by Close or when the broadcaster is closed
+type Listener struct {
+	C           <-chan *TickerEvent
+	ch          chan *TickerEvent
+	policy      DeliveryPolicy
+	dropped     uint64
+	done        chan struct{}
+	once        sync.Once
+	broadcaster *Broadcaster
+}
+
+// Dropped events count
+func (l *Listener) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// stop unblocks the pending delivery to the listener
+func (l *Listener) stop() {
+	l.once.Do(func() {
+		close(l.done)
+	})
+}
+
+// Close the listener, the broadcaster stops delivering events to it
+func (l *Listener) Close() {
+	l.stop()
+
+	l.broadcaster.remove(l)
+}
+
+// Broadcaster delivers each ticker event to every listener
+type Broadcaster struct {
+	mu        sync.RWMutex
+	listeners map[*Listener]struct{}
+	closed    bool
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewBroadcaster of ticker events
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		listeners: make(map[*Listener]struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Listen to the events, the channel of the listener is closed when the broadcaster is closed
+func (b *Broadcaster) Listen(options *ListenOptions) *Listener {
+	if options == nil {
+		options = &ListenOptions{}
+	}
+
+	if options.Buffer <= 0 {
+		options.Buffer = DefaultListenerBuffer
+	}
+
+	if options.Policy == "" {
+		options.Policy = DeliveryPolicyBlock
+	}
+
+	ch := make(chan *TickerEvent, options.Buffer)
+
+	l := &Listener{
+		C:           ch,
+		ch:          ch,
+		policy:      options.Policy,
+		done:        make(chan struct{}),
+		broadcaster: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		l.stop()
+		close(l.ch)
+
+		return l
+	}
+
+	b.listeners[l] = struct{}{}
+
+	return l
+}
+
+// remove the listener and close its channel
+func (b *Broadcaster) remove(l *Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.listeners[l]; ok == false {
+		return
+	}
+
+	delete(b.listeners, l)
+
+	close(l.ch)
+}
+
+// Len of the listeners
+func (b *Broadcaster) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.listeners)
+}
+
+// Broadcast the event to the listeners
+func (b *Broadcaster) Broadcast(event *TickerEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for l := range b.listeners {
+		if l.policy == DeliveryPolicyDrop {
+			select {
+			case l.ch <- event:
+			default:
+				atomic.AddUint64(&l.dropped, 1)
+			}
+
+			continue
+		}
+
+		select {
+		case l.ch <- event:
+		case <-l.done:
+		case <-b.done:
+		}
+	}
+}
+
+// Close the broadcaster and the channels of the listeners
+func (b *Broadcaster) Close() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for l := range b.listeners {
+		l.stop()
+
+		delete(b.listeners, l)
+
+		close(l.ch)
+	}
+}
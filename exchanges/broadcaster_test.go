@@ -0,0 +1,131 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTickerEvent(price int64) *TickerEvent {
+	return &TickerEvent{
+		Product: NewProduct("BTC", "EUR"),
+		Price:   decimal.New(price, 0),
+		Time:    time.Now(),
+	}
+}
+
+func TestBroadcasterFanOut(t *testing.T) {
+	b := NewBroadcaster()
+
+	l1 := b.Listen(nil)
+	l2 := b.Listen(&ListenOptions{
+		Buffer: 1,
+		Policy: DeliveryPolicyDrop,
+	})
+	assert.Equal(t, 2, b.Len())
+
+	b.Broadcast(newTickerEvent(1))
+	b.Broadcast(newTickerEvent(2))
+
+	assert.Equal(t, "1", (<-l1.C).Price.String())
+	assert.Equal(t, "2", (<-l1.C).Price.String())
+
+	// the second event is dropped by the full buffer of l2 only
+	assert.Equal(t, "1", (<-l2.C).Price.String())
+	assert.Equal(t, uint64(1), l2.Dropped())
+	assert.Equal(t, uint64(0), l1.Dropped())
+
+	l2.Close()
+	l2.Close()
+	assert.Equal(t, 1, b.Len())
+
+	_, ok := <-l2.C
+	assert.False(t, ok)
+
+	b.Broadcast(newTickerEvent(3))
+	assert.Equal(t, "3", (<-l1.C).Price.String())
+
+	b.Close()
+
+	_, ok = <-l1.C
+	assert.False(t, ok)
+	assert.Equal(t, 0, b.Len())
+
+	// the listeners of a closed broadcaster are closed
+	_, ok = <-b.Listen(nil).C
+	assert.False(t, ok)
+
+	l1.Close()
+}
+
+func TestBroadcasterBlockedListenerClose(t *testing.T) {
+	b := NewBroadcaster()
+
+	l := b.Listen(&ListenOptions{
+		Buffer: 1,
+	})
+
+	b.Broadcast(newTickerEvent(1))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		// blocks until the listener leaves
+		b.Broadcast(newTickerEvent(2))
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("broadcast did not block on the full listener")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	l.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast still blocked after the listener left")
+	}
+
+	assert.Equal(t, "1", (<-l.C).Price.String())
+
+	_, ok := <-l.C
+	assert.False(t, ok)
+}
+
+func TestBroadcasterBlockedClose(t *testing.T) {
+	b := NewBroadcaster()
+
+	b.Listen(&ListenOptions{
+		Buffer: 1,
+	})
+
+	b.Broadcast(newTickerEvent(1))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		b.Broadcast(newTickerEvent(2))
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+
+	b.Close()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast still blocked after the broadcaster was closed")
+	}
+}
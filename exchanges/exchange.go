@@ -5,10 +5,13 @@
 package exchanges
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type SideType string
@@ -20,17 +23,17 @@ const (
 
 // TickerEvent struct
 type TickerEvent struct {
-	Product Product   `json:"product"`
-	Price   float64   `json:"price"`
-	Side    SideType  `json:"side"`
-	Time    time.Time `json:"time"`
-	Size    float64   `json:"size"`
+	Product Product         `json:"product"`
+	Price   decimal.Decimal `json:"price"`
+	Side    SideType        `json:"side"`
+	Time    time.Time       `json:"time"`
+	Size    decimal.Decimal `json:"size"`
 }
 
 // OrderEvent struct
 type OrderEvent struct {
 	Side  SideType
-	Price float64
+	Price decimal.Decimal
 }
 
 // Product struct
@@ -95,11 +98,94 @@ type ExchangeProvider interface {
 
 	Ticker() TickerProvider
 	// Order(from string, to string) (<-chan *OrderEvent, error)
+
+	Balance() BalanceProvider
+
+	// Products catalog of exchange, nil when the exchange does not provide it
+	Products() *ProductCatalog
 }
 
 // TickerProvider interface
 type TickerProvider interface {
 	Subscribe(products ...Product) error
 	Unsubscribe(products ...Product) error
+	// Channel of a new listener of the feed dropping the events it cannot buffer,
+	// Listen returns a listener which can be closed
 	Channel() <-chan *TickerEvent
+	// Listen to the feed, every listener receives all the events
+	Listen(options *ListenOptions) *Listener
+}
+
+// Balance of a currency on an exchange account
+type Balance struct {
+	Currency  string          `json:"currency"`
+	Total     decimal.Decimal `json:"total"`
+	Available decimal.Decimal `json:"available"`
+	Hold      decimal.Decimal `json:"hold"`
+}
+
+// BalanceProvider interface
+type BalanceProvider interface {
+	Balances() ([]*Balance, error)
+}
+
+// FillRecorder interface is implemented by the virtual balances updated by the emulated orders
+type FillRecorder interface {
+	// Fill the order of size for value in quote currency, the fill the balances cannot cover is refused
+	Fill(side SideType, product Product, size decimal.Decimal, value decimal.Decimal) error
+}
+
+// BookEntry of an order book level
+type BookEntry struct {
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+// OrderBook of a product, the bids are sorted by descending price and the asks by ascending price
+type OrderBook struct {
+	Product Product      `json:"product"`
+	Bids    []*BookEntry `json:"bids"`
+	Asks    []*BookEntry `json:"asks"`
+	Time    time.Time    `json:"time"`
+}
+
+// BookProvider interface is implemented by the exchanges providing the order book of products
+type BookProvider interface {
+	OrderBook(product Product, depth int) (*OrderBook, error)
+}
+
+// OrderType type
+type OrderType string
+
+// OrderType enum
+const (
+	OrderTypeMarket OrderType = "market"
+	OrderTypeLimit  OrderType = "limit"
+)
+
+// OrderRequest struct, the price is the unit price of the limit orders
+type OrderRequest struct {
+	Product Product         `json:"product"`
+	Side    SideType        `json:"side"`
+	Type    OrderType       `json:"type"`
+	Size    decimal.Decimal `json:"size"`
+	Price   decimal.Decimal `json:"price"`
+}
+
+// PlacedOrder struct returned by the exchange
+type PlacedOrder struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// TradeProvider interface is implemented by the exchanges placing orders on an authenticated account
+type TradeProvider interface {
+	PlaceOrder(order *OrderRequest) (*PlacedOrder, error)
+	CancelOrder(id string) error
+}
+
+// Closer interface is implemented by the exchanges holding connections, the listeners of their
+// ticker are closed and the exchange cannot be used after Close
+type Closer interface {
+	Close(ctx context.Context) error
 }
@@ -0,0 +1,78 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeeTier struct, the rates are applied from the trailing 30 days volume in quote currency
+type FeeTier struct {
+	Volume decimal.Decimal `json:"volume"`
+	Maker  decimal.Decimal `json:"maker"`
+	Taker  decimal.Decimal `json:"taker"`
+}
+
+// FeeSchedule of an exchange
+type FeeSchedule struct {
+	Tiers []FeeTier `json:"tiers"`
+}
+
+// NewFeeSchedule with tiers
+func NewFeeSchedule(tiers ...FeeTier) *FeeSchedule {
+	s := &FeeSchedule{
+		Tiers: tiers,
+	}
+
+	sort.Slice(s.Tiers, func(i, j int) bool {
+		return s.Tiers[i].Volume.LessThan(s.Tiers[j].Volume)
+	})
+
+	return s
+}
+
+// Tier for the trailing volume
+func (s *FeeSchedule) Tier(volume decimal.Decimal) FeeTier {
+	tier := FeeTier{}
+
+	if s == nil {
+		return tier
+	}
+
+	for _, t := range s.Tiers {
+		if volume.LessThan(t.Volume) {
+			break
+		}
+
+		tier = t
+	}
+
+	return tier
+}
+
+// Rate of fee for the trailing volume, maker is true for the orders adding liquidity
+func (s *FeeSchedule) Rate(volume decimal.Decimal, maker bool) decimal.Decimal {
+	tier := s.Tier(volume)
+
+	if maker {
+		return tier.Maker
+	}
+
+	return tier.Taker
+}
+
+// FeeSchedules by provider name
+type FeeSchedules map[string]*FeeSchedule
+
+// Get schedule of provider, nil without fees
+func (s FeeSchedules) Get(provider string) *FeeSchedule {
+	if s == nil {
+		return nil
+	}
+
+	return s[provider]
+}
@@ -0,0 +1,33 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeSchedule(t *testing.T) {
+	s := NewFeeSchedule(
+		FeeTier{Volume: decimal.New(1000000, 0), Maker: decimal.Zero, Taker: decimal.RequireFromString("0.002")},
+		FeeTier{Volume: decimal.Zero, Maker: decimal.RequireFromString("0.001"), Taker: decimal.RequireFromString("0.003")},
+	)
+
+	assert.Equal(t, "0.003", s.Rate(decimal.Zero, false).String())
+	assert.Equal(t, "0.001", s.Rate(decimal.New(999999, 0), true).String())
+	assert.Equal(t, "0.002", s.Rate(decimal.New(1000000, 0), false).String())
+	assert.True(t, s.Rate(decimal.New(2000000, 0), true).IsZero())
+}
+
+func TestFeeScheduleNil(t *testing.T) {
+	var schedules FeeSchedules
+
+	s := schedules.Get("gdax")
+
+	assert.Nil(t, s)
+	assert.True(t, s.Rate(decimal.New(1000, 0), false).IsZero())
+}
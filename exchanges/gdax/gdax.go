@@ -5,51 +5,234 @@
 package gdax
 
 import (
-	"strconv"
+	"context"
+	"errors"
+	"strings"
+	"sync"
 
 	"github.com/euskadi31/cryptotrader/exchanges"
 	gdaxclient "github.com/preichenberger/go-gdax"
 	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
 )
 
+// DefaultAPI of GDAX
+const (
+	DefaultAPI = "https://api.gdax.com"
+	SandboxAPI = "https://api-public.sandbox.gdax.com"
+)
+
+// ErrMissingCredentials is returned by the authenticated requests without credentials
+var ErrMissingCredentials = errors.New("gdax api key, secret and passphrase are required")
+
+// Options of GDAX
+type Options struct {
+	// Name of the instance, gdax when empty
+	Name       string
+	Key        string
+	Secret     string
+	Passphrase string
+	// Sandbox connects to the public sandbox when the urls are empty
+	Sandbox bool
+	// API url, DefaultAPI or SandboxAPI when empty
+	API string
+	// WebSocketAPI url, DefaultWebSocketAPI or SandboxWebSocketAPI when empty
+	WebSocketAPI string
+}
+
 // GDAX struct
 type GDAX struct {
-	client *gdaxclient.Client
-	ws     *WebSocketClient
+	name     string
+	client   *gdaxclient.Client
+	ws       *WebSocketClient
+	ticker   *Ticker
+	products *exchanges.ProductCatalog
 }
 
-// NewGDAX Exchange
-func NewGDAX() (*GDAX, error) {
-	e := &GDAX{
-		client: gdaxclient.NewClient("", "", ""),
-		ws:     NewWebSocketClient(),
+// NewGDAX Exchange, the websocket is connected by the first subscription
+func NewGDAX(options *Options) (*GDAX, error) {
+	if options == nil {
+		options = &Options{}
 	}
 
-	if err := e.ws.Connect(); err != nil {
-		return nil, err
+	if options.Name == "" {
+		options.Name = "gdax"
+	}
+
+	if options.API == "" {
+		options.API = DefaultAPI
+
+		if options.Sandbox {
+			options.API = SandboxAPI
+		}
+	}
+
+	if options.WebSocketAPI == "" {
+		options.WebSocketAPI = DefaultWebSocketAPI
+
+		if options.Sandbox {
+			options.WebSocketAPI = SandboxWebSocketAPI
+		}
 	}
 
-	return e, nil
+	client := gdaxclient.NewClient(options.Secret, options.Key, options.Passphrase)
+	client.BaseURL = strings.TrimRight(options.API, "/")
+
+	ws := NewWebSocketClient(options.WebSocketAPI)
+
+	return &GDAX{
+		name:     options.Name,
+		client:   client,
+		ws:       ws,
+		ticker:   newTicker(ws),
+		products: exchanges.NewProductCatalog(loadProducts(client), exchanges.DefaultCatalogTTL),
+	}, nil
+}
+
+// NewProvider factory of the gdax instances
+func NewProvider(config *exchanges.ProviderConfig, providers exchanges.Manager) (exchanges.ExchangeProvider, error) {
+	return NewGDAX(&Options{
+		Name:         config.Name,
+		Key:          config.Key,
+		Secret:       config.Secret,
+		Passphrase:   config.Passphrase,
+		Sandbox:      config.Sandbox,
+		API:          config.API,
+		WebSocketAPI: config.WebSocketAPI,
+	})
+}
+
+// DefaultFeeSchedule of GDAX, the market orders pay the taker fee
+func DefaultFeeSchedule() *exchanges.FeeSchedule {
+	return exchanges.NewFeeSchedule(exchanges.FeeTier{
+		Volume: decimal.Zero,
+		Maker:  decimal.Zero,
+		Taker:  decimal.New(25, -4),
+	})
 }
 
 // Name of provider
 func (e GDAX) Name() string {
-	return "gdax"
+	return e.name
 }
 
-// Ticker channel
+// Ticker shared by the consumers of the instance
 func (e *GDAX) Ticker() exchanges.TickerProvider {
-	return &Ticker{
-		ws: e.ws,
+	return e.ticker
+}
+
+// Balance provider
+func (e *GDAX) Balance() exchanges.BalanceProvider {
+	return &Balance{
+		client: e.client,
+	}
+}
+
+// Products catalog loaded from the REST api
+func (e *GDAX) Products() *exchanges.ProductCatalog {
+	return e.products
+}
+
+// Close the websocket and the listeners of the ticker
+func (e *GDAX) Close(ctx context.Context) error {
+	e.ticker.broadcaster.Close()
+
+	return e.ws.Close(ctx)
+}
+
+// hasCredentials of the authenticated requests
+func hasCredentials(client *gdaxclient.Client) bool {
+	return client.Key != "" && client.Secret != "" && client.Passphrase != ""
+}
+
+// PlaceOrder on the account
+func (e *GDAX) PlaceOrder(order *exchanges.OrderRequest) (*exchanges.PlacedOrder, error) {
+	if hasCredentials(e.client) == false {
+		return nil, ErrMissingCredentials
+	}
+
+	orderType := order.Type
+
+	if orderType == "" {
+		orderType = exchanges.OrderTypeMarket
+	}
+
+	req := &gdaxclient.Order{
+		Type:      string(orderType),
+		Side:      string(order.Side),
+		ProductId: order.Product.String(),
+		Size:      order.Size.InexactFloat64(),
+	}
+
+	if orderType == exchanges.OrderTypeLimit {
+		req.Price = order.Price.InexactFloat64()
+	}
+
+	placed, err := e.client.CreateOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exchanges.PlacedOrder{
+		ID:          placed.Id,
+		Description: strings.Join([]string{req.Side, order.Size.String(), req.ProductId, req.Type, placed.Status}, " "),
+	}, nil
+}
+
+// CancelOrder by id
+func (e *GDAX) CancelOrder(id string) error {
+	if hasCredentials(e.client) == false {
+		return ErrMissingCredentials
+	}
+
+	return e.client.CancelOrder(id)
+}
+
+// Balance struct
+type Balance struct {
+	client *gdaxclient.Client
+}
+
+// Balances of the accounts
+func (b Balance) Balances() ([]*exchanges.Balance, error) {
+	if hasCredentials(b.client) == false {
+		return nil, ErrMissingCredentials
+	}
+
+	accounts, err := b.client.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []*exchanges.Balance{}
+
+	for _, account := range accounts {
+		balances = append(balances, &exchanges.Balance{
+			Currency:  account.Currency,
+			Total:     decimal.NewFromFloat(account.Balance),
+			Available: decimal.NewFromFloat(account.Available),
+			Hold:      decimal.NewFromFloat(account.Hold),
+		})
 	}
+
+	return balances, nil
 }
 
 // Ticker struct
 type Ticker struct {
-	ws *WebSocketClient
+	ws          *WebSocketClient
+	broadcaster *exchanges.Broadcaster
+	once        sync.Once
+}
+
+func newTicker(ws *WebSocketClient) *Ticker {
+	return &Ticker{
+		ws:          ws,
+		broadcaster: exchanges.NewBroadcaster(),
+	}
 }
 
-func (t Ticker) convertProduct(products []exchanges.Product) []*WebSocketProduct {
+func (t *Ticker) convertProduct(products []exchanges.Product) []*WebSocketProduct {
 	sp := []*WebSocketProduct{}
 
 	for _, p := range products {
@@ -104,40 +287,50 @@ func (t *Ticker) Unsubscribe(products ...exchanges.Product) error {
 	return nil
 }
 
-// Channel TickerEvent
+// Channel TickerEvent, the listener cannot be closed before the ticker
+// so the events it cannot buffer are dropped instead of blocking the other listeners
 func (t *Ticker) Channel() <-chan *exchanges.TickerEvent {
-	out := make(chan *exchanges.TickerEvent)
-
-	go func() {
-		for {
-			select {
-			case t := <-t.ws.Ticker:
-				price, err := strconv.ParseFloat(t.Price, 64)
-				if err != nil {
-					log.Error().Err(err).Msg("")
-				}
-
-				size, err := strconv.ParseFloat(t.LastSize, 64)
-				if err != nil {
-					log.Error().Err(err).Msg("")
-				}
-
-				side := exchanges.SideTypeBuy
-
-				if t.Side == "sell" {
-					side = exchanges.SideTypeSell
-				}
-
-				out <- &exchanges.TickerEvent{
-					Product: exchanges.NewProduct(t.Product.From, t.Product.To),
-					Price:   price,
-					Time:    t.Time.Time(),
-					Side:    side,
-					Size:    size,
-				}
-			}
+	return t.Listen(&exchanges.ListenOptions{
+		Policy: exchanges.DeliveryPolicyDrop,
+	}).C
+}
+
+// Listen to the ticker, every listener receives all the events
+func (t *Ticker) Listen(options *exchanges.ListenOptions) *exchanges.Listener {
+	t.once.Do(func() {
+		go t.broadcast()
+	})
+
+	return t.broadcaster.Listen(options)
+}
+
+// broadcast the ticker messages of the websocket to the listeners
+func (t *Ticker) broadcast() {
+	defer t.broadcaster.Close()
+
+	for msg := range t.ws.Ticker {
+		price, err := decimal.NewFromString(msg.Price)
+		if err != nil {
+			log.Error().Err(err).Msg("")
+		}
+
+		size, err := decimal.NewFromString(msg.LastSize)
+		if err != nil {
+			log.Error().Err(err).Msg("")
 		}
-	}()
 
-	return out
+		side := exchanges.SideTypeBuy
+
+		if msg.Side == "sell" {
+			side = exchanges.SideTypeSell
+		}
+
+		t.broadcaster.Broadcast(&exchanges.TickerEvent{
+			Product: exchanges.NewProduct(msg.Product.From, msg.Product.To),
+			Price:   price,
+			Time:    msg.Time.Time(),
+			Side:    side,
+			Size:    size,
+		})
+	}
 }
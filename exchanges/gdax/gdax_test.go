@@ -0,0 +1,339 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package gdax
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGDAXOptions(t *testing.T) {
+	e, err := NewGDAX(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "gdax", e.Name())
+	assert.Equal(t, DefaultAPI, e.client.BaseURL)
+	assert.Equal(t, DefaultWebSocketAPI, e.ws.api)
+
+	e, err = NewGDAX(&Options{
+		Name:    "gdax_sandbox",
+		Sandbox: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "gdax_sandbox", e.Name())
+	assert.Equal(t, SandboxAPI, e.client.BaseURL)
+	assert.Equal(t, SandboxWebSocketAPI, e.ws.api)
+
+	provider, err := NewProvider(&exchanges.ProviderConfig{
+		Name:         "local",
+		Key:          "key",
+		Secret:       "secret",
+		Passphrase:   "passphrase",
+		Sandbox:      true,
+		API:          "http://127.0.0.1:8000/",
+		WebSocketAPI: "ws://127.0.0.1:8000/ws",
+	}, nil)
+	assert.NoError(t, err)
+
+	e = provider.(*GDAX)
+	assert.Equal(t, "http://127.0.0.1:8000", e.client.BaseURL)
+	assert.Equal(t, "ws://127.0.0.1:8000/ws", e.ws.api)
+	assert.Equal(t, "passphrase", e.client.Passphrase)
+}
+
+func TestGDAXMissingCredentials(t *testing.T) {
+	e, err := NewGDAX(&Options{
+		Key:    "key",
+		Secret: "secret",
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Balance().Balances()
+	assert.Equal(t, ErrMissingCredentials, err)
+
+	_, err = e.PlaceOrder(&exchanges.OrderRequest{
+		Product: exchanges.NewProduct("BTC", "EUR"),
+		Side:    exchanges.SideTypeBuy,
+	})
+	assert.Equal(t, ErrMissingCredentials, err)
+	assert.Equal(t, ErrMissingCredentials, e.CancelOrder("foo"))
+}
+
+func TestGDAXTicker(t *testing.T) {
+	subscribed := make(chan *WebSocketSubscribeRequest, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req := &WebSocketSubscribeRequest{}
+
+		if err := conn.ReadJSON(req); err != nil {
+			return
+		}
+
+		subscribed <- req
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ticker","trade_id":20153558,"sequence":3262786978,"time":"2017-09-02T17:05:49.250000Z","product_id":"BTC-EUR","price":"4388.01000000","side":"sell","last_size":"0.03000000","best_bid":"4388","best_ask":"4388.01"}`))
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e, err := NewGDAX(&Options{
+		WebSocketAPI: "ws" + strings.TrimPrefix(srv.URL, "http"),
+	})
+	assert.NoError(t, err)
+
+	ticker := e.Ticker()
+	assert.True(t, ticker == e.Ticker())
+
+	events := ticker.Channel()
+	listener := ticker.Listen(&exchanges.ListenOptions{
+		Policy: exchanges.DeliveryPolicyDrop,
+	})
+	defer listener.Close()
+
+	// the connection is opened by the first subscription
+	assert.NoError(t, ticker.Unsubscribe(exchanges.NewProduct("BTC", "EUR")))
+	assert.NoError(t, ticker.Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	req := <-subscribed
+
+	assert.Equal(t, WebSocketEventTypeSubscribe, req.Type)
+	assert.Equal(t, WebSocketChannelTypeTicker, req.Channels[0].Name)
+	assert.Equal(t, "BTC", req.Channels[0].Products[0].From)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "BTC-EUR", event.Product.String())
+		assert.Equal(t, "4388.01", event.Price.String())
+		assert.Equal(t, "0.03", event.Size.String())
+		assert.Equal(t, exchanges.SideTypeSell, event.Side)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received")
+	}
+
+	// every listener receives the event
+	select {
+	case event := <-listener.C:
+		assert.Equal(t, "4388.01", event.Price.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received by the second listener")
+	}
+}
+
+func TestGDAXChannelNotRead(t *testing.T) {
+	count := exchanges.DefaultListenerBuffer + 10
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req := &WebSocketSubscribeRequest{}
+
+		if err := conn.ReadJSON(req); err != nil {
+			return
+		}
+
+		for i := 0; i < count; i++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ticker","trade_id":20153558,"sequence":3262786978,"time":"2017-09-02T17:05:49.250000Z","product_id":"BTC-EUR","price":"4388.01000000","side":"sell","last_size":"0.03000000","best_bid":"4388","best_ask":"4388.01"}`))
+		}
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e, err := NewGDAX(&Options{
+		WebSocketAPI: "ws" + strings.TrimPrefix(srv.URL, "http"),
+	})
+	assert.NoError(t, err)
+	defer e.Close(context.Background())
+
+	ticker := e.Ticker()
+
+	// the channel is never read
+	ticker.Channel()
+
+	listener := ticker.Listen(nil)
+	defer listener.Close()
+
+	assert.NoError(t, ticker.Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	// the listener receives all the events while the buffer of the channel is full
+	for i := 0; i < count; i++ {
+		select {
+		case <-listener.C:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("ticker event %d not received", i)
+		}
+	}
+}
+
+func TestGDAXClose(t *testing.T) {
+	closed := make(chan error, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				closed <- err
+
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e, err := NewGDAX(&Options{
+		WebSocketAPI: "ws" + strings.TrimPrefix(srv.URL, "http"),
+	})
+	assert.NoError(t, err)
+
+	events := e.Ticker().Channel()
+
+	assert.NoError(t, e.Ticker().Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, e.Close(ctx))
+	assert.NoError(t, e.Close(ctx))
+
+	select {
+	case err := <-closed:
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	case <-time.After(5 * time.Second):
+		t.Fatal("close message not received")
+	}
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker channel not closed")
+	}
+
+	// the websocket does not reconnect after Close
+	assert.Equal(t, ErrClosed, e.Ticker().Subscribe(exchanges.NewProduct("BTC", "EUR")))
+}
+
+func TestGDAXConnectionLost(t *testing.T) {
+	subscribed := make(chan *WebSocketSubscribeRequest, 2)
+	connections := int32(0)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req := &WebSocketSubscribeRequest{}
+
+		if err := conn.ReadJSON(req); err != nil {
+			return
+		}
+
+		subscribed <- req
+
+		// the first connection is dropped after the subscription
+		if atomic.AddInt32(&connections, 1) == 1 {
+			return
+		}
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ticker","trade_id":20153558,"sequence":3262786978,"time":"2017-09-02T17:05:49.250000Z","product_id":"BTC-EUR","price":"4388.01000000","side":"sell","last_size":"0.03000000","best_bid":"4388","best_ask":"4388.01"}`))
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	e, err := NewGDAX(&Options{
+		WebSocketAPI: "ws" + strings.TrimPrefix(srv.URL, "http"),
+	})
+	assert.NoError(t, err)
+
+	e.ws.delay = 10 * time.Millisecond
+
+	events := e.Ticker().Channel()
+
+	assert.NoError(t, e.Ticker().Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	<-subscribed
+
+	// the products are subscribed again on the new connection
+	select {
+	case req := <-subscribed:
+		assert.Equal(t, WebSocketEventTypeSubscribe, req.Type)
+		assert.Equal(t, 1, len(req.Channels))
+		assert.Equal(t, WebSocketChannelTypeTicker, req.Channels[0].Name)
+		assert.Equal(t, "BTC", req.Channels[0].Products[0].From)
+		assert.Equal(t, "EUR", req.Channels[0].Products[0].To)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not reconnected")
+	}
+
+	// the channel of the listeners survives the reconnection
+	select {
+	case event, ok := <-events:
+		assert.True(t, ok)
+		assert.Equal(t, "BTC-EUR", event.Product.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received after reconnect")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, e.Close(ctx))
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker channel not closed")
+	}
+
+	assert.Equal(t, ErrClosed, e.Ticker().Subscribe(exchanges.NewProduct("BTC", "EUR")))
+}
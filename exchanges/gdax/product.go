@@ -0,0 +1,60 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package gdax
+
+import (
+	"github.com/euskadi31/cryptotrader/exchanges"
+	gdaxclient "github.com/preichenberger/go-gdax"
+	"github.com/shopspring/decimal"
+)
+
+// Product struct of the GDAX /products endpoint
+type Product struct {
+	ID             string          `json:"id"`
+	BaseCurrency   string          `json:"base_currency"`
+	QuoteCurrency  string          `json:"quote_currency"`
+	BaseMinSize    decimal.Decimal `json:"base_min_size"`
+	BaseMaxSize    decimal.Decimal `json:"base_max_size"`
+	BaseIncrement  decimal.Decimal `json:"base_increment"`
+	QuoteIncrement decimal.Decimal `json:"quote_increment"`
+	Status         string          `json:"status"`
+}
+
+// ProductInfo converts the GDAX product, the products without status are online
+func (p Product) ProductInfo() *exchanges.ProductInfo {
+	status := exchanges.ProductStatus(p.Status)
+
+	if status == "" {
+		status = exchanges.ProductStatusOnline
+	}
+
+	return &exchanges.ProductInfo{
+		Product:        exchanges.NewProduct(p.BaseCurrency, p.QuoteCurrency),
+		BaseIncrement:  p.BaseIncrement,
+		QuoteIncrement: p.QuoteIncrement,
+		MinSize:        p.BaseMinSize,
+		MaxSize:        p.BaseMaxSize,
+		Status:         status,
+	}
+}
+
+// loadProducts from the REST api
+func loadProducts(client *gdaxclient.Client) func() ([]*exchanges.ProductInfo, error) {
+	return func() ([]*exchanges.ProductInfo, error) {
+		var products []Product
+
+		if _, err := client.Request("GET", "/products", nil, &products); err != nil {
+			return nil, err
+		}
+
+		infos := []*exchanges.ProductInfo{}
+
+		for _, product := range products {
+			infos = append(infos, product.ProductInfo())
+		}
+
+		return infos, nil
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package gdax
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductInfo(t *testing.T) {
+	products := []Product{}
+
+	err := json.Unmarshal([]byte(`[
+		{
+			"id": "BTC-EUR",
+			"base_currency": "BTC",
+			"quote_currency": "EUR",
+			"base_min_size": "0.001",
+			"base_max_size": "50",
+			"base_increment": "0.00000001",
+			"quote_increment": "0.01",
+			"status": "online"
+		},
+		{
+			"id": "LTC-EUR",
+			"base_currency": "LTC",
+			"quote_currency": "EUR",
+			"base_min_size": "0.01",
+			"base_max_size": "1000000",
+			"quote_increment": "0.01"
+		}
+	]`), &products)
+	assert.NoError(t, err)
+
+	info := products[0].ProductInfo()
+
+	assert.Equal(t, "BTC-EUR", info.Product.String())
+	assert.Equal(t, "0.001", info.MinSize.String())
+	assert.Equal(t, "50", info.MaxSize.String())
+	assert.Equal(t, "0.00000001", info.BaseIncrement.String())
+	assert.Equal(t, "0.01", info.QuoteIncrement.String())
+	assert.True(t, info.IsTrading())
+
+	info = products[1].ProductInfo()
+
+	assert.Equal(t, exchanges.ProductStatusOnline, info.Status)
+	assert.True(t, info.BaseIncrement.IsZero())
+}
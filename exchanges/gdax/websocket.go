@@ -5,11 +5,13 @@
 package gdax
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -124,36 +126,92 @@ type WebSocketTickerResponse struct {
 	Time     Time              `json:"time"`
 }
 
+// WebSocket api of GDAX
+const (
+	DefaultWebSocketAPI = "wss://ws-feed.gdax.com"
+	SandboxWebSocketAPI = "wss://ws-feed-public.sandbox.gdax.com"
+)
+
+// ErrClosed is returned when the websocket is used after Close
+var ErrClosed = errors.New("gdax websocket is closed")
+
+const (
+	// reconnectDelay before the first reconnection of a lost connection, it doubles after each failure
+	reconnectDelay = time.Second
+	// reconnectMaxDelay between two reconnections
+	reconnectMaxDelay = time.Minute
+)
+
 // WebSocketClient struct
 type WebSocketClient struct {
-	api         string
-	ws          *websocket.Conn
-	isConnected bool
-	Ticker      chan *WebSocketTickerResponse
+	api          string
+	mu           sync.Mutex
+	ws           *websocket.Conn
+	isConnected  bool
+	isClosed     bool
+	reconnecting bool
+	done         chan struct{}
+	// delay before the first reconnection
+	delay time.Duration
+	// channels subscribed, they are subscribed again after a reconnection
+	channels map[WebSocketChannelType]map[string]*WebSocketProduct
+	// Ticker channel is closed by Close
+	Ticker chan *WebSocketTickerResponse
 }
 
-// NewWebSocketClient constructor
-func NewWebSocketClient() *WebSocketClient {
-	ws := &WebSocketClient{
-		api:    "wss://ws-feed.gdax.com",
-		Ticker: make(chan *WebSocketTickerResponse, 100),
+// NewWebSocketClient constructor, DefaultWebSocketAPI is used when api is empty
+func NewWebSocketClient(api string) *WebSocketClient {
+	if api == "" {
+		api = DefaultWebSocketAPI
 	}
 
-	go ws.receiver()
-
-	return ws
+	return &WebSocketClient{
+		api:      api,
+		done:     make(chan struct{}),
+		delay:    reconnectDelay,
+		channels: make(map[WebSocketChannelType]map[string]*WebSocketProduct),
+		Ticker:   make(chan *WebSocketTickerResponse, 100),
+	}
 }
 
 // Connect to websocket server
 func (c *WebSocketClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.connect()
+}
+
+// dial the websocket server
+func (c *WebSocketClient) dial() (*websocket.Conn, error) {
 	u, err := url.Parse(c.api)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	log.Info().Msgf("GDAX WebSocket: connecting to %s", u.String())
 
 	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("GDAX WebSocket: connected to %s", u.String())
+
+	return ws, nil
+}
+
+// connect opens the connection when it is closed, the lock is held by the caller
+func (c *WebSocketClient) connect() error {
+	if c.isClosed {
+		return ErrClosed
+	}
+
+	if c.isConnected || c.reconnecting {
+		return nil
+	}
+
+	ws, err := c.dial()
 	if err != nil {
 		log.Error().Err(err).Msg("")
 		return err
@@ -163,56 +221,223 @@ func (c *WebSocketClient) Connect() error {
 
 	c.isConnected = true
 
-	log.Info().Msgf("GDAX WebSocket: connected to %s", u.String())
+	go c.receiver(ws)
 
 	return nil
 }
 
-// Subscribe to channel
-func (c *WebSocketClient) Subscribe(channels ...*WebSocketChannel) error {
-	e := &WebSocketSubscribeRequest{
+// Close the connection, a close message is sent until the deadline of ctx
+func (c *WebSocketClient) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isClosed {
+		return nil
+	}
+
+	c.isClosed = true
+
+	close(c.done)
+
+	// the receiver closes the ticker channel when it stops
+	if c.isConnected == false {
+		if c.reconnecting == false {
+			close(c.Ticker)
+		}
+
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if ok == false {
+		deadline = time.Now().Add(time.Second)
+	}
+
+	if err := c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline); err != nil {
+		log.Error().Err(err).Msg("GDAX WebSocket: close message")
+	}
+
+	return c.ws.Close()
+}
+
+// track the channels of request, the lock is held by the caller
+func (c *WebSocketClient) track(request *WebSocketSubscribeRequest) {
+	for _, channel := range request.Channels {
+		products, ok := c.channels[channel.Name]
+		if ok == false {
+			products = make(map[string]*WebSocketProduct)
+
+			c.channels[channel.Name] = products
+		}
+
+		for _, product := range channel.Products {
+			if request.Type == WebSocketEventTypeSubscribe {
+				products[product.String()] = product
+			} else {
+				delete(products, product.String())
+			}
+		}
+	}
+}
+
+// subscriptions request of the tracked channels, the lock is held by the caller
+func (c *WebSocketClient) subscriptions() *WebSocketSubscribeRequest {
+	request := &WebSocketSubscribeRequest{
 		WebSocketEvent: &WebSocketEvent{
 			Type: WebSocketEventTypeSubscribe,
 		},
-		Channels: channels,
+		Channels: []*WebSocketChannel{},
 	}
 
-	b, err := json.Marshal(e)
+	for name, products := range c.channels {
+		if len(products) == 0 {
+			continue
+		}
+
+		channel := &WebSocketChannel{
+			Name: name,
+		}
+
+		for _, product := range products {
+			channel.Products = append(channel.Products, product)
+		}
+
+		request.Channels = append(request.Channels, channel)
+	}
+
+	return request
+}
+
+// write the request on the connection, it is opened when connect is true.
+// The request is sent by the reconnection when the connection is lost.
+func (c *WebSocketClient) write(request *WebSocketSubscribeRequest, connect bool) error {
+	b, err := json.Marshal(request)
 	if err != nil {
 		return err
 	}
 
-	return c.ws.WriteMessage(websocket.TextMessage, b)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reconnecting && c.isClosed == false {
+		c.track(request)
+
+		return nil
+	}
+
+	if c.isConnected == false && connect == false {
+		return nil
+	}
+
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	if err := c.ws.WriteMessage(websocket.TextMessage, b); err != nil {
+		return err
+	}
+
+	c.track(request)
+
+	return nil
+}
+
+// Subscribe to channel, the connection is opened by the first subscription
+func (c *WebSocketClient) Subscribe(channels ...*WebSocketChannel) error {
+	return c.write(&WebSocketSubscribeRequest{
+		WebSocketEvent: &WebSocketEvent{
+			Type: WebSocketEventTypeSubscribe,
+		},
+		Channels: channels,
+	}, true)
 }
 
 // Unsubscribe to channel
 func (c *WebSocketClient) Unsubscribe(channels ...*WebSocketChannel) error {
-	e := &WebSocketSubscribeRequest{
+	return c.write(&WebSocketSubscribeRequest{
 		WebSocketEvent: &WebSocketEvent{
 			Type: WebSocketEventTypeUnsubscribe,
 		},
 		Channels: channels,
-	}
+	}, false)
+}
 
-	b, err := json.Marshal(e)
-	if err != nil {
-		return err
+// reconnect the lost connection with an increasing delay then subscribe again to the channels,
+// it returns nil when the client is closed
+func (c *WebSocketClient) reconnect(cause error) *websocket.Conn {
+	c.mu.Lock()
+
+	c.isConnected = false
+
+	if c.isClosed {
+		c.mu.Unlock()
+
+		return nil
 	}
 
-	return c.ws.WriteMessage(websocket.TextMessage, b)
-}
+	c.reconnecting = true
+
+	c.mu.Unlock()
+
+	log.Error().Err(cause).Msg("GDAX WebSocket: connection lost")
+
+	delay := c.delay
 
-func (c *WebSocketClient) receiver() {
 	for {
-		if !c.isConnected {
-			time.Sleep(time.Millisecond * 100)
+		select {
+		case <-time.After(delay):
+		case <-c.done:
+			return nil
+		}
+
+		ws, err := c.dial()
+		if err != nil {
+			log.Error().Err(err).Msgf("GDAX WebSocket: reconnect, next attempt in %s", delay)
+
+			if delay *= 2; delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
 
 			continue
 		}
 
-		_, message, err := c.ws.ReadMessage()
+		c.mu.Lock()
+
+		if c.isClosed {
+			c.mu.Unlock()
+
+			ws.Close()
+
+			return nil
+		}
+
+		c.ws = ws
+		c.isConnected = true
+		c.reconnecting = false
+
+		// a failed subscription loses the connection which is reconnected again
+		if request := c.subscriptions(); len(request.Channels) > 0 {
+			if err := ws.WriteJSON(request); err != nil {
+				log.Error().Err(err).Msg("GDAX WebSocket: subscribe after reconnect")
+			}
+		}
+
+		c.mu.Unlock()
+
+		return ws
+	}
+}
+
+// receiver reads the messages until the client is closed, the lost connections are reconnected
+func (c *WebSocketClient) receiver(ws *websocket.Conn) {
+	defer close(c.Ticker)
+
+	for {
+		_, message, err := ws.ReadMessage()
 		if err != nil {
-			log.Error().Err(err).Msg("")
+			if ws = c.reconnect(err); ws == nil {
+				return
+			}
 
 			continue
 		}
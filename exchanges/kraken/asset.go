@@ -0,0 +1,84 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package kraken
+
+import (
+	"strings"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+)
+
+// assets renamed by Kraken, including the legacy codes prefixed by X or Z
+var assets = map[string]string{
+	"XBT":  "BTC",
+	"XXBT": "BTC",
+	"XDG":  "DOGE",
+	"XXDG": "DOGE",
+	"XETH": "ETH",
+	"XETC": "ETC",
+	"XLTC": "LTC",
+	"XXRP": "XRP",
+	"XXLM": "XLM",
+	"XXMR": "XMR",
+	"XZEC": "ZEC",
+	"XREP": "REP",
+	"XMLN": "MLN",
+	"XICN": "ICN",
+	"ZEUR": "EUR",
+	"ZUSD": "USD",
+	"ZGBP": "GBP",
+	"ZCAD": "CAD",
+	"ZJPY": "JPY",
+	"ZKRW": "KRW",
+}
+
+// codes of the assets in the Kraken pairs
+var codes = map[string]string{
+	"BTC":  "XBT",
+	"DOGE": "XDG",
+}
+
+// NormalizeAsset converts the Kraken asset code to the common code
+func NormalizeAsset(code string) string {
+	code = strings.ToUpper(code)
+
+	if asset, ok := assets[code]; ok {
+		return asset
+	}
+
+	return code
+}
+
+// AssetCode converts the common asset code to the Kraken code
+func AssetCode(asset string) string {
+	asset = strings.ToUpper(asset)
+
+	if code, ok := codes[asset]; ok {
+		return code
+	}
+
+	return asset
+}
+
+// NewProductFromPair converts the websocket pair name formatted as XBT/EUR
+func NewProductFromPair(pair string) exchanges.Product {
+	part := strings.SplitN(pair, "/", 2)
+
+	if len(part) != 2 {
+		return exchanges.NewProduct(NormalizeAsset(pair), "")
+	}
+
+	return exchanges.NewProduct(NormalizeAsset(part[0]), NormalizeAsset(part[1]))
+}
+
+// PairName of product used by the websocket api
+func PairName(product exchanges.Product) string {
+	return AssetCode(product.From) + "/" + AssetCode(product.To)
+}
+
+// PairCode of product used by the rest api
+func PairCode(product exchanges.Product) string {
+	return AssetCode(product.From) + AssetCode(product.To)
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package kraken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPI of Kraken
+const DefaultAPI = "https://api.kraken.com"
+
+// Errors
+var (
+	ErrMissingCredentials = errors.New("kraken api key and secret are required")
+)
+
+// APIError returned by Kraken
+type APIError struct {
+	Messages []string
+}
+
+func (e APIError) Error() string {
+	return "kraken: " + strings.Join(e.Messages, ", ")
+}
+
+type response struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// Client of the Kraken rest api
+type Client struct {
+	BaseURL    string
+	Key        string
+	Secret     string
+	HTTPClient *http.Client
+	mu         sync.Mutex
+	nonce      int64
+}
+
+// NewClient constructor, the private methods require key and secret
+func NewClient(key string, secret string) *Client {
+	return &Client{
+		BaseURL: DefaultAPI,
+		Key:     key,
+		Secret:  secret,
+		HTTPClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// nextNonce returns an increasing nonce based on the current time
+func (c *Client) nextNonce() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nonce := time.Now().UnixNano()
+
+	if nonce <= c.nonce {
+		nonce = c.nonce + 1
+	}
+
+	c.nonce = nonce
+
+	return nonce
+}
+
+// sign the request of path, see https://www.kraken.com/help/api#general-usage
+func sign(secret []byte, path string, nonce string, body string) string {
+	sha := sha256.New()
+	sha.Write([]byte(nonce + body))
+
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(append([]byte(path), sha.Sum(nil)...))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Client) do(req *http.Request, result interface{}) error {
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("kraken: %s", res.Status)
+	}
+
+	r := &response{}
+
+	if err := json.NewDecoder(res.Body).Decode(r); err != nil {
+		return err
+	}
+
+	if len(r.Error) > 0 {
+		return &APIError{
+			Messages: r.Error,
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(r.Result, result)
+}
+
+// Public request on the method of the public api
+func (c *Client) Public(method string, params url.Values, result interface{}) error {
+	u := c.BaseURL + "/0/public/" + method
+
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.do(req, result)
+}
+
+// Private request on the method of the authenticated api
+func (c *Client) Private(method string, params url.Values, result interface{}) error {
+	if c.Key == "" || c.Secret == "" {
+		return ErrMissingCredentials
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(c.Secret)
+	if err != nil {
+		return fmt.Errorf("kraken secret: %v", err)
+	}
+
+	if params == nil {
+		params = url.Values{}
+	}
+
+	nonce := strconv.FormatInt(c.nextNonce(), 10)
+
+	params.Set("nonce", nonce)
+
+	path := "/0/private/" + method
+	body := params.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL+path, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("API-Key", c.Key)
+	req.Header.Set("API-Sign", sign(secret, path, nonce, body))
+
+	return c.do(req, result)
+}
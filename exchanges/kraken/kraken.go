@@ -0,0 +1,393 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package kraken provides the Kraken exchange, the Kraken asset codes are normalized (XBT is BTC).
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+// Errors
+var (
+	ErrOrderBookNotFound = errors.New("kraken order book not found")
+	ErrNoSandbox         = errors.New("kraken has no sandbox")
+)
+
+// Options of Kraken
+type Options struct {
+	// Name of the instance, kraken when empty
+	Name   string
+	Key    string
+	Secret string
+	// API url, DefaultAPI when empty
+	API string
+	// WebSocketAPI url, DefaultWebSocketAPI when empty
+	WebSocketAPI string
+}
+
+// Kraken struct
+type Kraken struct {
+	name     string
+	client   *Client
+	ws       *WebSocketClient
+	ticker   *Ticker
+	products *exchanges.ProductCatalog
+}
+
+// NewKraken Exchange, the websocket is connected by the first subscription
+func NewKraken(options *Options) (*Kraken, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	if options.Name == "" {
+		options.Name = "kraken"
+	}
+
+	client := NewClient(options.Key, options.Secret)
+
+	if options.API != "" {
+		client.BaseURL = strings.TrimRight(options.API, "/")
+	}
+
+	e := &Kraken{
+		name:   options.Name,
+		client: client,
+		ws:     NewWebSocketClient(options.WebSocketAPI),
+	}
+
+	e.ticker = newTicker(e.ws)
+
+	e.products = exchanges.NewProductCatalog(e.loadProducts, exchanges.DefaultCatalogTTL)
+
+	return e, nil
+}
+
+// NewProvider factory of the kraken instances
+func NewProvider(config *exchanges.ProviderConfig, providers exchanges.Manager) (exchanges.ExchangeProvider, error) {
+	if config.Sandbox {
+		return nil, ErrNoSandbox
+	}
+
+	return NewKraken(&Options{
+		Name:         config.Name,
+		Key:          config.Key,
+		Secret:       config.Secret,
+		API:          config.API,
+		WebSocketAPI: config.WebSocketAPI,
+	})
+}
+
+// DefaultFeeSchedule of Kraken for a 30 days volume below 50000 USD
+func DefaultFeeSchedule() *exchanges.FeeSchedule {
+	return exchanges.NewFeeSchedule(exchanges.FeeTier{
+		Volume: decimal.Zero,
+		Maker:  decimal.New(16, -4),
+		Taker:  decimal.New(26, -4),
+	})
+}
+
+// Name of provider
+func (e Kraken) Name() string {
+	return e.name
+}
+
+// Ticker of the trades shared by the consumers of the instance
+func (e *Kraken) Ticker() exchanges.TickerProvider {
+	return e.ticker
+}
+
+// Balance provider
+func (e *Kraken) Balance() exchanges.BalanceProvider {
+	return &Balance{
+		client: e.client,
+	}
+}
+
+// Close the websocket and the listeners of the ticker
+func (e *Kraken) Close(ctx context.Context) error {
+	e.ticker.broadcaster.Close()
+
+	if err := e.ws.Close(ctx); err != nil && err != ErrNotConnected {
+		return err
+	}
+
+	return nil
+}
+
+// Products catalog loaded from the asset pairs
+func (e *Kraken) Products() *exchanges.ProductCatalog {
+	return e.products
+}
+
+// assetPair struct of the AssetPairs method
+type assetPair struct {
+	Altname      string          `json:"altname"`
+	WSName       string          `json:"wsname"`
+	Base         string          `json:"base"`
+	Quote        string          `json:"quote"`
+	PairDecimals int32           `json:"pair_decimals"`
+	LotDecimals  int32           `json:"lot_decimals"`
+	OrderMin     decimal.Decimal `json:"ordermin"`
+	Status       string          `json:"status"`
+}
+
+func (e *Kraken) loadProducts() ([]*exchanges.ProductInfo, error) {
+	pairs := map[string]*assetPair{}
+
+	if err := e.client.Public("AssetPairs", nil, &pairs); err != nil {
+		return nil, err
+	}
+
+	products := []*exchanges.ProductInfo{}
+
+	for name, pair := range pairs {
+		// dark pool pairs
+		if strings.HasSuffix(name, ".d") {
+			continue
+		}
+
+		product := exchanges.NewProduct(NormalizeAsset(pair.Base), NormalizeAsset(pair.Quote))
+
+		if pair.WSName != "" {
+			product = NewProductFromPair(pair.WSName)
+		}
+
+		status := exchanges.ProductStatus(pair.Status)
+
+		if status == "" {
+			status = exchanges.ProductStatusOnline
+		}
+
+		products = append(products, &exchanges.ProductInfo{
+			Product:        product,
+			BaseIncrement:  decimal.New(1, -pair.LotDecimals),
+			QuoteIncrement: decimal.New(1, -pair.PairDecimals),
+			MinSize:        pair.OrderMin,
+			Status:         status,
+		})
+	}
+
+	return products, nil
+}
+
+// parseBookEntries formatted as [[price, volume, timestamp]]
+func parseBookEntries(rows [][]json.RawMessage) ([]*exchanges.BookEntry, error) {
+	entries := []*exchanges.BookEntry{}
+
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+
+		entry := &exchanges.BookEntry{}
+
+		if err := json.Unmarshal(row[0], &entry.Price); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(row[1], &entry.Size); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// OrderBook of product limited to depth levels on each side
+func (e *Kraken) OrderBook(product exchanges.Product, depth int) (*exchanges.OrderBook, error) {
+	params := url.Values{}
+	params.Set("pair", PairCode(product))
+
+	if depth > 0 {
+		params.Set("count", strconv.Itoa(depth))
+	}
+
+	books := map[string]*struct {
+		Asks [][]json.RawMessage `json:"asks"`
+		Bids [][]json.RawMessage `json:"bids"`
+	}{}
+
+	if err := e.client.Public("Depth", params, &books); err != nil {
+		return nil, err
+	}
+
+	// the result is keyed by the pair name which can differ from the requested code
+	for _, book := range books {
+		asks, err := parseBookEntries(book.Asks)
+		if err != nil {
+			return nil, err
+		}
+
+		bids, err := parseBookEntries(book.Bids)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(asks, func(i, j int) bool {
+			return asks[i].Price.LessThan(asks[j].Price)
+		})
+
+		sort.Slice(bids, func(i, j int) bool {
+			return bids[i].Price.GreaterThan(bids[j].Price)
+		})
+
+		return &exchanges.OrderBook{
+			Product: product,
+			Asks:    asks,
+			Bids:    bids,
+			Time:    time.Now().UTC(),
+		}, nil
+	}
+
+	return nil, ErrOrderBookNotFound
+}
+
+// PlaceOrder on the account
+func (e *Kraken) PlaceOrder(order *exchanges.OrderRequest) (*exchanges.PlacedOrder, error) {
+	orderType := order.Type
+
+	if orderType == "" {
+		orderType = exchanges.OrderTypeMarket
+	}
+
+	params := url.Values{}
+	params.Set("pair", PairCode(order.Product))
+	params.Set("type", string(order.Side))
+	params.Set("ordertype", string(orderType))
+	params.Set("volume", order.Size.String())
+
+	if orderType == exchanges.OrderTypeLimit {
+		params.Set("price", order.Price.String())
+	}
+
+	result := &struct {
+		Descr struct {
+			Order string `json:"order"`
+		} `json:"descr"`
+		TxID []string `json:"txid"`
+	}{}
+
+	if err := e.client.Private("AddOrder", params, result); err != nil {
+		return nil, err
+	}
+
+	placed := &exchanges.PlacedOrder{
+		Description: result.Descr.Order,
+	}
+
+	if len(result.TxID) > 0 {
+		placed.ID = result.TxID[0]
+	}
+
+	return placed, nil
+}
+
+// CancelOrder by transaction id
+func (e *Kraken) CancelOrder(id string) error {
+	params := url.Values{}
+	params.Set("txid", id)
+
+	return e.client.Private("CancelOrder", params, nil)
+}
+
+// Balance struct
+type Balance struct {
+	client *Client
+}
+
+// Balances of the account, Kraken does not report the amounts on hold
+func (b Balance) Balances() ([]*exchanges.Balance, error) {
+	amounts := map[string]decimal.Decimal{}
+
+	if err := b.client.Private("Balance", nil, &amounts); err != nil {
+		return nil, err
+	}
+
+	balances := []*exchanges.Balance{}
+
+	for code, amount := range amounts {
+		balances = append(balances, &exchanges.Balance{
+			Currency:  NormalizeAsset(code),
+			Total:     amount,
+			Available: amount,
+		})
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Currency < balances[j].Currency
+	})
+
+	return balances, nil
+}
+
+// Ticker struct
+type Ticker struct {
+	ws          *WebSocketClient
+	broadcaster *exchanges.Broadcaster
+	once        sync.Once
+}
+
+func newTicker(ws *WebSocketClient) *Ticker {
+	return &Ticker{
+		ws:          ws,
+		broadcaster: exchanges.NewBroadcaster(),
+	}
+}
+
+// Subscribe to product
+func (t *Ticker) Subscribe(products ...exchanges.Product) error {
+	return t.ws.Subscribe(products...)
+}
+
+// Unsubscribe to product
+func (t *Ticker) Unsubscribe(products ...exchanges.Product) error {
+	return t.ws.Unsubscribe(products...)
+}
+
+// Channel TickerEvent, it is closed by Close, the listener cannot be closed before the ticker
+// so the events it cannot buffer are dropped instead of blocking the other listeners
+func (t *Ticker) Channel() <-chan *exchanges.TickerEvent {
+	return t.Listen(&exchanges.ListenOptions{
+		Policy: exchanges.DeliveryPolicyDrop,
+	}).C
+}
+
+// Listen to the trades, the listeners are closed by Close
+func (t *Ticker) Listen(options *exchanges.ListenOptions) *exchanges.Listener {
+	t.once.Do(func() {
+		go t.broadcast()
+	})
+
+	return t.broadcaster.Listen(options)
+}
+
+// broadcast the trades of the websocket to the listeners
+func (t *Ticker) broadcast() {
+	defer t.broadcaster.Close()
+
+	for trade := range t.ws.Trades {
+		t.broadcaster.Broadcast(&exchanges.TickerEvent{
+			Product: trade.Product,
+			Price:   trade.Price,
+			Time:    trade.Time,
+			Side:    trade.Side,
+			Size:    trade.Volume,
+		})
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package kraken
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSecret = base64.StdEncoding.EncodeToString([]byte("secret"))
+
+// newStandIn starts a local server replaying the Kraken rest and websocket api
+func newStandIn(t *testing.T, handlers map[string]http.HandlerFunc) (*httptest.Server, *Kraken) {
+	mux := http.NewServeMux()
+
+	for path, handler := range handlers {
+		mux.HandleFunc(path, handler)
+	}
+
+	if _, ok := handlers["/ws"]; ok == false {
+		mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+			upgrader := websocket.Upgrader{}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+
+	k, err := NewKraken(&Options{
+		Key:          "key",
+		Secret:       testSecret,
+		API:          srv.URL,
+		WebSocketAPI: "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws",
+	})
+	assert.NoError(t, err)
+
+	return srv, k
+}
+
+func reply(w http.ResponseWriter, result string) {
+	fmt.Fprintf(w, `{"error":[],"result":%s}`, result)
+}
+
+func TestAsset(t *testing.T) {
+	assert.Equal(t, "BTC", NormalizeAsset("XBT"))
+	assert.Equal(t, "BTC", NormalizeAsset("XXBT"))
+	assert.Equal(t, "EUR", NormalizeAsset("ZEUR"))
+	assert.Equal(t, "DASH", NormalizeAsset("DASH"))
+
+	product := NewProductFromPair("XBT/EUR")
+
+	assert.Equal(t, "BTC-EUR", product.String())
+	assert.Equal(t, "XBT/EUR", PairName(product))
+	assert.Equal(t, "XBTEUR", PairCode(product))
+	assert.Equal(t, "ETH-BTC", NewProductFromPair("ETH/XBT").String())
+}
+
+func TestKrakenTicker(t *testing.T) {
+	subscribed := make(chan *WebSocketRequest, 2)
+
+	srv, k := newStandIn(t, map[string]http.HandlerFunc{
+		"/ws": func(w http.ResponseWriter, r *http.Request) {
+			upgrader := websocket.Upgrader{}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			req := &WebSocketRequest{}
+
+			if err := conn.ReadJSON(req); err != nil {
+				return
+			}
+
+			// the reconnections after the test do not block the server
+			select {
+			case subscribed <- req:
+			default:
+			}
+
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"event":"heartbeat"}`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""]],"trade","XBT/EUR"]`))
+
+			// the connection is dropped after the trade
+		},
+	})
+	defer srv.Close()
+
+	k.ws.delay = 10 * time.Millisecond
+
+	ticker := k.Ticker()
+	events := ticker.Channel()
+
+	assert.NoError(t, ticker.Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	req := <-subscribed
+
+	assert.Equal(t, WebSocketEventSubscribe, req.Event)
+	assert.Equal(t, []string{"XBT/EUR"}, req.Pair)
+	assert.Equal(t, WebSocketChannelTrade, req.Subscription.Name)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "BTC-EUR", event.Product.String())
+		assert.Equal(t, "5541.2", event.Price.String())
+		assert.Equal(t, "0.15850568", event.Size.String())
+		assert.Equal(t, exchanges.SideTypeSell, event.Side)
+		assert.Equal(t, int64(1534614057), event.Time.Unix())
+		assert.Equal(t, 321597000, event.Time.Nanosecond())
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received")
+	}
+
+	// the products are subscribed again when the dropped connection is reconnected
+	select {
+	case req := <-subscribed:
+		assert.Equal(t, WebSocketEventSubscribe, req.Event)
+		assert.Equal(t, []string{"XBT/EUR"}, req.Pair)
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not reconnected")
+	}
+
+	select {
+	case event, ok := <-events:
+		assert.True(t, ok)
+		assert.Equal(t, "BTC-EUR", event.Product.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker event not received after reconnect")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, k.Close(ctx))
+
+	assert.Equal(t, ErrClosed, ticker.Subscribe(exchanges.NewProduct("BTC", "EUR")))
+}
+
+func TestKrakenProducts(t *testing.T) {
+	srv, k := newStandIn(t, map[string]http.HandlerFunc{
+		"/0/public/AssetPairs": func(w http.ResponseWriter, r *http.Request) {
+			reply(w, `{
+				"XXBTZEUR": {"altname":"XBTEUR","wsname":"XBT/EUR","base":"XXBT","quote":"ZEUR","pair_decimals":1,"lot_decimals":8,"ordermin":"0.0001","status":"online"},
+				"XXBTZEUR.d": {"altname":"XBTEUR.d","base":"XXBT","quote":"ZEUR","pair_decimals":1,"lot_decimals":8},
+				"XETHZEUR": {"altname":"ETHEUR","base":"XETH","quote":"ZEUR","pair_decimals":2,"lot_decimals":8,"ordermin":"0.01","status":"cancel_only"}
+			}`)
+		},
+	})
+	defer srv.Close()
+
+	products, err := k.Products().All()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(products))
+
+	product, err := k.Products().Get("BTC-EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.00000001", product.BaseIncrement.String())
+	assert.Equal(t, "0.1", product.QuoteIncrement.String())
+	assert.Equal(t, "0.0001", product.MinSize.String())
+	assert.True(t, product.IsTrading())
+
+	product, err = k.Products().Get("ETH-EUR")
+	assert.NoError(t, err)
+	assert.False(t, product.IsTrading())
+}
+
+func TestKrakenOrderBook(t *testing.T) {
+	srv, k := newStandIn(t, map[string]http.HandlerFunc{
+		"/0/public/Depth": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "XBTEUR", r.URL.Query().Get("pair"))
+			assert.Equal(t, "2", r.URL.Query().Get("count"))
+
+			reply(w, `{"XXBTZEUR":{
+				"asks":[["5542.00000","1.000",1534614057],["5541.50000","0.500",1534614057]],
+				"bids":[["5540.00000","2.000",1534614057],["5541.00000","0.100",1534614057]]
+			}}`)
+		},
+	})
+	defer srv.Close()
+
+	book, err := k.OrderBook(exchanges.NewProduct("BTC", "EUR"), 2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "5541.5", book.Asks[0].Price.String())
+	assert.Equal(t, "0.5", book.Asks[0].Size.String())
+	assert.Equal(t, "5541", book.Bids[0].Price.String())
+	assert.Equal(t, "5540", book.Bids[1].Price.String())
+}
+
+func TestKrakenPlaceOrder(t *testing.T) {
+	srv, k := newStandIn(t, map[string]http.HandlerFunc{
+		"/0/private/AddOrder": func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+
+			params, err := url.ParseQuery(string(body))
+			assert.NoError(t, err)
+
+			secret, _ := base64.StdEncoding.DecodeString(testSecret)
+
+			assert.Equal(t, "key", r.Header.Get("API-Key"))
+			assert.Equal(t, sign(secret, "/0/private/AddOrder", params.Get("nonce"), string(body)), r.Header.Get("API-Sign"))
+			assert.Equal(t, "XBTEUR", params.Get("pair"))
+			assert.Equal(t, "buy", params.Get("type"))
+			assert.Equal(t, "limit", params.Get("ordertype"))
+			assert.Equal(t, "0.1234", params.Get("volume"))
+			assert.Equal(t, "5000.5", params.Get("price"))
+
+			reply(w, `{"descr":{"order":"buy 0.1234 XBTEUR @ limit 5000.5"},"txid":["OUF4EM-FRGI2-MQMWZD"]}`)
+		},
+		"/0/private/CancelOrder": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"error":["EOrder:Unknown order"]}`)
+		},
+	})
+	defer srv.Close()
+
+	placed, err := k.PlaceOrder(&exchanges.OrderRequest{
+		Product: exchanges.NewProduct("BTC", "EUR"),
+		Side:    exchanges.SideTypeBuy,
+		Type:    exchanges.OrderTypeLimit,
+		Size:    decimal.RequireFromString("0.1234"),
+		Price:   decimal.RequireFromString("5000.5"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "OUF4EM-FRGI2-MQMWZD", placed.ID)
+
+	assert.EqualError(t, k.CancelOrder("foo"), "kraken: EOrder:Unknown order")
+}
+
+func TestKrakenBalances(t *testing.T) {
+	srv, k := newStandIn(t, map[string]http.HandlerFunc{
+		"/0/private/Balance": func(w http.ResponseWriter, r *http.Request) {
+			reply(w, `{"ZEUR":"1000.5000","XXBT":"0.5000000000"}`)
+		},
+	})
+	defer srv.Close()
+
+	balances, err := k.Balance().Balances()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(balances))
+	assert.Equal(t, "BTC", balances[0].Currency)
+	assert.Equal(t, "0.5", balances[0].Total.String())
+	assert.Equal(t, "EUR", balances[1].Currency)
+	assert.Equal(t, "1000.5", balances[1].Available.String())
+
+	k.client.Key = ""
+
+	_, err = k.Balance().Balances()
+	assert.Equal(t, ErrMissingCredentials, err)
+}
+
+func TestParseTrades(t *testing.T) {
+	trades, err := parseTrades([]byte(`[0,{"a":["5525.40000",1,"1.000"]},"ticker","XBT/USD"]`))
+	assert.NoError(t, err)
+	assert.Nil(t, trades)
+
+	_, err = parseTrades([]byte(`[0,"trade"]`))
+	assert.Error(t, err)
+
+	b, _ := json.Marshal([]interface{}{0, [][]string{{"1", "2", "3.5", "b", "m", ""}}, "trade", "XDG/XBT"})
+
+	trades, err = parseTrades(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "DOGE-BTC", trades[0].Product.String())
+	assert.Equal(t, exchanges.SideTypeBuy, trades[0].Side)
+	assert.Equal(t, 500000000, trades[0].Time.Nanosecond())
+}
+
+func TestKrakenConnectsOnSubscribe(t *testing.T) {
+	k, err := NewKraken(&Options{
+		Name:         "kraken_eu",
+		WebSocketAPI: "ws://127.0.0.1:1/ws",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "kraken_eu", k.Name())
+
+	assert.NoError(t, k.Ticker().Unsubscribe(exchanges.NewProduct("BTC", "EUR")))
+	assert.Error(t, k.Ticker().Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	_, err = NewProvider(&exchanges.ProviderConfig{Name: "kraken", Sandbox: true}, nil)
+	assert.Equal(t, ErrNoSandbox, err)
+}
+
+func TestKrakenClose(t *testing.T) {
+	closed := make(chan error, 1)
+
+	srv, k := newStandIn(t, map[string]http.HandlerFunc{
+		"/ws": func(w http.ResponseWriter, r *http.Request) {
+			upgrader := websocket.Upgrader{}
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					closed <- err
+
+					return
+				}
+			}
+		},
+	})
+	defer srv.Close()
+
+	// the listeners are closed when the websocket is not connected
+	idle, err := NewKraken(&Options{
+		WebSocketAPI: "ws://127.0.0.1:1/ws",
+	})
+	assert.NoError(t, err)
+
+	listener := idle.Ticker().Listen(nil)
+
+	assert.NoError(t, idle.Close(context.Background()))
+
+	_, ok := <-listener.C
+	assert.False(t, ok)
+
+	events := k.Ticker().Channel()
+
+	assert.NoError(t, k.Ticker().Subscribe(exchanges.NewProduct("BTC", "EUR")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, k.Close(ctx))
+
+	select {
+	case err := <-closed:
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	case <-time.After(5 * time.Second):
+		t.Fatal("close message not received")
+	}
+
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ticker channel not closed")
+	}
+}
@@ -0,0 +1,465 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultWebSocketAPI of Kraken
+const DefaultWebSocketAPI = "wss://ws.kraken.com"
+
+// WebSocket events
+const (
+	WebSocketEventSubscribe          = "subscribe"
+	WebSocketEventUnsubscribe        = "unsubscribe"
+	WebSocketEventHeartbeat          = "heartbeat"
+	WebSocketEventSystemStatus       = "systemStatus"
+	WebSocketEventSubscriptionStatus = "subscriptionStatus"
+)
+
+// WebSocketChannelTrade is the channel of the executed trades
+const WebSocketChannelTrade = "trade"
+
+// ErrNotConnected is returned when the websocket is used before Connect
+var ErrNotConnected = errors.New("kraken websocket is not connected")
+
+// ErrClosed is returned when the websocket is used after Close
+var ErrClosed = errors.New("kraken websocket is closed")
+
+const (
+	// reconnectDelay before the first reconnection of a lost connection, it doubles after each failure
+	reconnectDelay = time.Second
+	// reconnectMaxDelay between two reconnections
+	reconnectMaxDelay = time.Minute
+)
+
+// WebSocketSubscription struct
+type WebSocketSubscription struct {
+	Name string `json:"name"`
+}
+
+// WebSocketRequest struct
+type WebSocketRequest struct {
+	Event        string                 `json:"event"`
+	Pair         []string               `json:"pair"`
+	Subscription *WebSocketSubscription `json:"subscription"`
+}
+
+// WebSocketEvent struct of the messages which are not channel data
+type WebSocketEvent struct {
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Pair         string `json:"pair"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// Trade struct received on the trade channel
+type Trade struct {
+	Product exchanges.Product
+	Price   decimal.Decimal
+	Volume  decimal.Decimal
+	Time    time.Time
+	Side    exchanges.SideType
+}
+
+// WebSocketClient struct
+type WebSocketClient struct {
+	api          string
+	mu           sync.Mutex
+	ws           *websocket.Conn
+	closed       bool
+	reconnecting bool
+	done         chan struct{}
+	// delay before the first reconnection
+	delay time.Duration
+	// products subscribed by pair name, they are subscribed again after a reconnection
+	products map[string]exchanges.Product
+	Trades   chan *Trade
+}
+
+// NewWebSocketClient constructor
+func NewWebSocketClient(api string) *WebSocketClient {
+	if api == "" {
+		api = DefaultWebSocketAPI
+	}
+
+	return &WebSocketClient{
+		api:      api,
+		done:     make(chan struct{}),
+		delay:    reconnectDelay,
+		products: make(map[string]exchanges.Product),
+		Trades:   make(chan *Trade, 100),
+	}
+}
+
+// Connect to websocket server, the lost connections are reconnected until Close
+func (c *WebSocketClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.connect()
+}
+
+// dial the websocket server
+func (c *WebSocketClient) dial() (*websocket.Conn, error) {
+	log.Info().Msgf("Kraken WebSocket: connecting to %s", c.api)
+
+	ws, _, err := websocket.DefaultDialer.Dial(c.api, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Kraken WebSocket: connected to %s", c.api)
+
+	return ws, nil
+}
+
+// connect opens the connection once, the lock is held by the caller
+func (c *WebSocketClient) connect() error {
+	if c.closed {
+		return ErrClosed
+	}
+
+	if c.ws != nil || c.reconnecting {
+		return nil
+	}
+
+	ws, err := c.dial()
+	if err != nil {
+		return err
+	}
+
+	c.ws = ws
+
+	go c.receiver(ws)
+
+	return nil
+}
+
+// Close the connection, a close message is sent until the deadline of ctx
+func (c *WebSocketClient) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	// the receiver closes the trades channel when it stops
+	if c.reconnecting {
+		c.closed = true
+
+		close(c.done)
+
+		return nil
+	}
+
+	if c.ws == nil {
+		return ErrNotConnected
+	}
+
+	c.closed = true
+
+	close(c.done)
+
+	deadline, ok := ctx.Deadline()
+	if ok == false {
+		deadline = time.Now().Add(time.Second)
+	}
+
+	if err := c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline); err != nil {
+		log.Error().Err(err).Msg("Kraken WebSocket: close message")
+	}
+
+	return c.ws.Close()
+}
+
+// tradeRequest of event on the trades of products
+func tradeRequest(event string, products []exchanges.Product) *WebSocketRequest {
+	req := &WebSocketRequest{
+		Event: event,
+		Pair:  []string{},
+		Subscription: &WebSocketSubscription{
+			Name: WebSocketChannelTrade,
+		},
+	}
+
+	for _, product := range products {
+		req.Pair = append(req.Pair, PairName(product))
+	}
+
+	return req
+}
+
+// track the products of event, the lock is held by the caller
+func (c *WebSocketClient) track(event string, products []exchanges.Product) {
+	for _, product := range products {
+		if event == WebSocketEventSubscribe {
+			c.products[PairName(product)] = product
+		} else {
+			delete(c.products, PairName(product))
+		}
+	}
+}
+
+// send the event on the connection, it is sent by the reconnection when the connection is lost
+func (c *WebSocketClient) send(event string, products []exchanges.Product) error {
+	b, err := json.Marshal(tradeRequest(event, products))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.reconnecting && c.closed == false {
+		c.track(event, products)
+
+		return nil
+	}
+
+	// the connection is opened by the first subscription
+	if c.ws == nil && event == WebSocketEventUnsubscribe {
+		return nil
+	}
+
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	if err := c.ws.WriteMessage(websocket.TextMessage, b); err != nil {
+		return err
+	}
+
+	c.track(event, products)
+
+	return nil
+}
+
+// Subscribe to the trades of products
+func (c *WebSocketClient) Subscribe(products ...exchanges.Product) error {
+	return c.send(WebSocketEventSubscribe, products)
+}
+
+// Unsubscribe to the trades of products
+func (c *WebSocketClient) Unsubscribe(products ...exchanges.Product) error {
+	return c.send(WebSocketEventUnsubscribe, products)
+}
+
+// reconnect the lost connection with an increasing delay then subscribe again to the products,
+// it returns nil when the client is closed
+func (c *WebSocketClient) reconnect(cause error) *websocket.Conn {
+	c.mu.Lock()
+
+	if c.closed {
+		c.mu.Unlock()
+
+		return nil
+	}
+
+	c.ws = nil
+	c.reconnecting = true
+
+	c.mu.Unlock()
+
+	log.Error().Err(cause).Msg("Kraken WebSocket: connection lost")
+
+	delay := c.delay
+
+	for {
+		select {
+		case <-time.After(delay):
+		case <-c.done:
+			return nil
+		}
+
+		ws, err := c.dial()
+		if err != nil {
+			log.Error().Err(err).Msgf("Kraken WebSocket: reconnect, next attempt in %s", delay)
+
+			if delay *= 2; delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+
+			continue
+		}
+
+		c.mu.Lock()
+
+		if c.closed {
+			c.mu.Unlock()
+
+			ws.Close()
+
+			return nil
+		}
+
+		c.ws = ws
+		c.reconnecting = false
+
+		// a failed subscription loses the connection which is reconnected again
+		if len(c.products) > 0 {
+			products := []exchanges.Product{}
+
+			for _, product := range c.products {
+				products = append(products, product)
+			}
+
+			if err := ws.WriteJSON(tradeRequest(WebSocketEventSubscribe, products)); err != nil {
+				log.Error().Err(err).Msg("Kraken WebSocket: subscribe after reconnect")
+			}
+		}
+
+		c.mu.Unlock()
+
+		return ws
+	}
+}
+
+// receiver reads the messages until the client is closed, the lost connections are reconnected
+func (c *WebSocketClient) receiver(ws *websocket.Conn) {
+	defer close(c.Trades)
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			if ws = c.reconnect(err); ws == nil {
+				return
+			}
+
+			continue
+		}
+
+		if err := c.processMessage(message); err != nil {
+			log.Error().Err(err).Msg("Kraken WebSocket")
+		}
+	}
+}
+
+func (c *WebSocketClient) processMessage(message []byte) error {
+	// the events are objects and the channels data are arrays
+	if len(message) > 0 && message[0] == '{' {
+		event := &WebSocketEvent{}
+
+		if err := json.Unmarshal(message, event); err != nil {
+			return err
+		}
+
+		if event.Status == "error" {
+			return fmt.Errorf("%s %s: %s", event.Event, event.Pair, event.ErrorMessage)
+		}
+
+		return nil
+	}
+
+	trades, err := parseTrades(message)
+	if err != nil {
+		return err
+	}
+
+	for _, trade := range trades {
+		c.Trades <- trade
+	}
+
+	return nil
+}
+
+// parseTrades of a trade channel message formatted as
+// [channelID, [[price, volume, time, side, orderType, misc]], "trade", pair]
+func parseTrades(message []byte) ([]*Trade, error) {
+	var data []json.RawMessage
+
+	if err := json.Unmarshal(message, &data); err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4 {
+		return nil, fmt.Errorf("invalid channel message: %s", message)
+	}
+
+	var channel, pair string
+
+	if err := json.Unmarshal(data[len(data)-2], &channel); err != nil {
+		return nil, err
+	}
+
+	if channel != WebSocketChannelTrade {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(data[len(data)-1], &pair); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+
+	if err := json.Unmarshal(data[1], &rows); err != nil {
+		return nil, err
+	}
+
+	product := NewProductFromPair(pair)
+	trades := []*Trade{}
+
+	for _, row := range rows {
+		if len(row) < 4 {
+			return nil, fmt.Errorf("invalid trade: %v", row)
+		}
+
+		price, err := decimal.NewFromString(row[0])
+		if err != nil {
+			return nil, err
+		}
+
+		volume, err := decimal.NewFromString(row[1])
+		if err != nil {
+			return nil, err
+		}
+
+		t, err := parseTime(row[2])
+		if err != nil {
+			return nil, err
+		}
+
+		side := exchanges.SideTypeBuy
+
+		if row[3] == "s" {
+			side = exchanges.SideTypeSell
+		}
+
+		trades = append(trades, &Trade{
+			Product: product,
+			Price:   price,
+			Volume:  volume,
+			Time:    t,
+			Side:    side,
+		})
+	}
+
+	return trades, nil
+}
+
+// parseTime of the unix timestamps with fractional seconds
+func parseTime(value string) (time.Time, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	sec := d.IntPart()
+	nsec := d.Sub(decimal.New(sec, 0)).Shift(9).IntPart()
+
+	return time.Unix(sec, nsec).UTC(), nil
+}
@@ -5,12 +5,19 @@
 package exchanges
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 var (
 	ErrProviderNotFound = errors.New("provider not found")
 	ErrManagerNotInit   = errors.New("manager is not init")
+	ErrBalanceNotFound  = errors.New("balance not found")
 )
 
 // Manager of ExchangeProvider
@@ -40,3 +47,65 @@ func (m Manager) Get(key string) (ExchangeProvider, error) {
 func (m Manager) Add(exchange ExchangeProvider) {
 	m[exchange.Name()] = exchange
 }
+
+// Balance returns the available amount of currency on provider
+func (m Manager) Balance(provider string, currency string) (decimal.Decimal, error) {
+	exchange, err := m.Get(provider)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	balances, err := exchange.Balance().Balances()
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	for _, balance := range balances {
+		if strings.EqualFold(balance.Currency, currency) {
+			return balance.Available, nil
+		}
+	}
+
+	return decimal.Zero, ErrBalanceNotFound
+}
+
+// Product returns the trading rules of product on provider
+func (m Manager) Product(provider string, product string) (*ProductInfo, error) {
+	exchange, err := m.Get(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := exchange.Products()
+	if catalog == nil {
+		return nil, ErrProductsNotSupported
+	}
+
+	return catalog.Get(product)
+}
+
+// Close the connections of the exchanges, the first error is returned after all the exchanges are closed
+func (m Manager) Close(ctx context.Context) error {
+	names := []string{}
+
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var first error
+
+	for _, name := range names {
+		closer, ok := m[name].(Closer)
+		if ok == false {
+			continue
+		}
+
+		if err := closer.Close(ctx); err != nil && first == nil {
+			first = fmt.Errorf("close %s: %v", name, err)
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type closerMock struct {
+	providerMock
+	closed bool
+	err    error
+}
+
+func (p *closerMock) Close(ctx context.Context) error {
+	p.closed = true
+
+	return p.err
+}
+
+func TestManagerClose(t *testing.T) {
+	manager := NewManager()
+
+	gdax := &closerMock{providerMock: providerMock{name: "gdax"}}
+	kraken := &closerMock{providerMock: providerMock{name: "kraken"}, err: errors.New("boom")}
+
+	manager.Add(gdax)
+	manager.Add(kraken)
+	manager.Add(&providerMock{name: "paper"})
+
+	assert.EqualError(t, manager.Close(context.Background()), "close kraken: boom")
+	assert.True(t, gdax.closed)
+	assert.True(t, kraken.closed)
+
+	kraken.err = nil
+
+	assert.NoError(t, manager.Close(context.Background()))
+}
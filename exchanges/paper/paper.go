@@ -0,0 +1,203 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package paper provides an exchange trading with virtual balances on the feed of a real exchange.
+package paper
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// Errors
+var (
+	ErrMissingSource       = errors.New("paper exchange requires a source")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// BalanceStore persists the virtual balances of the paper exchanges
+type BalanceStore interface {
+	// Load the balances of the exchange name, nil when they were never saved
+	Load(name string) (map[string]decimal.Decimal, error)
+	Save(name string, balances map[string]decimal.Decimal) error
+}
+
+// Paper struct
+type Paper struct {
+	name    string
+	source  exchanges.ExchangeProvider
+	balance *Balance
+}
+
+// NewPaper Exchange, the ticker is provided by source
+func NewPaper(name string, source exchanges.ExchangeProvider, balances map[string]decimal.Decimal) *Paper {
+	return &Paper{
+		name:    name,
+		source:  source,
+		balance: NewBalance(balances),
+	}
+}
+
+// NewProvider factory of the paper instances, the source must be built before
+func NewProvider(config *exchanges.ProviderConfig, providers exchanges.Manager) (exchanges.ExchangeProvider, error) {
+	if config.Source == "" {
+		return nil, ErrMissingSource
+	}
+
+	source, err := providers.Get(config.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPaper(config.Name, source, config.Balances), nil
+}
+
+// Name of provider
+func (e Paper) Name() string {
+	return e.name
+}
+
+// Ticker of source exchange
+func (e *Paper) Ticker() exchanges.TickerProvider {
+	return e.source.Ticker()
+}
+
+// Balance provider
+func (e *Paper) Balance() exchanges.BalanceProvider {
+	return e.balance
+}
+
+// Persist the balances in store, the saved balances replace the initial balances
+// and the balances are saved after each fill
+func (e *Paper) Persist(store BalanceStore) error {
+	return e.balance.persist(e.name, store)
+}
+
+// Products catalog of source exchange
+func (e *Paper) Products() *exchanges.ProductCatalog {
+	if e.source == nil {
+		return nil
+	}
+
+	return e.source.Products()
+}
+
+// Balance struct of virtual balances
+type Balance struct {
+	mu       sync.RWMutex
+	balances map[string]decimal.Decimal
+	name     string
+	store    BalanceStore
+}
+
+// NewBalance with initial amounts by currency
+func NewBalance(balances map[string]decimal.Decimal) *Balance {
+	b := &Balance{
+		balances: make(map[string]decimal.Decimal),
+	}
+
+	for currency, amount := range balances {
+		b.balances[strings.ToUpper(currency)] = amount
+	}
+
+	return b
+}
+
+// persist the balances of the exchange name in store
+func (b *Balance) persist(name string, store BalanceStore) error {
+	saved, err := store.Load(name)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if saved != nil {
+		b.balances = make(map[string]decimal.Decimal)
+
+		for currency, amount := range saved {
+			b.balances[strings.ToUpper(currency)] = amount
+		}
+	}
+
+	b.name = name
+	b.store = store
+
+	return b.save()
+}
+
+// save the balances in the store, the lock is held by the caller
+func (b *Balance) save() error {
+	if b.store == nil {
+		return nil
+	}
+
+	balances := make(map[string]decimal.Decimal)
+
+	for currency, amount := range b.balances {
+		balances[currency] = amount
+	}
+
+	return b.store.Save(b.name, balances)
+}
+
+// Balances implements exchanges.BalanceProvider
+func (b *Balance) Balances() ([]*exchanges.Balance, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	balances := []*exchanges.Balance{}
+
+	for currency, amount := range b.balances {
+		balances = append(balances, &exchanges.Balance{
+			Currency:  currency,
+			Total:     amount,
+			Available: amount,
+		})
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Currency < balances[j].Currency
+	})
+
+	return balances, nil
+}
+
+// Fill implements exchanges.FillRecorder
+func (b *Balance) Fill(side exchanges.SideType, product exchanges.Product, size decimal.Decimal, value decimal.Decimal) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	base := strings.ToUpper(product.From)
+	quote := strings.ToUpper(product.To)
+
+	if side == exchanges.SideTypeBuy {
+		if b.balances[quote].LessThan(value) {
+			return ErrInsufficientBalance
+		}
+
+		b.balances[base] = b.balances[base].Add(size)
+		b.balances[quote] = b.balances[quote].Sub(value)
+	} else {
+		if b.balances[base].LessThan(size) {
+			return ErrInsufficientBalance
+		}
+
+		b.balances[base] = b.balances[base].Sub(size)
+		b.balances[quote] = b.balances[quote].Add(value)
+	}
+
+	if err := b.save(); err != nil {
+		log.Error().Err(err).Msgf("Save balances of %s", b.name)
+	}
+
+	return nil
+}
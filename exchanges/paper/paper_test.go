@@ -0,0 +1,92 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package paper
+
+import (
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalance(t *testing.T) {
+	b := NewBalance(map[string]decimal.Decimal{
+		"eur": decimal.New(1500, 0),
+	})
+
+	product := exchanges.NewProductFromString("BTC-EUR")
+
+	assert.NoError(t, b.Fill(exchanges.SideTypeBuy, product, decimal.RequireFromString("0.1"), decimal.New(500, 0)))
+	assert.NoError(t, b.Fill(exchanges.SideTypeBuy, product, decimal.RequireFromString("0.2"), decimal.New(1000, 0)))
+
+	// the fills the balances cannot cover are refused
+	assert.Equal(t, ErrInsufficientBalance, b.Fill(exchanges.SideTypeBuy, product, decimal.RequireFromString("0.1"), decimal.New(1, 0)))
+	assert.Equal(t, ErrInsufficientBalance, b.Fill(exchanges.SideTypeSell, product, decimal.RequireFromString("0.4"), decimal.New(2000, 0)))
+
+	balances, err := b.Balances()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(balances))
+	assert.Equal(t, "BTC", balances[0].Currency)
+	assert.Equal(t, "0.3", balances[0].Total.String())
+	assert.Equal(t, "EUR", balances[1].Currency)
+	assert.True(t, balances[1].Available.IsZero())
+
+	assert.NoError(t, b.Fill(exchanges.SideTypeSell, product, decimal.RequireFromString("0.3"), decimal.New(1600, 0)))
+
+	balances, err = b.Balances()
+	assert.NoError(t, err)
+	assert.True(t, balances[0].Total.IsZero())
+	assert.Equal(t, "1600", balances[1].Total.String())
+}
+
+func TestPaper(t *testing.T) {
+	e := NewPaper("paper", nil, nil)
+
+	assert.Equal(t, "paper", e.Name())
+	assert.Implements(t, (*exchanges.FillRecorder)(nil), e.Balance())
+}
+
+// storeMock keeps the saved balances in memory
+type storeMock map[string]map[string]decimal.Decimal
+
+func (s storeMock) Load(name string) (map[string]decimal.Decimal, error) {
+	return s[name], nil
+}
+
+func (s storeMock) Save(name string, balances map[string]decimal.Decimal) error {
+	s[name] = balances
+
+	return nil
+}
+
+func TestPaperPersist(t *testing.T) {
+	store := storeMock{}
+	product := exchanges.NewProductFromString("BTC-EUR")
+
+	e := NewPaper("paper", nil, map[string]decimal.Decimal{
+		"EUR": decimal.New(1000, 0),
+	})
+
+	// the initial balances are saved
+	assert.NoError(t, e.Persist(store))
+	assert.Equal(t, "1000", store["paper"]["EUR"].String())
+
+	assert.NoError(t, e.Balance().(exchanges.FillRecorder).Fill(exchanges.SideTypeBuy, product, decimal.RequireFromString("0.1"), decimal.New(500, 0)))
+	assert.Equal(t, "500", store["paper"]["EUR"].String())
+
+	// the saved balances replace the initial balances at the next start
+	restarted := NewPaper("paper", nil, map[string]decimal.Decimal{
+		"EUR": decimal.New(1000, 0),
+	})
+
+	assert.NoError(t, restarted.Persist(store))
+
+	balances, err := restarted.Balance().Balances()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(balances))
+	assert.Equal(t, "0.1", balances[0].Total.String())
+	assert.Equal(t, "500", balances[1].Total.String())
+}
@@ -0,0 +1,191 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCatalogTTL is the duration after which the products of a catalog are loaded again
+const DefaultCatalogTTL = time.Hour
+
+// ProductStatus type
+type ProductStatus string
+
+// ProductStatus enum
+const (
+	ProductStatusOnline   ProductStatus = "online"
+	ProductStatusOffline  ProductStatus = "offline"
+	ProductStatusDelisted ProductStatus = "delisted"
+)
+
+// Errors
+var (
+	ErrProductNotFound      = errors.New("product not found")
+	ErrProductsNotSupported = errors.New("products catalog not supported by provider")
+)
+
+// DefaultBaseIncrement is used when the exchange does not define the increment of the sizes
+var DefaultBaseIncrement = decimal.New(1, -8)
+
+// ProductInfo struct of the trading rules of a product
+type ProductInfo struct {
+	Product        Product         `json:"product"`
+	BaseIncrement  decimal.Decimal `json:"base_increment"`
+	QuoteIncrement decimal.Decimal `json:"quote_increment"`
+	MinSize        decimal.Decimal `json:"min_size"`
+	MaxSize        decimal.Decimal `json:"max_size"`
+	Status         ProductStatus   `json:"status"`
+}
+
+// IsTrading returns true if the orders are accepted on the product
+func (p ProductInfo) IsTrading() bool {
+	return p.Status == ProductStatusOnline
+}
+
+func roundDown(value decimal.Decimal, increment decimal.Decimal) decimal.Decimal {
+	return value.Div(increment).Floor().Mul(increment)
+}
+
+// RoundSize down to the base increment of product
+func (p ProductInfo) RoundSize(size decimal.Decimal) decimal.Decimal {
+	increment := p.BaseIncrement
+
+	if increment.Sign() <= 0 {
+		increment = DefaultBaseIncrement
+	}
+
+	return roundDown(size, increment)
+}
+
+// RoundPrice down to the quote increment of product
+func (p ProductInfo) RoundPrice(price decimal.Decimal) decimal.Decimal {
+	if p.QuoteIncrement.Sign() <= 0 {
+		return price
+	}
+
+	return roundDown(price, p.QuoteIncrement)
+}
+
+// ValidateSize checks that an order of size can be placed on product, a zero max size is not checked
+func (p ProductInfo) ValidateSize(size decimal.Decimal) error {
+	if p.IsTrading() == false {
+		return fmt.Errorf("product %s is %s", p.Product, p.Status)
+	}
+
+	if size.LessThan(p.MinSize) {
+		return fmt.Errorf("size %s is below the minimum size %s of %s", size, p.MinSize, p.Product)
+	}
+
+	if p.MaxSize.IsPositive() && size.GreaterThan(p.MaxSize) {
+		return fmt.Errorf("size %s is above the maximum size %s of %s", size, p.MaxSize, p.Product)
+	}
+
+	return nil
+}
+
+// ProductCatalog of an exchange, the products are loaded on first use and refreshed after ttl
+type ProductCatalog struct {
+	mu       sync.Mutex
+	load     func() ([]*ProductInfo, error)
+	ttl      time.Duration
+	products map[string]*ProductInfo
+	loadedAt time.Time
+	now      func() time.Time
+}
+
+// NewProductCatalog with the load function of the exchange
+func NewProductCatalog(load func() ([]*ProductInfo, error), ttl time.Duration) *ProductCatalog {
+	if ttl <= 0 {
+		ttl = DefaultCatalogTTL
+	}
+
+	return &ProductCatalog{
+		load:     load,
+		ttl:      ttl,
+		products: make(map[string]*ProductInfo),
+		now:      time.Now,
+	}
+}
+
+// refresh the products when they are expired, the stale products are kept when the loading fails
+func (c *ProductCatalog) refresh() error {
+	now := c.now()
+
+	if c.loadedAt.IsZero() == false && now.Sub(c.loadedAt) < c.ttl {
+		return nil
+	}
+
+	products, err := c.load()
+	if err != nil {
+		if len(c.products) > 0 {
+			log.Warn().Err(err).Msg("Refresh products catalog failed, using stale products")
+
+			// retry after the next ttl
+			c.loadedAt = now
+
+			return nil
+		}
+
+		return err
+	}
+
+	c.products = make(map[string]*ProductInfo)
+
+	for _, product := range products {
+		c.products[strings.ToUpper(product.Product.String())] = product
+	}
+
+	c.loadedAt = now
+
+	return nil
+}
+
+// Get product by id
+func (c *ProductCatalog) Get(id string) (*ProductInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	product, ok := c.products[strings.ToUpper(id)]
+	if ok == false {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+// All products of catalog
+func (c *ProductCatalog) All() ([]*ProductInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+
+	products := []*ProductInfo{}
+
+	for _, product := range c.products {
+		products = append(products, product)
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Product.String() < products[j].Product.String()
+	})
+
+	return products, nil
+}
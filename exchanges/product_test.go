@@ -0,0 +1,102 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProductInfo() *ProductInfo {
+	return &ProductInfo{
+		Product:        NewProduct("BTC", "EUR"),
+		BaseIncrement:  decimal.RequireFromString("0.0001"),
+		QuoteIncrement: decimal.RequireFromString("0.01"),
+		MinSize:        decimal.RequireFromString("0.001"),
+		MaxSize:        decimal.New(50, 0),
+		Status:         ProductStatusOnline,
+	}
+}
+
+func TestProductInfoRound(t *testing.T) {
+	p := newProductInfo()
+
+	assert.Equal(t, "0.1234", p.RoundSize(decimal.RequireFromString("0.123456789")).String())
+	assert.Equal(t, "1", p.RoundSize(decimal.New(1, 0)).String())
+	assert.Equal(t, "10000.12", p.RoundPrice(decimal.RequireFromString("10000.129")).String())
+
+	p.BaseIncrement = decimal.Zero
+	p.QuoteIncrement = decimal.Zero
+
+	assert.Equal(t, "0.12345678", p.RoundSize(decimal.RequireFromString("0.123456789")).String())
+	assert.Equal(t, "10000.129", p.RoundPrice(decimal.RequireFromString("10000.129")).String())
+}
+
+func TestProductInfoValidateSize(t *testing.T) {
+	p := newProductInfo()
+
+	assert.NoError(t, p.ValidateSize(decimal.RequireFromString("0.001")))
+	assert.EqualError(t, p.ValidateSize(decimal.RequireFromString("0.0009")), "size 0.0009 is below the minimum size 0.001 of BTC-EUR")
+	assert.EqualError(t, p.ValidateSize(decimal.New(51, 0)), "size 51 is above the maximum size 50 of BTC-EUR")
+
+	p.MaxSize = decimal.Zero
+
+	assert.NoError(t, p.ValidateSize(decimal.New(51, 0)))
+
+	p.Status = ProductStatusOffline
+
+	assert.EqualError(t, p.ValidateSize(decimal.New(1, 0)), "product BTC-EUR is offline")
+}
+
+func TestProductCatalog(t *testing.T) {
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+	loads := 0
+
+	var err error
+
+	c := NewProductCatalog(func() ([]*ProductInfo, error) {
+		loads++
+
+		return []*ProductInfo{newProductInfo()}, err
+	}, time.Hour)
+	c.now = func() time.Time {
+		return now
+	}
+
+	p, e := c.Get("btc-eur")
+	assert.NoError(t, e)
+	assert.Equal(t, "BTC-EUR", p.Product.String())
+
+	_, e = c.Get("ETH-EUR")
+	assert.Equal(t, ErrProductNotFound, e)
+	assert.Equal(t, 1, loads)
+
+	// the stale products are used when the refresh fails
+	now = now.Add(time.Hour)
+	err = errors.New("unavailable")
+
+	p, e = c.Get("BTC-EUR")
+	assert.NoError(t, e)
+	assert.NotNil(t, p)
+	assert.Equal(t, 2, loads)
+
+	products, e := c.All()
+	assert.NoError(t, e)
+	assert.Equal(t, 1, len(products))
+	assert.Equal(t, 2, loads)
+}
+
+func TestProductCatalogLoadFailed(t *testing.T) {
+	c := NewProductCatalog(func() ([]*ProductInfo, error) {
+		return nil, errors.New("unavailable")
+	}, 0)
+
+	_, err := c.Get("BTC-EUR")
+	assert.EqualError(t, err, "unavailable")
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrUnknownType is returned when no factory is registered for the exchange type
+var ErrUnknownType = errors.New("unknown exchange type")
+
+// ProviderConfig of an exchange instance
+type ProviderConfig struct {
+	// Name of the instance in the manager
+	Name       string
+	Type       string
+	Key        string
+	Secret     string
+	Passphrase string
+	Sandbox    bool
+	// API url, the default of the type when empty
+	API string
+	// WebSocketAPI url, the default of the type when empty
+	WebSocketAPI string
+	// Source instance of the ticker of a paper exchange
+	Source string
+	// Balances by currency of a paper exchange
+	Balances map[string]decimal.Decimal
+}
+
+// Factory creates the provider of an exchange instance, it must not connect to the exchange
+// before the provider is used. The manager contains the instances built without source.
+type Factory func(config *ProviderConfig, providers Manager) (ExchangeProvider, error)
+
+// Registry of the factories by exchange type
+type Registry map[string]Factory
+
+// NewRegistry of factories
+func NewRegistry() Registry {
+	return Registry{}
+}
+
+// Register the factory of an exchange type
+func (r Registry) Register(kind string, factory Factory) {
+	r[kind] = factory
+}
+
+// New provider of config
+func (r Registry) New(config *ProviderConfig, providers Manager) (ExchangeProvider, error) {
+	factory, ok := r[config.Type]
+	if ok == false {
+		return nil, fmt.Errorf("exchange %s: %v %s", config.Name, ErrUnknownType, config.Type)
+	}
+
+	provider, err := factory(config, providers)
+	if err != nil {
+		return nil, fmt.Errorf("exchange %s: %v", config.Name, err)
+	}
+
+	return provider, nil
+}
+
+// Build the manager of the instances, the instances with a source are built last
+func (r Registry) Build(configs []*ProviderConfig) (Manager, error) {
+	sorted := make([]*ProviderConfig, len(configs))
+	copy(sorted, configs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Source == "") != (sorted[j].Source == "") {
+			return sorted[i].Source == ""
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	manager := NewManager()
+
+	for _, config := range sorted {
+		provider, err := r.New(config, manager)
+		if err != nil {
+			return nil, err
+		}
+
+		manager[config.Name] = provider
+	}
+
+	return manager, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package exchanges
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type providerMock struct {
+	name   string
+	source ExchangeProvider
+}
+
+func (p providerMock) Name() string {
+	return p.name
+}
+
+func (p providerMock) Ticker() TickerProvider {
+	return nil
+}
+
+func (p providerMock) Balance() BalanceProvider {
+	return nil
+}
+
+func (p providerMock) Products() *ProductCatalog {
+	return nil
+}
+
+func TestRegistryBuild(t *testing.T) {
+	registry := NewRegistry()
+
+	registry.Register("mock", func(config *ProviderConfig, providers Manager) (ExchangeProvider, error) {
+		provider := &providerMock{
+			name: config.Name,
+		}
+
+		if config.Source != "" {
+			source, err := providers.Get(config.Source)
+			if err != nil {
+				return nil, err
+			}
+
+			provider.source = source
+		}
+
+		return provider, nil
+	})
+
+	manager, err := registry.Build([]*ProviderConfig{
+		{Name: "paper", Type: "mock", Source: "main"},
+		{Name: "main", Type: "mock"},
+		{Name: "sandbox", Type: "mock", Sandbox: true},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(manager))
+
+	paper, err := manager.Get("paper")
+	assert.NoError(t, err)
+	assert.Equal(t, "main", paper.(*providerMock).source.Name())
+
+	_, err = registry.Build([]*ProviderConfig{
+		{Name: "main", Type: "unknown"},
+	})
+	assert.EqualError(t, err, "exchange main: unknown exchange type unknown")
+
+	registry.Register("broken", func(config *ProviderConfig, providers Manager) (ExchangeProvider, error) {
+		return nil, errors.New("boom")
+	})
+
+	_, err = registry.Build([]*ProviderConfig{
+		{Name: "main", Type: "broken"},
+	})
+	assert.EqualError(t, err, "exchange main: boom")
+}
@@ -0,0 +1,25 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors
+var (
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+// SyntaxError is returned by Compile when the source is invalid
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package expression compiles and evaluates arithmetic and boolean expressions
+// like `rsi(14) < 30 && price < sma(50) * 0.97`.
+package expression
+
+// Type of expression
+type Type int
+
+// Type enum
+const (
+	TypeNumber Type = iota
+	TypeBool
+)
+
+func (t Type) String() string {
+	if t == TypeBool {
+		return "bool"
+	}
+
+	return "number"
+}
+
+// Env resolves the variables and functions of expression at evaluation
+type Env interface {
+	Variable(name string) (float64, error)
+	Call(name string, args ...float64) (float64, error)
+}
+
+// Definitions of the variables and functions available in expressions
+type Definitions struct {
+	// Variables names
+	Variables map[string]bool
+
+	// Functions names with their number of arguments
+	Functions map[string]int
+}
+
+// Program is a compiled expression
+type Program struct {
+	source string
+	root   *node
+}
+
+// Compile source expression, the variables and functions are checked against definitions
+func Compile(source string, defs *Definitions) (*Program, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{
+		tokens: tokens,
+		defs:   defs,
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected " + t.String()}
+	}
+
+	return &Program{
+		source: source,
+		root:   root,
+	}, nil
+}
+
+// String returns the source of Program
+func (p Program) String() string {
+	return p.source
+}
+
+// Type of the Program result
+func (p Program) Type() Type {
+	return p.root.typ
+}
+
+// Bool evaluates the Program as condition
+func (p Program) Bool(env Env) (bool, error) {
+	if p.root.typ != TypeBool {
+		return false, &SyntaxError{Pos: 0, Msg: "expression is not a condition"}
+	}
+
+	return p.root.cond(env)
+}
+
+// Number evaluates the Program as number
+func (p Program) Number(env Env) (float64, error) {
+	if p.root.typ != TypeNumber {
+		return 0, &SyntaxError{Pos: 0, Msg: "expression is not a number"}
+	}
+
+	return p.root.num(env)
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type env struct {
+	variables map[string]float64
+	calls     int
+}
+
+func (e *env) Variable(name string) (float64, error) {
+	return e.variables[name], nil
+}
+
+func (e *env) Call(name string, args ...float64) (float64, error) {
+	e.calls++
+
+	switch name {
+	case "sma":
+		return args[0] * 2, nil
+	case "max":
+		if args[0] > args[1] {
+			return args[0], nil
+		}
+
+		return args[1], nil
+	default:
+		return 0, errors.New("not enough data")
+	}
+}
+
+var defs = &Definitions{
+	Variables: map[string]bool{
+		"price": true,
+	},
+	Functions: map[string]int{
+		"sma":  1,
+		"max":  2,
+		"fail": 0,
+	},
+}
+
+func TestCompileCondition(t *testing.T) {
+	p, err := Compile("rsi < 30", defs)
+	assert.Nil(t, p)
+	assert.EqualError(t, err, `unknown variable "rsi" at position 0`)
+
+	p, err = Compile("price < sma(50) * 0.97 && !(price == 0)", defs)
+	assert.NoError(t, err)
+	assert.Equal(t, TypeBool, p.Type())
+	assert.Equal(t, "price < sma(50) * 0.97 && !(price == 0)", p.String())
+
+	e := &env{variables: map[string]float64{"price": 96}}
+
+	result, err := p.Bool(e)
+	assert.NoError(t, err)
+	assert.True(t, result)
+
+	e.variables["price"] = 98
+
+	result, err = p.Bool(e)
+	assert.NoError(t, err)
+	assert.False(t, result)
+
+	_, err = p.Number(e)
+	assert.Error(t, err)
+}
+
+func TestCompileNumber(t *testing.T) {
+	p, err := Compile("-(price + 2) * 3 / max(1, 2) - 1", defs)
+	assert.NoError(t, err)
+	assert.Equal(t, TypeNumber, p.Type())
+
+	result, err := p.Number(&env{variables: map[string]float64{"price": 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(-10), result)
+
+	_, err = p.Bool(&env{})
+	assert.Error(t, err)
+
+	p, err = Compile("price / 0", defs)
+	assert.NoError(t, err)
+
+	_, err = p.Number(&env{})
+	assert.Equal(t, ErrDivisionByZero, err)
+}
+
+func TestCompileShortCircuit(t *testing.T) {
+	p, err := Compile("false && fail() > 1 || true || fail() > 1", defs)
+	assert.NoError(t, err)
+
+	e := &env{}
+
+	result, err := p.Bool(e)
+	assert.NoError(t, err)
+	assert.True(t, result)
+	assert.Equal(t, 0, e.calls)
+
+	p, err = Compile("true && fail() > 1", defs)
+	assert.NoError(t, err)
+
+	_, err = p.Bool(e)
+	assert.EqualError(t, err, "not enough data")
+}
+
+func TestCompileErrors(t *testing.T) {
+	errs := map[string]string{
+		"":                     `unexpected end of expression at position 0`,
+		"price <":              `unexpected end of expression at position 7`,
+		"price < 1 < 2":        `unexpected "<" at position 10`,
+		"(price < 1":           `expected ")", got end of expression at position 10`,
+		"price # 1":            `unexpected character '#' at position 6`,
+		"1..2 > 1":             `invalid number "1..2" at position 0`,
+		"rsi(14) < 30":         `unknown function "rsi" at position 0`,
+		"sma(1, 2) > 1":        `function "sma" expects 1 arguments, got 2`,
+		"sma(1 > 2) > 1":       `function "sma" expects number arguments at position 4`,
+		"price && true":        `operator "&&" expects bool operand, got number at position 0`,
+		"true + 1 > 0":         `operator "+" expects number operand, got bool at position 0`,
+		"!price":               `operator "!" expects bool operand, got number at position 1`,
+		"-true":                `operator "-" expects number operand, got bool at position 1`,
+		"true == 1":            `operator "==" expects bool operand, got number at position 8`,
+		"max(1, 2":             `expected ")", got end of expression at position 8`,
+		"price > 1 || price":   `operator "||" expects bool operand, got number at position 13`,
+		"price < true":         `operator "<" expects number operand, got bool at position 8`,
+		"sma(1) > 1 price":     `unexpected "price" at position 11`,
+		"(true) == (1 < 2) )":  `unexpected ")" at position 18`,
+		"max(1, 2) == max(1,)": `unexpected ")" at position 19`,
+	}
+
+	for source, msg := range errs {
+		p, err := Compile(source, defs)
+		assert.Nil(t, p, source)
+
+		if assert.Error(t, err, source) {
+			assert.Contains(t, err.Error(), msg, source)
+		}
+	}
+}
+
+func BenchmarkProgram(b *testing.B) {
+	p, err := Compile("price < sma(50) * 0.97 && max(price, 1) > 0", defs)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	e := &env{variables: map[string]float64{"price": 96}}
+
+	for n := 0; n < b.N; n++ {
+		p.Bool(e)
+	}
+}
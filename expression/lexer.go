@@ -0,0 +1,117 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"strconv"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	typ   tokenType
+	value string
+	num   float64
+	pos   int
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of expression"
+	}
+
+	return strconv.Quote(t.value)
+}
+
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"<", ">", "!", "+", "-", "*", "/",
+}
+
+func isIdentRune(r rune, first bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+
+	return first == false && unicode.IsDigit(r)
+}
+
+// lex splits the source in tokens
+func lex(source string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{typ: tokenLParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{typ: tokenRParen, value: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{typ: tokenComma, value: ",", pos: i})
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			value := string(runes[start:i])
+
+			num, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, &SyntaxError{Pos: start, Msg: "invalid number " + strconv.Quote(value)}
+			}
+
+			tokens = append(tokens, token{typ: tokenNumber, value: value, num: num, pos: start})
+		case isIdentRune(r, true):
+			start := i
+
+			for i < len(runes) && isIdentRune(runes[i], false) {
+				i++
+			}
+
+			tokens = append(tokens, token{typ: tokenIdent, value: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+
+			for _, op := range operators {
+				if i+len(op) <= len(runes) && string(runes[i:i+len(op)]) == op {
+					tokens = append(tokens, token{typ: tokenOperator, value: op, pos: i})
+					i += len(op)
+					matched = true
+
+					break
+				}
+			}
+
+			if matched == false {
+				return nil, &SyntaxError{Pos: i, Msg: "unexpected character " + strconv.QuoteRune(r)}
+			}
+		}
+	}
+
+	tokens = append(tokens, token{typ: tokenEOF, pos: len(runes)})
+
+	return tokens, nil
+}
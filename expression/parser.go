@@ -0,0 +1,562 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package expression
+
+import (
+	"fmt"
+)
+
+// node is a compiled expression, num is set for TypeNumber and cond for TypeBool
+type node struct {
+	typ  Type
+	pos  int
+	num  func(env Env) (float64, error)
+	cond func(env Env) (bool, error)
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	defs   *Definitions
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+
+	if t.typ != tokenEOF {
+		p.i++
+	}
+
+	return t
+}
+
+func (p *parser) acceptOperator(ops ...string) (token, bool) {
+	t := p.peek()
+
+	if t.typ != tokenOperator {
+		return t, false
+	}
+
+	for _, op := range ops {
+		if t.value == op {
+			p.i++
+
+			return t, true
+		}
+	}
+
+	return t, false
+}
+
+func expect(n *node, typ Type, op token) error {
+	if n.typ != typ {
+		return &SyntaxError{
+			Pos: n.pos,
+			Msg: fmt.Sprintf("operator %s expects %s operand, got %s", op.String(), typ, n.typ),
+		}
+	}
+
+	return nil
+}
+
+// parseOr: and ( "||" and )*
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOperator("||")
+		if ok == false {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := expect(left, TypeBool, op); err != nil {
+			return nil, err
+		}
+
+		if err := expect(right, TypeBool, op); err != nil {
+			return nil, err
+		}
+
+		l, r := left.cond, right.cond
+
+		left = &node{
+			typ: TypeBool,
+			pos: left.pos,
+			cond: func(env Env) (bool, error) {
+				a, err := l(env)
+				if err != nil || a {
+					return a, err
+				}
+
+				return r(env)
+			},
+		}
+	}
+}
+
+// parseAnd: equality ( "&&" equality )*
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseEquality()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOperator("&&")
+		if ok == false {
+			return left, nil
+		}
+
+		right, err := p.parseEquality()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := expect(left, TypeBool, op); err != nil {
+			return nil, err
+		}
+
+		if err := expect(right, TypeBool, op); err != nil {
+			return nil, err
+		}
+
+		l, r := left.cond, right.cond
+
+		left = &node{
+			typ: TypeBool,
+			pos: left.pos,
+			cond: func(env Env) (bool, error) {
+				a, err := l(env)
+				if err != nil || a == false {
+					return false, err
+				}
+
+				return r(env)
+			},
+		}
+	}
+}
+
+// parseEquality: comparison ( ( "==" | "!=" ) comparison )*
+func (p *parser) parseEquality() (*node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOperator("==", "!=")
+		if ok == false {
+			return left, nil
+		}
+
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := expect(right, left.typ, op); err != nil {
+			return nil, err
+		}
+
+		equal := op.value == "=="
+
+		var cond func(env Env) (bool, error)
+
+		if left.typ == TypeBool {
+			l, r := left.cond, right.cond
+
+			cond = func(env Env) (bool, error) {
+				a, err := l(env)
+				if err != nil {
+					return false, err
+				}
+
+				b, err := r(env)
+				if err != nil {
+					return false, err
+				}
+
+				return (a == b) == equal, nil
+			}
+		} else {
+			l, r := left.num, right.num
+
+			cond = func(env Env) (bool, error) {
+				a, err := l(env)
+				if err != nil {
+					return false, err
+				}
+
+				b, err := r(env)
+				if err != nil {
+					return false, err
+				}
+
+				return (a == b) == equal, nil
+			}
+		}
+
+		left = &node{
+			typ:  TypeBool,
+			pos:  left.pos,
+			cond: cond,
+		}
+	}
+}
+
+// parseComparison: additive ( ( "<" | "<=" | ">" | ">=" ) additive )?
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.acceptOperator("<", "<=", ">", ">=")
+	if ok == false {
+		return left, nil
+	}
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := expect(left, TypeNumber, op); err != nil {
+		return nil, err
+	}
+
+	if err := expect(right, TypeNumber, op); err != nil {
+		return nil, err
+	}
+
+	var compare func(a, b float64) bool
+
+	switch op.value {
+	case "<":
+		compare = func(a, b float64) bool { return a < b }
+	case "<=":
+		compare = func(a, b float64) bool { return a <= b }
+	case ">":
+		compare = func(a, b float64) bool { return a > b }
+	default:
+		compare = func(a, b float64) bool { return a >= b }
+	}
+
+	l, r := left.num, right.num
+
+	return &node{
+		typ: TypeBool,
+		pos: left.pos,
+		cond: func(env Env) (bool, error) {
+			a, err := l(env)
+			if err != nil {
+				return false, err
+			}
+
+			b, err := r(env)
+			if err != nil {
+				return false, err
+			}
+
+			return compare(a, b), nil
+		},
+	}, nil
+}
+
+func arithmetic(left *node, right *node, op token) (*node, error) {
+	if err := expect(left, TypeNumber, op); err != nil {
+		return nil, err
+	}
+
+	if err := expect(right, TypeNumber, op); err != nil {
+		return nil, err
+	}
+
+	var compute func(a, b float64) (float64, error)
+
+	switch op.value {
+	case "+":
+		compute = func(a, b float64) (float64, error) { return a + b, nil }
+	case "-":
+		compute = func(a, b float64) (float64, error) { return a - b, nil }
+	case "*":
+		compute = func(a, b float64) (float64, error) { return a * b, nil }
+	default:
+		compute = func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, ErrDivisionByZero
+			}
+
+			return a / b, nil
+		}
+	}
+
+	l, r := left.num, right.num
+
+	return &node{
+		typ: TypeNumber,
+		pos: left.pos,
+		num: func(env Env) (float64, error) {
+			a, err := l(env)
+			if err != nil {
+				return 0, err
+			}
+
+			b, err := r(env)
+			if err != nil {
+				return 0, err
+			}
+
+			return compute(a, b)
+		},
+	}, nil
+}
+
+// parseAdditive: multiplicative ( ( "+" | "-" ) multiplicative )*
+func (p *parser) parseAdditive() (*node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOperator("+", "-")
+		if ok == false {
+			return left, nil
+		}
+
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+
+		if left, err = arithmetic(left, right, op); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseMultiplicative: unary ( ( "*" | "/" ) unary )*
+func (p *parser) parseMultiplicative() (*node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.acceptOperator("*", "/")
+		if ok == false {
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		if left, err = arithmetic(left, right, op); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseUnary: ( "!" | "-" ) unary | primary
+func (p *parser) parseUnary() (*node, error) {
+	op, ok := p.acceptOperator("!", "-")
+	if ok == false {
+		return p.parsePrimary()
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	if op.value == "!" {
+		if err := expect(operand, TypeBool, op); err != nil {
+			return nil, err
+		}
+
+		c := operand.cond
+
+		return &node{
+			typ: TypeBool,
+			pos: op.pos,
+			cond: func(env Env) (bool, error) {
+				v, err := c(env)
+
+				return v == false, err
+			},
+		}, nil
+	}
+
+	if err := expect(operand, TypeNumber, op); err != nil {
+		return nil, err
+	}
+
+	n := operand.num
+
+	return &node{
+		typ: TypeNumber,
+		pos: op.pos,
+		num: func(env Env) (float64, error) {
+			v, err := n(env)
+
+			return -v, err
+		},
+	}, nil
+}
+
+// parsePrimary: number | "true" | "false" | ident | ident "(" args ")" | "(" or ")"
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.next()
+
+	switch t.typ {
+	case tokenNumber:
+		value := t.num
+
+		return &node{
+			typ: TypeNumber,
+			pos: t.pos,
+			num: func(env Env) (float64, error) {
+				return value, nil
+			},
+		}, nil
+	case tokenLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.typ != tokenRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: "expected \")\", got " + closing.String()}
+		}
+
+		return n, nil
+	case tokenIdent:
+		if p.peek().typ == tokenLParen {
+			return p.parseCall(t)
+		}
+
+		return p.parseIdent(t)
+	default:
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unexpected " + t.String()}
+	}
+}
+
+func (p *parser) parseIdent(t token) (*node, error) {
+	switch t.value {
+	case "true", "false":
+		value := t.value == "true"
+
+		return &node{
+			typ: TypeBool,
+			pos: t.pos,
+			cond: func(env Env) (bool, error) {
+				return value, nil
+			},
+		}, nil
+	}
+
+	if p.defs == nil || p.defs.Variables[t.value] == false {
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unknown variable " + t.String()}
+	}
+
+	name := t.value
+
+	return &node{
+		typ: TypeNumber,
+		pos: t.pos,
+		num: func(env Env) (float64, error) {
+			return env.Variable(name)
+		},
+	}, nil
+}
+
+func (p *parser) parseCall(t token) (*node, error) {
+	arity, ok := 0, false
+
+	if p.defs != nil {
+		arity, ok = p.defs.Functions[t.value]
+	}
+
+	if ok == false {
+		return nil, &SyntaxError{Pos: t.pos, Msg: "unknown function " + t.String()}
+	}
+
+	// skip "("
+	p.next()
+
+	args := []func(env Env) (float64, error){}
+
+	if p.peek().typ != tokenRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			if arg.typ != TypeNumber {
+				return nil, &SyntaxError{
+					Pos: arg.pos,
+					Msg: fmt.Sprintf("function %s expects number arguments", t.String()),
+				}
+			}
+
+			args = append(args, arg.num)
+
+			if p.peek().typ != tokenComma {
+				break
+			}
+
+			p.next()
+		}
+	}
+
+	if closing := p.next(); closing.typ != tokenRParen {
+		return nil, &SyntaxError{Pos: closing.pos, Msg: "expected \")\", got " + closing.String()}
+	}
+
+	if len(args) != arity {
+		return nil, &SyntaxError{
+			Pos: t.pos,
+			Msg: fmt.Sprintf("function %s expects %d arguments, got %d", t.String(), arity, len(args)),
+		}
+	}
+
+	name := t.value
+
+	return &node{
+		typ: TypeNumber,
+		pos: t.pos,
+		num: func(env Env) (float64, error) {
+			values := make([]float64, len(args))
+
+			for i, arg := range args {
+				v, err := arg(env)
+				if err != nil {
+					return 0, err
+				}
+
+				values[i] = v
+			}
+
+			return env.Call(name, values...)
+		},
+	}, nil
+}
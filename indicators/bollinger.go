@@ -0,0 +1,56 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+import (
+	"math"
+)
+
+// StdDev returns the population standard deviation of values over size elements.
+// The result is aligned on the end of values and contains len(values)-size+1 elements.
+func StdDev(values []float64, size int) []float64 {
+	means := SMA(values, size)
+
+	result := make([]float64, 0, len(means))
+
+	for i, mean := range means {
+		sum := float64(0)
+
+		for _, v := range values[i : i+size] {
+			sum += (v - mean) * (v - mean)
+		}
+
+		result = append(result, math.Sqrt(sum/float64(size)))
+	}
+
+	return result
+}
+
+// Bands struct
+type Bands struct {
+	Upper  []float64
+	Middle []float64
+	Lower  []float64
+}
+
+// Bollinger returns the Bollinger Bands of values over size elements,
+// upper and lower bands are width standard deviations away from the middle band.
+func Bollinger(values []float64, size int, width float64) *Bands {
+	middle := SMA(values, size)
+	deviations := StdDev(values, size)
+
+	bands := &Bands{
+		Upper:  make([]float64, 0, len(middle)),
+		Middle: middle,
+		Lower:  make([]float64, 0, len(middle)),
+	}
+
+	for i, m := range middle {
+		bands.Upper = append(bands.Upper, m+width*deviations[i])
+		bands.Lower = append(bands.Lower, m-width*deviations[i])
+	}
+
+	return bands
+}
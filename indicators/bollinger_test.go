@@ -0,0 +1,34 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdDev(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	assert.Equal(t, []float64{2}, StdDev(values, 8))
+	assert.Equal(t, []float64{}, StdDev(values, 9))
+}
+
+func TestBollinger(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	bands := Bollinger(values, 8, 2)
+
+	assert.Equal(t, []float64{9}, bands.Upper)
+	assert.Equal(t, []float64{5}, bands.Middle)
+	assert.Equal(t, []float64{1}, bands.Lower)
+
+	bands = Bollinger(values, 9, 2)
+
+	assert.Equal(t, 0, len(bands.Upper))
+	assert.Equal(t, 0, len(bands.Middle))
+	assert.Equal(t, 0, len(bands.Lower))
+}
@@ -0,0 +1,36 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+// EMA returns the exponential moving average of values over size elements.
+// The first element is seeded with the SMA of the first size values,
+// the result contains len(values)-size+1 elements.
+func EMA(values []float64, size int) []float64 {
+	if size <= 0 || len(values) < size {
+		return []float64{}
+	}
+
+	result := make([]float64, 0, len(values)-size+1)
+
+	k := 2 / float64(size+1)
+
+	sum := float64(0)
+
+	for _, v := range values[:size] {
+		sum += v
+	}
+
+	ema := sum / float64(size)
+
+	result = append(result, ema)
+
+	for _, v := range values[size:] {
+		ema = (v-ema)*k + ema
+
+		result = append(result, ema)
+	}
+
+	return result
+}
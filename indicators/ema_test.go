@@ -0,0 +1,27 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEMA(t *testing.T) {
+	values := []float64{2, 4, 6, 8, 12, 14}
+
+	assert.Equal(t, []float64{4, 6, 9, 11.5}, EMA(values, 3))
+	assert.Equal(t, []float64{}, EMA(values, 7))
+	assert.Equal(t, []float64{}, EMA(values, 0))
+}
+
+func TestMovingAverage(t *testing.T) {
+	values := []float64{2, 4, 6, 8, 12, 14}
+
+	assert.Equal(t, SMA(values, 3), MovingAverage(MovingAverageTypeSMA, values, 3))
+	assert.Equal(t, EMA(values, 3), MovingAverage(MovingAverageTypeEMA, values, 3))
+	assert.Equal(t, SMA(values, 3), MovingAverage("", values, 3))
+}
@@ -0,0 +1,25 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package indicators provides technical analysis indicators computed on series of values.
+package indicators
+
+// MovingAverageType type
+type MovingAverageType string
+
+// MovingAverageType enum
+const (
+	MovingAverageTypeSMA MovingAverageType = "sma"
+	MovingAverageTypeEMA MovingAverageType = "ema"
+)
+
+// MovingAverage returns the moving average of values by type, SMA is used by default.
+func MovingAverage(t MovingAverageType, values []float64, size int) []float64 {
+	switch t {
+	case MovingAverageTypeEMA:
+		return EMA(values, size)
+	default:
+		return SMA(values, size)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+// RSI returns the Relative Strength Index of values over size elements with the Wilder smoothing.
+// The result is aligned on the end of values and contains len(values)-size elements.
+func RSI(values []float64, size int) []float64 {
+	if size <= 0 || len(values) <= size {
+		return []float64{}
+	}
+
+	result := make([]float64, 0, len(values)-size)
+
+	gain := float64(0)
+	loss := float64(0)
+
+	for i := 1; i <= size; i++ {
+		if change := values[i] - values[i-1]; change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+
+	gain /= float64(size)
+	loss /= float64(size)
+
+	result = append(result, rsi(gain, loss))
+
+	for i := size + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+
+		g, l := float64(0), float64(0)
+
+		if change > 0 {
+			g = change
+		} else {
+			l = -change
+		}
+
+		gain = (gain*float64(size-1) + g) / float64(size)
+		loss = (loss*float64(size-1) + l) / float64(size)
+
+		result = append(result, rsi(gain, loss))
+	}
+
+	return result
+}
+
+func rsi(gain float64, loss float64) float64 {
+	if loss == 0 {
+		return 100
+	}
+
+	return 100 - 100/(1+gain/loss)
+}
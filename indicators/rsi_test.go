@@ -0,0 +1,24 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSI(t *testing.T) {
+	values := []float64{10, 12, 11, 13, 13}
+
+	result := RSI(values, 2)
+
+	// average gain 2/2, average loss 1/2 on the first window
+	assert.Equal(t, 3, len(result))
+	assert.InDelta(t, 66.666666, result[0], 0.000001)
+	assert.Equal(t, []float64{}, RSI(values, 5))
+	assert.Equal(t, []float64{}, RSI(values, 0))
+	assert.Equal(t, []float64{100}, RSI([]float64{1, 2, 3}, 2))
+}
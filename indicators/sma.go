@@ -0,0 +1,31 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+// SMA returns the simple moving average of values over size elements.
+// The result is aligned on the end of values and contains len(values)-size+1 elements.
+func SMA(values []float64, size int) []float64 {
+	if size <= 0 || len(values) < size {
+		return []float64{}
+	}
+
+	result := make([]float64, 0, len(values)-size+1)
+
+	sum := float64(0)
+
+	for i, v := range values {
+		sum += v
+
+		if i >= size {
+			sum -= values[i-size]
+		}
+
+		if i >= size-1 {
+			result = append(result, sum/float64(size))
+		}
+	}
+
+	return result
+}
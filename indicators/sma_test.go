@@ -0,0 +1,32 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMA(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6}
+
+	assert.Equal(t, []float64{2, 3, 4, 5}, SMA(values, 3))
+	assert.Equal(t, []float64{3.5}, SMA(values, 6))
+	assert.Equal(t, []float64{}, SMA(values, 7))
+	assert.Equal(t, []float64{}, SMA(values, 0))
+}
+
+func BenchmarkSMA(b *testing.B) {
+	values := make([]float64, 5000)
+
+	for i := range values {
+		values[i] = float64(i)
+	}
+
+	for n := 0; n < b.N; n++ {
+		SMA(values, 50)
+	}
+}
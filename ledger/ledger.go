@@ -0,0 +1,266 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package ledger computes the realized and unrealized profit and loss of the campaigns.
+package ledger
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.New(100, 0)
+
+// PriceSource returns the last prices of the products followed on provider
+type PriceSource interface {
+	LastPrices(provider string) map[string]float64
+}
+
+// CampaignSource returns the campaigns traded with their buy order resolved
+type CampaignSource interface {
+	Campaigns() []*entity.Campaign
+}
+
+// Total struct of the profit and loss in a quote currency
+type Total struct {
+	Count      int             `json:"count"`
+	Wins       int             `json:"wins"`
+	Cost       decimal.Decimal `json:"cost"`
+	Fees       decimal.Decimal `json:"fees"`
+	PnL        decimal.Decimal `json:"pnl"`
+	PnLPercent decimal.Decimal `json:"pnl_percent"`
+}
+
+// Position struct of an open position valued at current price
+type Position struct {
+	CampaignID int             `json:"campaign_id"`
+	Provider   string          `json:"provider"`
+	ProductID  string          `json:"product_id"`
+	Quote      string          `json:"quote"`
+	Size       decimal.Decimal `json:"size"`
+	Cost       decimal.Decimal `json:"cost"`
+	Price      decimal.Decimal `json:"price"`
+	Value      decimal.Decimal `json:"value"`
+	PnL        decimal.Decimal `json:"pnl"`
+	PnLPercent decimal.Decimal `json:"pnl_percent"`
+	// Priced is false when the current price of the product is unknown
+	Priced bool `json:"priced"`
+}
+
+// Report struct
+type Report struct {
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	RoundTrips []*entity.RoundTrip `json:"round_trips"`
+	Realized   map[string]*Total   `json:"realized"`
+	Positions  []*Position         `json:"positions"`
+	Unrealized map[string]*Total   `json:"unrealized"`
+}
+
+func quoteCurrency(productID string) string {
+	return strings.ToUpper(exchanges.NewProductFromString(productID).To)
+}
+
+func percent(pnl decimal.Decimal, cost decimal.Decimal) decimal.Decimal {
+	if cost.IsZero() {
+		return decimal.Zero
+	}
+
+	return pnl.Mul(hundred).Div(cost)
+}
+
+// NewRoundTrip from the buy and sell orders of campaign, the fees of both orders are deducted
+func NewRoundTrip(campaign *entity.Campaign, buy *entity.Order, sell *entity.Order, closedAt time.Time) *entity.RoundTrip {
+	fees := buy.Fee.Add(sell.Fee)
+	pnl := sell.Price.Sub(buy.Price).Sub(fees)
+
+	return &entity.RoundTrip{
+		CampaignID: campaign.ID,
+		Provider:   campaign.Provider,
+		ProductID:  campaign.ProductID,
+		Quote:      quoteCurrency(campaign.ProductID),
+		BuyOrder:   buy,
+		SellOrder:  sell,
+		Fees:       fees,
+		PnL:        pnl,
+		PnLPercent: percent(pnl, buy.GetCost()),
+		ClosedAt:   closedAt.Unix(),
+	}
+}
+
+// Realized totals of round trips by quote currency
+func Realized(roundTrips []*entity.RoundTrip) map[string]*Total {
+	totals := map[string]*Total{}
+
+	for _, roundTrip := range roundTrips {
+		total, ok := totals[roundTrip.Quote]
+		if ok == false {
+			total = &Total{}
+			totals[roundTrip.Quote] = total
+		}
+
+		total.Count++
+		total.Cost = total.Cost.Add(roundTrip.BuyOrder.GetCost())
+		total.Fees = total.Fees.Add(roundTrip.Fees)
+		total.PnL = total.PnL.Add(roundTrip.PnL)
+
+		if roundTrip.PnL.IsPositive() {
+			total.Wins++
+		}
+	}
+
+	for _, total := range totals {
+		total.PnLPercent = percent(total.PnL, total.Cost)
+	}
+
+	return totals
+}
+
+// Unrealized profit and loss of the open positions of campaigns, net of the estimated exit fee
+func Unrealized(campaigns []*entity.Campaign, prices PriceSource) []*Position {
+	positions := []*Position{}
+	cache := map[string]map[string]float64{}
+
+	for _, campaign := range campaigns {
+		state := campaign.PositionState()
+
+		if campaign.BuyOrder == nil || (state != entity.CampaignStateSell && state != entity.CampaignStateSelling) {
+			continue
+		}
+
+		if _, ok := cache[campaign.Provider]; ok == false {
+			cache[campaign.Provider] = prices.LastPrices(campaign.Provider)
+		}
+
+		position := &Position{
+			CampaignID: campaign.ID,
+			Provider:   campaign.Provider,
+			ProductID:  campaign.ProductID,
+			Quote:      quoteCurrency(campaign.ProductID),
+			Size:       campaign.BuyOrder.Size,
+			Cost:       campaign.BuyOrder.GetCost(),
+		}
+
+		if last, ok := cache[campaign.Provider][campaign.ProductID]; ok {
+			price := decimal.NewFromFloat(last)
+
+			position.Price = price
+			position.Value = position.Size.Mul(price)
+			position.PnL = campaign.BuyOrder.GetMarginInCurrency(price)
+			position.PnLPercent = percent(position.PnL, position.Cost)
+			position.Priced = true
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions
+}
+
+// UnrealizedTotals of the priced positions by quote currency
+func UnrealizedTotals(positions []*Position) map[string]*Total {
+	totals := map[string]*Total{}
+
+	for _, position := range positions {
+		if position.Priced == false {
+			continue
+		}
+
+		total, ok := totals[position.Quote]
+		if ok == false {
+			total = &Total{}
+			totals[position.Quote] = total
+		}
+
+		total.Count++
+		total.Cost = total.Cost.Add(position.Cost)
+		total.PnL = total.PnL.Add(position.PnL)
+
+		if position.PnL.IsPositive() {
+			total.Wins++
+		}
+	}
+
+	for _, total := range totals {
+		total.PnLPercent = percent(total.PnL, total.Cost)
+	}
+
+	return totals
+}
+
+// Service struct
+type Service struct {
+	db        *storm.DB
+	prices    PriceSource
+	campaigns CampaignSource
+}
+
+// NewService ledger, the open positions are read from the campaigns of source
+func NewService(db *storm.DB, prices PriceSource, campaigns CampaignSource) *Service {
+	return &Service{
+		db:        db,
+		prices:    prices,
+		campaigns: campaigns,
+	}
+}
+
+// Report of the round trips closed between from and to and of the open positions,
+// the campaign filter is ignored when campaignID is 0.
+func (s *Service) Report(from time.Time, to time.Time, campaignID int) (*Report, error) {
+	matchers := []q.Matcher{
+		q.Gte("ClosedAt", from.Unix()),
+		q.Lte("ClosedAt", to.Unix()),
+	}
+
+	if campaignID > 0 {
+		matchers = append(matchers, q.Eq("CampaignID", campaignID))
+	}
+
+	roundTrips := []*entity.RoundTrip{}
+
+	if err := s.db.Select(matchers...).OrderBy("ClosedAt").Find(&roundTrips); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+
+	// the stored campaigns reference their buy order, the source holds it
+	campaigns := s.campaigns.Campaigns()
+
+	if campaignID > 0 {
+		found := []*entity.Campaign{}
+
+		for _, campaign := range campaigns {
+			if campaign.ID == campaignID {
+				found = append(found, campaign)
+			}
+		}
+
+		if len(found) == 0 {
+			return nil, storm.ErrNotFound
+		}
+
+		campaigns = found
+	}
+
+	positions := Unrealized(campaigns, s.prices)
+
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].CampaignID < positions[j].CampaignID
+	})
+
+	return &Report{
+		From:       from,
+		To:         to,
+		RoundTrips: roundTrips,
+		Realized:   Realized(roundTrips),
+		Positions:  positions,
+		Unrealized: UnrealizedTotals(positions),
+	}, nil
+}
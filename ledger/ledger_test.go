@@ -0,0 +1,186 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package ledger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type pricesMock map[string]map[string]float64
+
+type campaignsMock []*entity.Campaign
+
+func (c campaignsMock) Campaigns() []*entity.Campaign {
+	return c
+}
+
+func (p pricesMock) LastPrices(provider string) map[string]float64 {
+	return p[provider]
+}
+
+func newRoundTrip(buy float64, sell float64, fee float64) *entity.RoundTrip {
+	campaign := &entity.Campaign{
+		ID:        1,
+		Provider:  "gdax",
+		ProductID: "BTC-EUR",
+	}
+
+	return NewRoundTrip(campaign, &entity.Order{
+		Side:  exchanges.SideTypeBuy,
+		Size:  decimal.RequireFromString("0.1"),
+		Price: decimal.NewFromFloat(buy),
+		Fee:   decimal.NewFromFloat(fee),
+	}, &entity.Order{
+		Side:  exchanges.SideTypeSell,
+		Size:  decimal.RequireFromString("0.1"),
+		Price: decimal.NewFromFloat(sell),
+		Fee:   decimal.NewFromFloat(fee),
+	}, time.Unix(1512345600, 0))
+}
+
+func assertTotal(t *testing.T, count int, wins int, cost string, pnl string, pnlPercent string, total *Total) {
+	if assert.NotNil(t, total) {
+		assert.Equal(t, count, total.Count)
+		assert.Equal(t, wins, total.Wins)
+		assert.Equal(t, cost, total.Cost.String())
+		assert.Equal(t, pnl, total.PnL.String())
+		assert.Equal(t, pnlPercent, total.PnLPercent.String())
+	}
+}
+
+func TestNewRoundTrip(t *testing.T) {
+	roundTrip := newRoundTrip(1000, 1100, 2.5)
+
+	assert.Equal(t, 1, roundTrip.CampaignID)
+	assert.Equal(t, "EUR", roundTrip.Quote)
+	assert.Equal(t, "5", roundTrip.Fees.String())
+	assert.Equal(t, "95", roundTrip.PnL.String())
+	assert.Equal(t, "9.4763092269326683", roundTrip.PnLPercent.String())
+	assert.Equal(t, int64(1512345600), roundTrip.ClosedAt)
+}
+
+func TestRealized(t *testing.T) {
+	totals := Realized([]*entity.RoundTrip{
+		newRoundTrip(1000, 1100, 0),
+		newRoundTrip(1000, 950, 0),
+	})
+
+	assertTotal(t, 2, 1, "2000", "50", "2.5", totals["EUR"])
+}
+
+func TestUnrealized(t *testing.T) {
+	campaigns := []*entity.Campaign{
+		{
+			ID:        1,
+			Provider:  "gdax",
+			ProductID: "BTC-EUR",
+			State:     entity.CampaignStateSell,
+			BuyOrder: &entity.Order{
+				Size:  decimal.RequireFromString("0.1"),
+				Price: decimal.New(1000, 0),
+			},
+		},
+		{
+			ID:        2,
+			Provider:  "gdax",
+			ProductID: "BTC-EUR",
+			State:     entity.CampaignStateBuy,
+			BuyOrder: &entity.Order{
+				Size:  decimal.RequireFromString("0.1"),
+				Price: decimal.New(1000, 0),
+			},
+		},
+		{
+			ID:        3,
+			Provider:  "gdax",
+			ProductID: "ETH-EUR",
+			State:     entity.CampaignStateSell,
+			BuyOrder: &entity.Order{
+				Size:  decimal.New(1, 0),
+				Price: decimal.New(500, 0),
+			},
+		},
+	}
+
+	positions := Unrealized(campaigns, pricesMock{
+		"gdax": {
+			"BTC-EUR": 12000,
+		},
+	})
+
+	assert.Equal(t, 2, len(positions))
+
+	assert.Equal(t, 1, positions[0].CampaignID)
+	assert.True(t, positions[0].Priced)
+	assert.Equal(t, "1200", positions[0].Value.String())
+	assert.Equal(t, "200", positions[0].PnL.String())
+	assert.Equal(t, "20", positions[0].PnLPercent.String())
+
+	assert.Equal(t, 3, positions[1].CampaignID)
+	assert.False(t, positions[1].Priced)
+
+	totals := UnrealizedTotals(positions)
+
+	assertTotal(t, 1, 1, "1000", "200", "20", totals["EUR"])
+}
+
+func TestServiceReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ledger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := storm.Open(filepath.Join(dir, "ledger.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// the stored campaign references its buy order which is held by the source
+	service := NewService(db, pricesMock{
+		"gdax": {
+			"BTC-EUR": 12000,
+		},
+	}, campaignsMock{
+		{
+			ID:         1,
+			Provider:   "gdax",
+			ProductID:  "BTC-EUR",
+			State:      entity.CampaignStateSell,
+			BuyOrderID: 1,
+			BuyOrder: &entity.Order{
+				ID:    1,
+				Size:  decimal.RequireFromString("0.1"),
+				Price: decimal.New(1000, 0),
+			},
+		},
+		{
+			ID:        2,
+			Provider:  "gdax",
+			ProductID: "BTC-EUR",
+			State:     entity.CampaignStateBuy,
+		},
+	})
+
+	report, err := service.Report(time.Now().Add(-time.Hour), time.Now(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(report.Positions))
+	assert.Equal(t, "200", report.Positions[0].PnL.String())
+	assertTotal(t, 1, 1, "1000", "200", "20", report.Unrealized["EUR"])
+
+	report, err = service.Report(time.Now().Add(-time.Hour), time.Now(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(report.Positions))
+
+	_, err = service.Report(time.Now().Add(-time.Hour), time.Now(), 3)
+	assert.Equal(t, storm.ErrNotFound, err)
+}
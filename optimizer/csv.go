@@ -0,0 +1,66 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package optimizer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// WriteCSV writes the evaluations of result as a table, one column per option
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+
+	options := []string{}
+
+	if len(result.Evaluations) > 0 {
+		for key := range result.Evaluations[0].Options {
+			options = append(options, key)
+		}
+	}
+
+	sort.Strings(options)
+
+	header := append([]string{"rank"}, options...)
+	header = append(header, "score", "profit", "return_percent", "trades", "win_rate", "max_drawdown", "open_profit")
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, evaluation := range result.Evaluations {
+		record := []string{strconv.Itoa(evaluation.Rank)}
+
+		for _, key := range options {
+			record = append(record, fmt.Sprintf("%v", evaluation.Options[key]))
+		}
+
+		record = append(
+			record,
+			formatFloat(evaluation.Score),
+			formatFloat(evaluation.Metrics.Profit),
+			formatFloat(evaluation.Metrics.ReturnPercent),
+			strconv.Itoa(evaluation.Metrics.Trades),
+			formatFloat(evaluation.Metrics.WinRate),
+			formatFloat(evaluation.Metrics.MaxDrawdown),
+			formatFloat(evaluation.Metrics.OpenProfit),
+		)
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,389 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package optimizer searches the algorithm options maximizing a backtest metric.
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/euskadi31/cryptotrader/backtest"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+)
+
+// Method of search
+type Method string
+
+// Method enum
+const (
+	MethodGrid   Method = "grid"
+	MethodRandom Method = "random"
+)
+
+// Metric used to rank the results
+type Metric string
+
+// Metric enum
+const (
+	MetricProfit      Metric = "profit"
+	MetricReturn      Metric = "return"
+	MetricWinRate     Metric = "win_rate"
+	MetricMaxDrawdown Metric = "max_drawdown"
+)
+
+// MaxCombinations evaluated by an optimization
+const MaxCombinations = 10000
+
+// Errors
+var (
+	ErrNoRanges            = errors.New("optimizer without ranges")
+	ErrInvalidRange        = errors.New("optimizer range invalid")
+	ErrInvalidSplit        = errors.New("not enough ticks for walk-forward windows")
+	ErrInvalidSamples      = errors.New("optimizer random method requires positive samples")
+	ErrNoCombinations      = errors.New("optimizer without combinations")
+	ErrTooManyCombinations = fmt.Errorf("optimizer combinations exceed the limit of %d", MaxCombinations)
+)
+
+// Range of values of an algorithm option, Values is used instead of From/To/Step when set
+type Range struct {
+	Option string        `json:"option"`
+	From   float64       `json:"from"`
+	To     float64       `json:"to"`
+	Step   float64       `json:"step"`
+	Values []interface{} `json:"values,omitempty"`
+}
+
+func (r Range) values() ([]interface{}, error) {
+	if r.Option == "" {
+		return nil, ErrInvalidRange
+	}
+
+	if len(r.Values) > 0 {
+		return r.Values, nil
+	}
+
+	if r.Step <= 0 || r.To < r.From {
+		return nil, ErrInvalidRange
+	}
+
+	values := []interface{}{}
+
+	steps := int(math.Floor((r.To-r.From)/r.Step + 1e-9))
+
+	for i := 0; i <= steps; i++ {
+		values = append(values, r.From+float64(i)*r.Step)
+	}
+
+	return values, nil
+}
+
+// Request struct
+type Request struct {
+	Campaign *entity.Campaign `json:"campaign"`
+	Ranges   []*Range         `json:"ranges"`
+	Method   Method           `json:"method"`
+	Samples  int              `json:"samples"`
+	Metric   Metric           `json:"metric"`
+	Workers  int              `json:"workers"`
+	Seed     int64            `json:"seed"`
+
+	// WalkForward is the number of windows, each window is optimized and tested on the next one
+	WalkForward int `json:"walk_forward"`
+}
+
+// Evaluation of a set of options
+type Evaluation struct {
+	Rank    int                    `json:"rank"`
+	Options map[string]interface{} `json:"options"`
+	Score   float64                `json:"score"`
+	Metrics *backtest.Metrics      `json:"metrics"`
+}
+
+// Fold of walk-forward validation
+type Fold struct {
+	Window      int         `json:"window"`
+	InSample    *Evaluation `json:"in_sample"`
+	OutOfSample *Evaluation `json:"out_of_sample"`
+}
+
+// Result struct
+type Result struct {
+	Metric      Metric        `json:"metric"`
+	Evaluations []*Evaluation `json:"evaluations"`
+	WalkForward []*Fold       `json:"walk_forward,omitempty"`
+}
+
+// Optimizer struct
+type Optimizer struct {
+	backtest *backtest.Backtest
+}
+
+// New Optimizer
+func New(bt *backtest.Backtest) *Optimizer {
+	return &Optimizer{
+		backtest: bt,
+	}
+}
+
+// score of metrics, higher is better
+func score(metric Metric, m *backtest.Metrics) float64 {
+	switch metric {
+	case MetricReturn:
+		return m.ReturnPercent
+	case MetricWinRate:
+		return m.WinRate
+	case MetricMaxDrawdown:
+		return -m.MaxDrawdown
+	default:
+		return m.Profit
+	}
+}
+
+// combinations of the ranges, all of them for grid method or samples of them for random method
+func (o Optimizer) combinations(req *Request) ([]map[string]interface{}, error) {
+	if len(req.Ranges) == 0 {
+		return nil, ErrNoRanges
+	}
+
+	values := make([][]interface{}, len(req.Ranges))
+
+	// the size of the grid is checked before building it
+	size := 1
+
+	for i, r := range req.Ranges {
+		v, err := r.values()
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = v
+
+		if size *= len(v); size > MaxCombinations && req.Method != MethodRandom {
+			return nil, ErrTooManyCombinations
+		}
+	}
+
+	if req.Method == MethodRandom {
+		if req.Samples <= 0 {
+			return nil, ErrInvalidSamples
+		}
+
+		if req.Samples > MaxCombinations {
+			return nil, ErrTooManyCombinations
+		}
+
+		rnd := rand.New(rand.NewSource(req.Seed))
+
+		combinations := make([]map[string]interface{}, 0, req.Samples)
+
+		for n := 0; n < req.Samples; n++ {
+			combination := map[string]interface{}{}
+
+			for i, r := range req.Ranges {
+				combination[r.Option] = values[i][rnd.Intn(len(values[i]))]
+			}
+
+			combinations = append(combinations, combination)
+		}
+
+		return combinations, nil
+	}
+
+	combinations := []map[string]interface{}{{}}
+
+	for i, r := range req.Ranges {
+		next := make([]map[string]interface{}, 0, len(combinations)*len(values[i]))
+
+		for _, combination := range combinations {
+			for _, v := range values[i] {
+				c := map[string]interface{}{}
+
+				for key, val := range combination {
+					c[key] = val
+				}
+
+				c[r.Option] = v
+
+				next = append(next, c)
+			}
+		}
+
+		combinations = next
+	}
+
+	if len(combinations) == 0 {
+		return nil, ErrNoCombinations
+	}
+
+	return combinations, nil
+}
+
+// campaign with options applied on buy and sell algorithm options
+func campaignWithOptions(c *entity.Campaign, options map[string]interface{}) *entity.Campaign {
+	campaign := *c
+
+	campaign.BuyAlgorithmOptions = map[string]interface{}{}
+	campaign.SellAlgorithmOptions = map[string]interface{}{}
+
+	for key, val := range c.BuyAlgorithmOptions {
+		campaign.BuyAlgorithmOptions[key] = val
+	}
+
+	for key, val := range c.SellAlgorithmOptions {
+		campaign.SellAlgorithmOptions[key] = val
+	}
+
+	for key, val := range options {
+		campaign.BuyAlgorithmOptions[key] = val
+		campaign.SellAlgorithmOptions[key] = val
+	}
+
+	return &campaign
+}
+
+// evaluate combinations in parallel on ticks and rank them by metric
+func (o Optimizer) evaluate(req *Request, combinations []map[string]interface{}, ticks []*exchanges.TickerEvent) ([]*Evaluation, error) {
+	workers := req.Workers
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	evaluations := make([]*Evaluation, len(combinations))
+	errs := make([]error, len(combinations))
+
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				result, err := o.backtest.Run(campaignWithOptions(req.Campaign, combinations[i]), ticks)
+				if err != nil {
+					errs[i] = err
+
+					continue
+				}
+
+				evaluations[i] = &Evaluation{
+					Options: combinations[i],
+					Score:   score(req.Metric, result.Metrics),
+					Metrics: result.Metrics,
+				}
+			}
+		}()
+	}
+
+	for i := range combinations {
+		jobs <- i
+	}
+
+	close(jobs)
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		return evaluations[i].Score > evaluations[j].Score
+	})
+
+	for i, evaluation := range evaluations {
+		evaluation.Rank = i + 1
+	}
+
+	return evaluations, nil
+}
+
+// Run the optimization of request on ticks
+func (o Optimizer) Run(req *Request, ticks []*exchanges.TickerEvent) (*Result, error) {
+	if req.Metric == "" {
+		req.Metric = MetricProfit
+	}
+
+	combinations, err := o.combinations(req)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluations, err := o.evaluate(req, combinations, ticks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Metric:      req.Metric,
+		Evaluations: evaluations,
+	}
+
+	if req.WalkForward > 0 {
+		if result.WalkForward, err = o.walkForward(req, combinations, ticks); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// walkForward splits ticks in consecutive windows, optimizes each window and tests the best options on the next one
+func (o Optimizer) walkForward(req *Request, combinations []map[string]interface{}, ticks []*exchanges.TickerEvent) ([]*Fold, error) {
+	windows := req.WalkForward + 1
+	size := len(ticks) / windows
+
+	if size == 0 {
+		return nil, ErrInvalidSplit
+	}
+
+	folds := []*Fold{}
+
+	for i := 0; i < req.WalkForward; i++ {
+		train := ticks[i*size : (i+1)*size]
+		test := ticks[(i+1)*size : (i+2)*size]
+
+		evaluations, err := o.evaluate(req, combinations, train)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(evaluations) == 0 {
+			return nil, ErrNoCombinations
+		}
+
+		best := evaluations[0]
+
+		result, err := o.backtest.Run(campaignWithOptions(req.Campaign, best.Options), test)
+		if err != nil {
+			return nil, err
+		}
+
+		folds = append(folds, &Fold{
+			Window:   i + 1,
+			InSample: best,
+			OutOfSample: &Evaluation{
+				Rank:    1,
+				Options: best.Options,
+				Score:   score(req.Metric, result.Metrics),
+				Metrics: result.Metrics,
+			},
+		})
+	}
+
+	return folds, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package optimizer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/backtest"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTicks(prices ...float64) []*exchanges.TickerEvent {
+	ticks := []*exchanges.TickerEvent{}
+
+	for i, price := range prices {
+		ticks = append(ticks, &exchanges.TickerEvent{
+			Product: exchanges.NewProduct("BTC", "EUR"),
+			Price:   decimal.NewFromFloat(price),
+			Size:    decimal.New(1, 0),
+			Time:    time.Unix(int64(i), 0),
+		})
+	}
+
+	return ticks
+}
+
+func newOptimizer() *Optimizer {
+	m := algorithms.NewManager()
+
+	m.Add(algorithms.NewMACross())
+
+	return New(backtest.New(m))
+}
+
+func newRequest() *Request {
+	options := map[string]interface{}{
+		algorithms.MACrossCandlePeriod:  1,
+		algorithms.MACrossMinSeparation: 0.0,
+	}
+
+	return &Request{
+		Campaign: &entity.Campaign{
+			Volume:               decimal.New(1, 0),
+			BuyAlgorithm:         "ma_cross",
+			BuyAlgorithmOptions:  options,
+			SellAlgorithm:        "ma_cross",
+			SellAlgorithmOptions: options,
+		},
+		Ranges: []*Range{
+			{Option: algorithms.MACrossFastSize, From: 1, To: 2, Step: 1},
+			{Option: algorithms.MACrossSlowSize, From: 3, To: 5, Step: 2},
+		},
+		Workers: 2,
+	}
+}
+
+var prices = []float64{10, 9, 8, 7, 6, 12, 13, 14, 15, 16, 12, 8, 4, 3, 2, 3, 4, 8, 12, 14, 15, 9, 5, 4}
+
+func TestRangeValues(t *testing.T) {
+	values, err := Range{Option: "trend.selling.long_trend_size", From: 50, To: 300, Step: 25}.values()
+	assert.NoError(t, err)
+	assert.Equal(t, 11, len(values))
+	assert.Equal(t, float64(300), values[10])
+
+	values, err = Range{Option: "ma_cross.type", Values: []interface{}{"sma", "ema"}}.values()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"sma", "ema"}, values)
+
+	_, err = Range{Option: "foo", From: 2, To: 1, Step: 1}.values()
+	assert.Equal(t, ErrInvalidRange, err)
+
+	_, err = Range{Option: "foo", From: 1, To: 2}.values()
+	assert.Equal(t, ErrInvalidRange, err)
+
+	_, err = Range{Values: []interface{}{"sma", "ema"}}.values()
+	assert.Equal(t, ErrInvalidRange, err)
+}
+
+func TestOptimizerGrid(t *testing.T) {
+	o := newOptimizer()
+
+	result, err := o.Run(newRequest(), newTicks(prices...))
+	assert.NoError(t, err)
+
+	assert.Equal(t, MetricProfit, result.Metric)
+	assert.Equal(t, 4, len(result.Evaluations))
+
+	for i, evaluation := range result.Evaluations {
+		assert.Equal(t, i+1, evaluation.Rank)
+
+		if i > 0 {
+			assert.True(t, result.Evaluations[i-1].Score >= evaluation.Score)
+		}
+	}
+}
+
+func TestOptimizerRandom(t *testing.T) {
+	o := newOptimizer()
+
+	req := newRequest()
+	req.Method = MethodRandom
+	req.Samples = 3
+	req.Metric = MetricWinRate
+
+	result, err := o.Run(req, newTicks(prices...))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, len(result.Evaluations))
+}
+
+func TestOptimizerWalkForward(t *testing.T) {
+	o := newOptimizer()
+
+	req := newRequest()
+	req.WalkForward = 2
+
+	result, err := o.Run(req, newTicks(prices...))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(result.WalkForward))
+	assert.Equal(t, 1, result.WalkForward[0].Window)
+	assert.Equal(t, result.WalkForward[0].InSample.Options, result.WalkForward[0].OutOfSample.Options)
+
+	req.WalkForward = 30
+
+	_, err = o.Run(req, newTicks(prices...))
+	assert.Equal(t, ErrInvalidSplit, err)
+}
+
+func TestOptimizerErrors(t *testing.T) {
+	o := newOptimizer()
+
+	req := newRequest()
+	req.Ranges = nil
+
+	_, err := o.Run(req, newTicks(prices...))
+	assert.Equal(t, ErrNoRanges, err)
+
+	_, err = o.Run(newRequest(), nil)
+	assert.Equal(t, backtest.ErrNoTicks, err)
+
+	req = newRequest()
+	req.Method = MethodRandom
+	req.Samples = 0
+	req.WalkForward = 2
+
+	_, err = o.Run(req, newTicks(prices...))
+	assert.Equal(t, ErrInvalidSamples, err)
+
+	req.Samples = MaxCombinations + 1
+
+	_, err = o.Run(req, newTicks(prices...))
+	assert.Equal(t, ErrTooManyCombinations, err)
+
+	req = newRequest()
+	req.Ranges = []*Range{
+		{Option: "a", From: 1, To: 1000, Step: 1},
+		{Option: "b", From: 1, To: 1000, Step: 1},
+	}
+
+	_, err = o.Run(req, newTicks(prices...))
+	assert.Equal(t, ErrTooManyCombinations, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	err := WriteCSV(buf, &Result{
+		Evaluations: []*Evaluation{
+			{
+				Rank: 1,
+				Options: map[string]interface{}{
+					"b": 2.0,
+					"a": "sma",
+				},
+				Score: 10.5,
+				Metrics: &backtest.Metrics{
+					Profit: 10.5,
+					Trades: 2,
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, strings.Join([]string{
+		"rank,a,b,score,profit,return_percent,trades,win_rate,max_drawdown,open_profit",
+		"1,sma,2,10.5,10.5,0,2,0,0,0",
+		"",
+	}, "\n"), buf.String())
+}
@@ -0,0 +1,189 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package portfolio values the balances of all exchanges in a quote currency.
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/rs/zerolog/log"
+)
+
+// PriceSource returns the last prices of the products followed on provider
+type PriceSource interface {
+	LastPrices(provider string) map[string]float64
+}
+
+// Asset struct
+type Asset struct {
+	Provider string  `json:"provider"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	Rate     float64 `json:"rate"`
+	Value    float64 `json:"value"`
+	// Priced is false when no rate is known for the currency
+	Priced bool `json:"priced"`
+}
+
+// Valuation struct
+type Valuation struct {
+	Quote  string    `json:"quote"`
+	Total  float64   `json:"total"`
+	Assets []*Asset  `json:"assets"`
+	Time   time.Time `json:"time"`
+}
+
+// Service struct
+type Service struct {
+	db        *storm.DB
+	providers exchanges.Manager
+	prices    PriceSource
+	quote     string
+	doneCh    chan bool
+}
+
+// NewService portfolio, quote is the currency of the history
+func NewService(db *storm.DB, providers exchanges.Manager, prices PriceSource, quote string) *Service {
+	return &Service{
+		db:        db,
+		providers: providers,
+		prices:    prices,
+		quote:     strings.ToUpper(quote),
+		doneCh:    make(chan bool),
+	}
+}
+
+// Quote currency of Service
+func (s Service) Quote() string {
+	return s.quote
+}
+
+// Value the balances of all providers in quote currency
+func (s *Service) Value(quote string) (*Valuation, error) {
+	quote = strings.ToUpper(quote)
+
+	names := []string{}
+
+	for name := range s.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	rates := map[string]Rates{}
+	global := Rates{}
+
+	for _, name := range names {
+		rates[name] = Rates(s.prices.LastPrices(name))
+
+		global.Merge(rates[name])
+	}
+
+	valuation := &Valuation{
+		Quote:  quote,
+		Assets: []*Asset{},
+		Time:   time.Now().UTC(),
+	}
+
+	for _, name := range names {
+		balances, err := s.providers[name].Balance().Balances()
+		if err != nil {
+			return nil, fmt.Errorf("%s balances: %v", name, err)
+		}
+
+		for _, balance := range balances {
+			if balance.Total.IsZero() {
+				continue
+			}
+
+			asset := &Asset{
+				Provider: name,
+				Currency: strings.ToUpper(balance.Currency),
+				Amount:   balance.Total.InexactFloat64(),
+			}
+
+			rate, ok := rates[name].Rate(asset.Currency, quote)
+			if ok == false {
+				rate, ok = global.Rate(asset.Currency, quote)
+			}
+
+			if ok {
+				asset.Rate = rate
+				asset.Value = asset.Amount * rate
+				asset.Priced = true
+
+				valuation.Total += asset.Value
+			}
+
+			valuation.Assets = append(valuation.Assets, asset)
+		}
+	}
+
+	return valuation, nil
+}
+
+// Snapshot records the current value of portfolio
+func (s *Service) Snapshot() (*entity.PortfolioSnapshot, error) {
+	valuation, err := s.Value(s.quote)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &entity.PortfolioSnapshot{
+		Time:   valuation.Time.Unix(),
+		Quote:  valuation.Quote,
+		Total:  valuation.Total,
+		Assets: map[string]float64{},
+	}
+
+	for _, asset := range valuation.Assets {
+		snapshot.Assets[asset.Provider+":"+asset.Currency] += asset.Value
+	}
+
+	if err := s.db.Save(snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// History of the portfolio value between from and to
+func (s *Service) History(from time.Time, to time.Time) ([]*entity.PortfolioSnapshot, error) {
+	snapshots := []*entity.PortfolioSnapshot{}
+
+	if err := s.db.Range("Time", from.Unix(), to.Unix(), &snapshots); err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// Start recording a snapshot every interval
+func (s *Service) Start(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.Snapshot(); err != nil {
+				log.Error().Err(err).Msg("Portfolio snapshot")
+			}
+		case <-s.doneCh:
+			return
+		}
+	}
+}
+
+// Stop recording
+func (s *Service) Stop() {
+	close(s.doneCh)
+}
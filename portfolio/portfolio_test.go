@@ -0,0 +1,71 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/exchanges/paper"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type pricesMock map[string]map[string]float64
+
+func (p pricesMock) LastPrices(provider string) map[string]float64 {
+	return p[provider]
+}
+
+func TestServiceValue(t *testing.T) {
+	providers := exchanges.NewManager()
+
+	providers.Add(paper.NewPaper("gdax", nil, map[string]decimal.Decimal{
+		"EUR": decimal.New(100, 0),
+		"BTC": decimal.RequireFromString("0.5"),
+		"ETH": decimal.New(2, 0),
+	}))
+
+	providers.Add(paper.NewPaper("kraken", nil, map[string]decimal.Decimal{
+		"BTC": decimal.RequireFromString("0.1"),
+		"XRP": decimal.New(1000, 0),
+	}))
+
+	s := NewService(nil, providers, pricesMock{
+		"gdax": {
+			"BTC-EUR": 14000,
+			"ETH-BTC": 0.05,
+		},
+		"kraken": {
+			"BTC-EUR": 14500,
+		},
+	}, "eur")
+
+	assert.Equal(t, "EUR", s.Quote())
+
+	valuation, err := s.Value("EUR")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "EUR", valuation.Quote)
+	assert.Equal(t, 5, len(valuation.Assets))
+
+	assets := map[string]*Asset{}
+
+	for _, asset := range valuation.Assets {
+		assets[asset.Provider+":"+asset.Currency] = asset
+	}
+
+	assert.Equal(t, float64(7000), assets["gdax:BTC"].Value)
+	assert.InDelta(t, 1400, assets["gdax:ETH"].Value, 0.000001)
+	assert.Equal(t, float64(100), assets["gdax:EUR"].Value)
+	assert.Equal(t, float64(1450), assets["kraken:BTC"].Value)
+	assert.False(t, assets["kraken:XRP"].Priced)
+
+	assert.InDelta(t, 9950, valuation.Total, 0.000001)
+
+	valuation, err = s.Value("BTC")
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5+0.1+0.1+100/14000.0, valuation.Total, 0.000001)
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package portfolio
+
+import (
+	"strings"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+)
+
+// Rates of products by name (BTC-EUR)
+type Rates map[string]float64
+
+// Merge rates into Rates without overriding existing products
+func (r Rates) Merge(rates Rates) {
+	for product, price := range rates {
+		if _, ok := r[product]; ok == false {
+			r[product] = price
+		}
+	}
+}
+
+// graph returns the conversion rates between currencies in both directions
+func (r Rates) graph() map[string]map[string]float64 {
+	graph := map[string]map[string]float64{}
+
+	add := func(from string, to string, rate float64) {
+		if _, ok := graph[from]; ok == false {
+			graph[from] = map[string]float64{}
+		}
+
+		graph[from][to] = rate
+	}
+
+	for name, price := range r {
+		if price <= 0 || strings.Count(name, "-") != 1 {
+			continue
+		}
+
+		product := exchanges.NewProductFromString(strings.ToUpper(name))
+
+		add(product.From, product.To, price)
+		add(product.To, product.From, 1/price)
+	}
+
+	return graph
+}
+
+// Rate of from currency in to currency, using the shortest chain of products
+// for the cross rates (ETH-EUR = ETH-BTC × BTC-EUR).
+func (r Rates) Rate(from string, to string) (float64, bool) {
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+
+	if from == to {
+		return 1, true
+	}
+
+	graph := r.graph()
+
+	rates := map[string]float64{
+		from: 1,
+	}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		currency := queue[0]
+		queue = queue[1:]
+
+		for next, rate := range graph[currency] {
+			if _, ok := rates[next]; ok {
+				continue
+			}
+
+			rates[next] = rates[currency] * rate
+
+			if next == to {
+				return rates[next], true
+			}
+
+			queue = append(queue, next)
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatesRate(t *testing.T) {
+	rates := Rates{
+		"ETH-BTC": 0.05,
+		"BTC-EUR": 14000,
+		"LTC-USD": 300,
+	}
+
+	rate, ok := rates.Rate("BTC", "EUR")
+	assert.True(t, ok)
+	assert.Equal(t, float64(14000), rate)
+
+	rate, ok = rates.Rate("eth", "eur")
+	assert.True(t, ok)
+	assert.InDelta(t, 700, rate, 0.000001)
+
+	rate, ok = rates.Rate("EUR", "BTC")
+	assert.True(t, ok)
+	assert.InDelta(t, 1/14000.0, rate, 0.000001)
+
+	rate, ok = rates.Rate("EUR", "EUR")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), rate)
+
+	_, ok = rates.Rate("LTC", "EUR")
+	assert.False(t, ok)
+}
+
+func TestRatesMerge(t *testing.T) {
+	rates := Rates{
+		"BTC-EUR": 14000,
+	}
+
+	rates.Merge(Rates{
+		"BTC-EUR": 13000,
+		"ETH-EUR": 700,
+	})
+
+	assert.Equal(t, Rates{
+		"BTC-EUR": 14000,
+		"ETH-EUR": 700,
+	}, rates)
+}
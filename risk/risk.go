@@ -0,0 +1,328 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package risk checks the orders against the trading limits before they are executed.
+package risk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+// Rule names
+const (
+	RuleMaxPositionSize    = "max_position_size"
+	RuleMaxExposure        = "max_exposure"
+	RuleMaxOrdersPerMinute = "max_orders_per_minute"
+	RuleMaxDailyLoss       = "max_daily_loss"
+	RuleMinBalanceReserve  = "min_balance_reserve"
+)
+
+// Error is returned when an order is rejected by a rule
+type Error struct {
+	Rule   string
+	Reason string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("risk %s: %s", e.Rule, e.Reason)
+}
+
+// Limits of the risk manager, a missing or zero limit is not checked.
+// Products and currencies keys are upper case, the position size is checked by product on each provider.
+type Limits struct {
+	MaxPositionSize    map[string]decimal.Decimal `json:"max_position_size"`
+	MaxExposure        map[string]decimal.Decimal `json:"max_exposure"`
+	MaxOrdersPerMinute int                        `json:"max_orders_per_minute"`
+	MaxDailyLoss       map[string]decimal.Decimal `json:"max_daily_loss"`
+	MinBalanceReserve  map[string]decimal.Decimal `json:"min_balance_reserve"`
+}
+
+// Balances returns the available balance of currency on provider
+type Balances interface {
+	Balance(provider string, currency string) (decimal.Decimal, error)
+}
+
+// State of the risk manager
+type State struct {
+	Positions   map[string]decimal.Decimal `json:"positions"`
+	Exposures   map[string]decimal.Decimal `json:"exposures"`
+	DailyLoss   map[string]decimal.Decimal `json:"daily_loss"`
+	OrdersCount int                        `json:"orders_last_minute"`
+}
+
+// Manager struct
+type Manager struct {
+	mu        sync.Mutex
+	limits    *Limits
+	balances  Balances
+	positions map[string]decimal.Decimal
+	exposures map[string]decimal.Decimal
+	losses    map[string]decimal.Decimal
+	day       string
+	orders    []time.Time
+	now       func() time.Time
+}
+
+// NewManager risk
+func NewManager(limits *Limits) *Manager {
+	if limits == nil {
+		limits = &Limits{}
+	}
+
+	return &Manager{
+		limits:    limits,
+		positions: make(map[string]decimal.Decimal),
+		exposures: make(map[string]decimal.Decimal),
+		losses:    make(map[string]decimal.Decimal),
+		orders:    []time.Time{},
+		now:       time.Now,
+	}
+}
+
+// SetBalances source used by the minimum balance reserve rule
+func (m *Manager) SetBalances(balances Balances) {
+	m.mu.Lock()
+	m.balances = balances
+	m.mu.Unlock()
+}
+
+// Limits of Manager
+func (m *Manager) Limits() *Limits {
+	return m.limits
+}
+
+func positionKey(order *entity.Order) string {
+	return order.Provider + "-" + strings.ToUpper(order.ProductID)
+}
+
+func quoteCurrency(order *entity.Order) string {
+	return strings.ToUpper(exchanges.NewProductFromString(order.ProductID).To)
+}
+
+// resetDay clears the daily losses at the beginning of a new UTC day
+func (m *Manager) resetDay(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+
+	if m.day != day {
+		m.day = day
+		m.losses = make(map[string]decimal.Decimal)
+	}
+}
+
+// pruneOrders removes the orders older than one minute
+func (m *Manager) pruneOrders(now time.Time) {
+	i := 0
+
+	for i < len(m.orders) && now.Sub(m.orders[i]) >= time.Minute {
+		i++
+	}
+
+	m.orders = m.orders[i:]
+}
+
+// Check order against the limits, the order price is the total value of the order and its fee is included in the cost
+func (m *Manager) Check(order *entity.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.resetDay(now)
+	m.pruneOrders(now)
+
+	if max := m.limits.MaxOrdersPerMinute; max > 0 && len(m.orders) >= max {
+		return &Error{
+			Rule:   RuleMaxOrdersPerMinute,
+			Reason: fmt.Sprintf("%d orders placed in the last minute, limit is %d", len(m.orders), max),
+		}
+	}
+
+	// the sell orders reduce the risk, only the rate limit applies
+	if order.Side != exchanges.SideTypeBuy {
+		return nil
+	}
+
+	product := strings.ToUpper(order.ProductID)
+	quote := quoteCurrency(order)
+
+	if max, ok := m.limits.MaxPositionSize[product]; ok && max.IsPositive() {
+		if size := m.positions[positionKey(order)].Add(order.Size); size.GreaterThan(max) {
+			return &Error{
+				Rule:   RuleMaxPositionSize,
+				Reason: fmt.Sprintf("position of %s %s exceeds the limit of %s", size, product, max),
+			}
+		}
+	}
+
+	if max, ok := m.limits.MaxExposure[quote]; ok && max.IsPositive() {
+		if exposure := m.exposures[quote].Add(order.GetCost()); exposure.GreaterThan(max) {
+			return &Error{
+				Rule:   RuleMaxExposure,
+				Reason: fmt.Sprintf("exposure of %s %s exceeds the limit of %s", exposure, quote, max),
+			}
+		}
+	}
+
+	if max, ok := m.limits.MaxDailyLoss[quote]; ok && max.IsPositive() {
+		if loss := m.losses[quote]; loss.GreaterThanOrEqual(max) {
+			return &Error{
+				Rule:   RuleMaxDailyLoss,
+				Reason: fmt.Sprintf("daily net realized loss of %s %s reached the limit of %s", loss, quote, max),
+			}
+		}
+	}
+
+	if reserve, ok := m.limits.MinBalanceReserve[quote]; ok && reserve.IsPositive() && m.balances != nil {
+		balance, err := m.balances.Balance(order.Provider, quote)
+		if err != nil {
+			return &Error{
+				Rule:   RuleMinBalanceReserve,
+				Reason: fmt.Sprintf("cannot get %s balance: %v", quote, err),
+			}
+		}
+
+		if remaining := balance.Sub(order.GetCost()); remaining.LessThan(reserve) {
+			return &Error{
+				Rule:   RuleMinBalanceReserve,
+				Reason: fmt.Sprintf("balance of %s %s after order is below the reserve of %s", remaining, quote, reserve),
+			}
+		}
+	}
+
+	return nil
+}
+
+// Filled records an executed order, the buy orders open a position
+func (m *Manager) Filled(order *entity.Order) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.pruneOrders(now)
+
+	m.orders = append(m.orders, now)
+
+	if order.Side == exchanges.SideTypeBuy {
+		m.open(order)
+	}
+}
+
+// Hedged records the orders of an offsetting pair, they count for the orders per minute limit
+// without opening a position since the bought volume is sold at the same time
+func (m *Manager) Hedged(orders ...*entity.Order) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.pruneOrders(now)
+
+	for range orders {
+		m.orders = append(m.orders, now)
+	}
+}
+
+// Restore the position of a buy order executed before the start of the manager
+func (m *Manager) Restore(order *entity.Order) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.open(order)
+}
+
+// Closed releases the position opened by buy and records the realized pnl of sell net of fees
+func (m *Manager) Closed(buy *entity.Order, sell *entity.Order) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resetDay(m.now())
+
+	key := positionKey(buy)
+	quote := quoteCurrency(buy)
+
+	m.positions[key] = m.positions[key].Sub(buy.Size)
+	m.exposures[quote] = m.exposures[quote].Sub(buy.GetCost())
+
+	if m.positions[key].Sign() <= 0 {
+		delete(m.positions, key)
+	}
+
+	if m.exposures[quote].Sign() <= 0 {
+		delete(m.exposures, quote)
+	}
+
+	m.lose(buy, sell)
+}
+
+// RestoreLoss records the realized pnl of a position closed at closedAt before the start of the manager,
+// the positions closed before the current day are ignored
+func (m *Manager) RestoreLoss(buy *entity.Order, sell *entity.Order, closedAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resetDay(m.now())
+
+	if closedAt.UTC().Format("2006-01-02") != m.day {
+		return
+	}
+
+	m.lose(buy, sell)
+}
+
+// lose adds the realized pnl of sell net of fees to the daily losses, the daily loss is the net loss of the day
+// and the profits offset the losses
+func (m *Manager) lose(buy *entity.Order, sell *entity.Order) {
+	pnl := sell.Price.Sub(sell.Fee).Sub(buy.GetCost())
+	quote := quoteCurrency(buy)
+
+	m.losses[quote] = m.losses[quote].Sub(pnl)
+}
+
+func (m *Manager) open(order *entity.Order) {
+	key := positionKey(order)
+	quote := quoteCurrency(order)
+
+	m.positions[key] = m.positions[key].Add(order.Size)
+	m.exposures[quote] = m.exposures[quote].Add(order.GetCost())
+}
+
+// State of Manager
+func (m *Manager) State() *State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.resetDay(now)
+	m.pruneOrders(now)
+
+	state := &State{
+		Positions:   make(map[string]decimal.Decimal),
+		Exposures:   make(map[string]decimal.Decimal),
+		DailyLoss:   make(map[string]decimal.Decimal),
+		OrdersCount: len(m.orders),
+	}
+
+	for key, val := range m.positions {
+		state.Positions[key] = val
+	}
+
+	for key, val := range m.exposures {
+		state.Exposures[key] = val
+	}
+
+	for key, val := range m.losses {
+		state.DailyLoss[key] = val
+	}
+
+	return state
+}
@@ -0,0 +1,266 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package risk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type balancesMock map[string]float64
+
+func (b balancesMock) Balance(provider string, currency string) (decimal.Decimal, error) {
+	balance, ok := b[currency]
+	if ok == false {
+		return decimal.Zero, errors.New("currency not found")
+	}
+
+	return decimal.NewFromFloat(balance), nil
+}
+
+func limit(currency string, value float64) map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		currency: decimal.NewFromFloat(value),
+	}
+}
+
+func newOrder(side exchanges.SideType, size float64, price float64) *entity.Order {
+	return &entity.Order{
+		Provider:  "gdax",
+		Side:      side,
+		ProductID: "BTC-EUR",
+		Size:      decimal.NewFromFloat(size),
+		Price:     decimal.NewFromFloat(price),
+	}
+}
+
+func assertRule(t *testing.T, rule string, err error) {
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, rule, err.(*Error).Rule)
+	}
+}
+
+func TestManagerWithoutLimits(t *testing.T) {
+	m := NewManager(nil)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 10, 1000)))
+
+		m.Filled(newOrder(exchanges.SideTypeBuy, 10, 1000))
+	}
+}
+
+func TestManagerMaxPositionSize(t *testing.T) {
+	m := NewManager(&Limits{
+		MaxPositionSize: limit("BTC-EUR", 1),
+	})
+
+	buy := newOrder(exchanges.SideTypeBuy, 0.6, 600)
+
+	assert.NoError(t, m.Check(buy))
+
+	m.Filled(buy)
+
+	assertRule(t, RuleMaxPositionSize, m.Check(newOrder(exchanges.SideTypeBuy, 0.6, 600)))
+
+	// sell orders are not limited by the position size
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeSell, 0.6, 600)))
+
+	m.Closed(buy, newOrder(exchanges.SideTypeSell, 0.6, 650))
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 0.6, 600)))
+}
+
+func TestManagerMaxPositionSizeIsExact(t *testing.T) {
+	m := NewManager(&Limits{
+		MaxPositionSize: limit("BTC-EUR", 0.3),
+	})
+
+	m.Filled(newOrder(exchanges.SideTypeBuy, 0.1, 100))
+
+	// 0.1 + 0.2 is above 0.3 with floats
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 0.2, 200)))
+	assertRule(t, RuleMaxPositionSize, m.Check(newOrder(exchanges.SideTypeBuy, 0.20000001, 200)))
+}
+
+func TestManagerMaxExposure(t *testing.T) {
+	m := NewManager(&Limits{
+		MaxExposure: limit("EUR", 1000),
+	})
+
+	m.Filled(newOrder(exchanges.SideTypeBuy, 1, 800))
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 0.1, 200)))
+	assertRule(t, RuleMaxExposure, m.Check(newOrder(exchanges.SideTypeBuy, 0.2, 201)))
+
+	// the fee is part of the exposure
+	order := newOrder(exchanges.SideTypeBuy, 0.1, 199)
+	order.Fee = decimal.New(2, 0)
+
+	assertRule(t, RuleMaxExposure, m.Check(order))
+
+	assert.Equal(t, "800", m.State().Exposures["EUR"].String())
+}
+
+func TestManagerMaxOrdersPerMinute(t *testing.T) {
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	m := NewManager(&Limits{
+		MaxOrdersPerMinute: 2,
+	})
+	m.now = func() time.Time {
+		return now
+	}
+
+	m.Filled(newOrder(exchanges.SideTypeBuy, 1, 10))
+	m.Filled(newOrder(exchanges.SideTypeSell, 1, 10))
+
+	assertRule(t, RuleMaxOrdersPerMinute, m.Check(newOrder(exchanges.SideTypeBuy, 1, 10)))
+	assertRule(t, RuleMaxOrdersPerMinute, m.Check(newOrder(exchanges.SideTypeSell, 1, 10)))
+
+	now = now.Add(time.Minute)
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 10)))
+}
+
+func TestManagerMaxDailyLoss(t *testing.T) {
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	m := NewManager(&Limits{
+		MaxDailyLoss: limit("EUR", 100),
+	})
+	m.now = func() time.Time {
+		return now
+	}
+
+	buy := newOrder(exchanges.SideTypeBuy, 1, 1000)
+
+	m.Filled(buy)
+	m.Closed(buy, newOrder(exchanges.SideTypeSell, 1, 950))
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+
+	sell := newOrder(exchanges.SideTypeSell, 1, 945)
+	sell.Fee = decimal.New(5, 0)
+
+	m.Filled(buy)
+	m.Closed(buy, sell)
+
+	assert.Equal(t, "110", m.State().DailyLoss["EUR"].String())
+
+	assertRule(t, RuleMaxDailyLoss, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeSell, 1, 1000)))
+
+	// the profits of the day offset the losses
+	m.Filled(buy)
+	m.Closed(buy, newOrder(exchanges.SideTypeSell, 1, 1030))
+
+	assert.Equal(t, "80", m.State().DailyLoss["EUR"].String())
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+
+	now = now.Add(24 * time.Hour)
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+}
+
+func TestManagerMinBalanceReserve(t *testing.T) {
+	m := NewManager(&Limits{
+		MinBalanceReserve: limit("EUR", 50),
+	})
+
+	// the reserve is not checked without balances
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+
+	m.SetBalances(balancesMock{
+		"EUR": 1000,
+	})
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 950)))
+	assertRule(t, RuleMinBalanceReserve, m.Check(newOrder(exchanges.SideTypeBuy, 1, 951)))
+
+	m.SetBalances(balancesMock{})
+
+	assertRule(t, RuleMinBalanceReserve, m.Check(newOrder(exchanges.SideTypeBuy, 1, 10)))
+}
+
+func TestManagerRestore(t *testing.T) {
+	m := NewManager(nil)
+
+	m.Restore(newOrder(exchanges.SideTypeBuy, 0.5, 500))
+
+	state := m.State()
+
+	assert.Equal(t, "0.5", state.Positions["gdax-BTC-EUR"].String())
+	assert.Equal(t, "500", state.Exposures["EUR"].String())
+	assert.Equal(t, 0, state.OrdersCount)
+}
+
+func TestManagerRestoreLoss(t *testing.T) {
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	m := NewManager(&Limits{
+		MaxDailyLoss: limit("EUR", 100),
+	})
+	m.now = func() time.Time {
+		return now
+	}
+
+	buy := newOrder(exchanges.SideTypeBuy, 1, 1000)
+
+	m.RestoreLoss(buy, newOrder(exchanges.SideTypeSell, 1, 900), now.Add(-24*time.Hour))
+	m.RestoreLoss(buy, newOrder(exchanges.SideTypeSell, 1, 1100), now.Add(-time.Hour))
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+
+	m.RestoreLoss(buy, newOrder(exchanges.SideTypeSell, 1, 880), now.Add(-time.Hour))
+
+	// the profit of the day offsets the loss
+	state := m.State()
+	assert.Equal(t, "20", state.DailyLoss["EUR"].String())
+	assert.Equal(t, 0, len(state.Positions))
+	assert.Equal(t, 0, state.OrdersCount)
+
+	assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+
+	m.RestoreLoss(buy, newOrder(exchanges.SideTypeSell, 1, 920), now.Add(-time.Hour))
+
+	assert.Equal(t, "100", m.State().DailyLoss["EUR"].String())
+
+	assertRule(t, RuleMaxDailyLoss, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := &Error{
+		Rule:   RuleMaxExposure,
+		Reason: "foo",
+	}
+
+	assert.EqualError(t, err, "risk max_exposure: foo")
+}
+
+func TestManagerHedged(t *testing.T) {
+	m := NewManager(&Limits{
+		MaxExposure:        limit("EUR", 1500),
+		MaxOrdersPerMinute: 4,
+	})
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+
+		m.Hedged(newOrder(exchanges.SideTypeBuy, 1, 1000), newOrder(exchanges.SideTypeSell, 1, 1010))
+	}
+
+	state := m.State()
+	assert.Equal(t, 0, len(state.Exposures))
+	assert.Equal(t, 4, state.OrdersCount)
+
+	assertRule(t, RuleMaxOrdersPerMinute, m.Check(newOrder(exchanges.SideTypeBuy, 1, 1000)))
+}
@@ -5,20 +5,31 @@
 package cryptotrader
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	stdlog "log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/arbitrage"
 	"github.com/euskadi31/cryptotrader/config"
 	"github.com/euskadi31/cryptotrader/controllers"
+	"github.com/euskadi31/cryptotrader/database"
+	"github.com/euskadi31/cryptotrader/database/entity"
 	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/exchanges/binance"
 	"github.com/euskadi31/cryptotrader/exchanges/gdax"
-	"github.com/euskadi31/cryptotrader/services"
+	"github.com/euskadi31/cryptotrader/exchanges/kraken"
+	"github.com/euskadi31/cryptotrader/exchanges/paper"
+	"github.com/euskadi31/cryptotrader/ledger"
+	"github.com/euskadi31/cryptotrader/portfolio"
+	"github.com/euskadi31/cryptotrader/risk"
 	"github.com/euskadi31/cryptotrader/trader"
 	"github.com/euskadi31/cryptotrader/trader/algorithms"
 	"github.com/euskadi31/go-eventemitter"
@@ -38,19 +49,24 @@ var container = service.New()
 
 // const of service name
 const (
-	ServiceLoggerKey           string = "service.logger"
-	ServiceConfigKey                  = "service.config"
-	ServiceRouterKey                  = "service.router"
-	ServiceDBKey                      = "service.db.storm"
-	ServiceExchangeManagerKey         = "service.exchange.manager"
-	ServiceGDAXExchangeKey            = "service.exchange.gdax"
-	ServiceTimeseriesKey              = "service.timeseries"
-	ServiceTraderEngineKey            = "service.trader.engine"
-	ServiceAlgorithmManagerKey        = "service.algorithm.manager"
-	ServiceAlgorithmTrendKey          = "service.algorithm.trend"
-	ServiceCampaignKey                = "service.campaign"
-	ServiceOrderKey                   = "service.order"
-	ServiceEventEmitterKey            = "service.eventemitter"
+	ServiceLoggerKey             string = "service.logger"
+	ServiceConfigKey                    = "service.config"
+	ServiceRouterKey                    = "service.router"
+	ServiceDBKey                        = "service.db.storm"
+	ServiceExchangeManagerKey           = "service.exchange.manager"
+	ServiceExchangeRegistryKey          = "service.exchange.registry"
+	ServiceTimeseriesKey                = "service.timeseries"
+	ServiceTraderEngineKey              = "service.trader.engine"
+	ServiceLedgerKey                    = "service.ledger"
+	ServicePortfolioKey                 = "service.portfolio"
+	ServiceArbitrageKey                 = "service.arbitrage"
+	ServiceRiskManagerKey               = "service.risk.manager"
+	ServiceAlgorithmManagerKey          = "service.algorithm.manager"
+	ServiceAlgorithmTrendKey            = "service.algorithm.trend"
+	ServiceAlgorithmMACrossKey          = "service.algorithm.ma_cross"
+	ServiceAlgorithmBollingerKey        = "service.algorithm.bollinger"
+	ServiceAlgorithmScriptKey           = "service.algorithm.script"
+	ServiceEventEmitterKey              = "service.eventemitter"
 )
 
 func init() {
@@ -94,9 +110,15 @@ func init() {
 		}
 
 		options.SetDefault("server.port", 8989)
+		options.SetDefault("server.shutdown_timeout", 30*time.Second)
 		options.SetDefault("logger.level", "info")
 		options.SetDefault("logger.prefix", applicationName)
 		options.SetDefault("database.path", "/var/lib/cryptotrader")
+		options.SetDefault("engine.feed_timeout", 5*time.Minute)
+		options.SetDefault("engine.max_order_errors", 3)
+		options.SetDefault("engine.rejection_cooldown", 15*time.Minute)
+		options.SetDefault("portfolio.quote", "EUR")
+		options.SetDefault("portfolio.interval", 5*time.Minute)
 
 		options.SetConfigName("config") // name of config file (without extension)
 
@@ -134,62 +156,218 @@ func init() {
 			log.Fatal().Err(err).Msg(ServiceDBKey)
 		}
 
+		if err := database.Migrate(db); err != nil {
+			log.Fatal().Err(err).Msg("Migrate database")
+		}
+
 		return db
 	})
 
-	container.Set(ServiceGDAXExchangeKey, func(c *service.Container) interface{} {
-		// cfg := c.Get(ServiceConfigKey).(*config.Configuration)
+	container.Set(ServiceExchangeRegistryKey, func(c *service.Container) interface{} {
+		registry := exchanges.NewRegistry()
 
-		ex, err := gdax.NewGDAX()
-		if err != nil {
-			log.Fatal().Err(err).Msg(ServiceGDAXExchangeKey)
-		}
+		registry.Register("gdax", gdax.NewProvider)
+		registry.Register("kraken", kraken.NewProvider)
+		registry.Register("binance", binance.NewProvider)
+		registry.Register("paper", paper.NewProvider)
 
-		return ex
+		return registry
 	})
 
 	container.Set(ServiceExchangeManagerKey, func(c *service.Container) interface{} {
-		manager := exchanges.NewManager()
+		cfg := c.Get(ServiceConfigKey).(*config.Configuration)
+		registry := c.Get(ServiceExchangeRegistryKey).(exchanges.Registry)
+
+		configs := []*exchanges.ProviderConfig{}
+
+		for name, exchange := range cfg.Exchanges {
+			if exchange.Enabled == false {
+				continue
+			}
+
+			configs = append(configs, &exchanges.ProviderConfig{
+				Name:         name,
+				Type:         exchange.Type,
+				Key:          exchange.Key,
+				Secret:       exchange.Secret,
+				Passphrase:   exchange.Passphrase,
+				Sandbox:      exchange.Sandbox,
+				API:          exchange.API,
+				WebSocketAPI: exchange.WebSocketAPI,
+				Source:       exchange.Source,
+				Balances:     exchange.Balances,
+			})
+		}
+
+		// the providers connect to the exchanges when they are used
+		manager, err := registry.Build(configs)
+		if err != nil {
+			log.Fatal().Err(err).Msg(ServiceExchangeManagerKey)
+		}
 
-		manager.Add(c.Get(ServiceGDAXExchangeKey).(exchanges.ExchangeProvider))
+		// the virtual balances of the paper exchanges survive the restarts
+		store := database.NewPaperBalanceStore(c.Get(ServiceDBKey).(*storm.DB))
+
+		for _, exchange := range manager {
+			if p, ok := exchange.(*paper.Paper); ok {
+				if err := p.Persist(store); err != nil {
+					log.Fatal().Err(err).Msgf("Load balances of %s", p.Name())
+				}
+			}
+		}
 
 		return manager
 	})
 
 	container.Set(ServiceAlgorithmTrendKey, func(c *service.Container) interface{} {
-		campaignService := c.Get(ServiceCampaignKey).(*services.CampaignService)
-		orderService := c.Get(ServiceOrderKey).(*services.OrderService)
+		return algorithms.NewTrend()
+	})
 
-		return algorithms.NewTrend(campaignService, orderService)
+	container.Set(ServiceAlgorithmMACrossKey, func(c *service.Container) interface{} {
+		return algorithms.NewMACross()
+	})
+
+	container.Set(ServiceAlgorithmBollingerKey, func(c *service.Container) interface{} {
+		return algorithms.NewBollinger()
+	})
+
+	container.Set(ServiceAlgorithmScriptKey, func(c *service.Container) interface{} {
+		return algorithms.NewScript()
 	})
 
 	container.Set(ServiceAlgorithmManagerKey, func(c *service.Container) interface{} {
+		cfg := c.Get(ServiceConfigKey).(*config.Configuration)
+
 		manager := algorithms.NewManager()
 
 		manager.Add(c.Get(ServiceAlgorithmTrendKey).(algorithms.Algorithm))
+		manager.Add(c.Get(ServiceAlgorithmMACrossKey).(algorithms.Algorithm))
+		manager.Add(c.Get(ServiceAlgorithmBollingerKey).(algorithms.Algorithm))
+		manager.Add(c.Get(ServiceAlgorithmScriptKey).(algorithms.Algorithm))
+		manager.Add(algorithms.NewComposite("composite", nil, manager))
+
+		for name, definition := range cfg.Algorithms.Composites {
+			defaults := algorithms.Options{}
+
+			for key, value := range definition {
+				defaults.Set("composite."+key, value)
+			}
+
+			manager.Add(algorithms.NewComposite(name, defaults, manager))
+		}
+
+		return manager
+	})
+
+	container.Set(ServiceRiskManagerKey, func(c *service.Container) interface{} {
+		cfg := c.Get(ServiceConfigKey).(*config.Configuration)
+		exchangesManager := c.Get(ServiceExchangeManagerKey).(exchanges.Manager)
+
+		manager := risk.NewManager(&risk.Limits{
+			MaxPositionSize:    cfg.Risk.MaxPositionSize,
+			MaxExposure:        cfg.Risk.MaxExposure,
+			MaxOrdersPerMinute: cfg.Risk.MaxOrdersPerMinute,
+			MaxDailyLoss:       cfg.Risk.MaxDailyLoss,
+			MinBalanceReserve:  cfg.Risk.MinBalanceReserve,
+		})
+
+		manager.SetBalances(exchangesManager)
 
 		return manager
 	})
 
-	container.Set(ServiceCampaignKey, func(c *service.Container) interface{} {
+	container.Set(ServiceLedgerKey, func(c *service.Container) interface{} {
 		db := c.Get(ServiceDBKey).(*storm.DB)
+		engine := c.Get(ServiceTraderEngineKey).(*trader.Engine)
 
-		return services.NewCampaignService(db)
+		return ledger.NewService(db, engine, engine)
 	})
 
-	container.Set(ServiceOrderKey, func(c *service.Container) interface{} {
+	container.Set(ServicePortfolioKey, func(c *service.Container) interface{} {
+		cfg := c.Get(ServiceConfigKey).(*config.Configuration)
 		db := c.Get(ServiceDBKey).(*storm.DB)
+		exchangesManager := c.Get(ServiceExchangeManagerKey).(exchanges.Manager)
+		engine := c.Get(ServiceTraderEngineKey).(*trader.Engine)
+
+		if err := db.Init(&entity.PortfolioSnapshot{}); err != nil {
+			log.Fatal().Err(err).Msg("Initialize bucket for PortfolioSnapshot")
+		}
+
+		return portfolio.NewService(db, exchangesManager, engine, cfg.Portfolio.Quote)
+	})
+
+	container.Set(ServiceArbitrageKey, func(c *service.Container) interface{} {
+		cfg := c.Get(ServiceConfigKey).(*config.Configuration)
+		exchangesManager := c.Get(ServiceExchangeManagerKey).(exchanges.Manager)
+		engine := c.Get(ServiceTraderEngineKey).(*trader.Engine)
+		riskManager := c.Get(ServiceRiskManagerKey).(*risk.Manager)
+		emitter := c.Get(ServiceEventEmitterKey).(eventemitter.EventEmitter)
+
+		products := []exchanges.Product{}
+
+		for _, product := range cfg.Arbitrage.Products {
+			products = append(products, exchanges.NewProductFromString(strings.ToUpper(product)))
+		}
 
-		return services.NewOrderService(db)
+		return arbitrage.NewService(exchangesManager, engine.Fees(), riskManager, emitter, &arbitrage.Options{
+			Products:    products,
+			Providers:   cfg.Arbitrage.Providers,
+			Threshold:   cfg.Arbitrage.Threshold,
+			MaxPriceAge: cfg.Arbitrage.MaxPriceAge,
+			Execute:     cfg.Arbitrage.Execute,
+			Size:        cfg.Arbitrage.Size,
+			Cooldown:    cfg.Arbitrage.Cooldown,
+		})
 	})
 
 	container.Set(ServiceTraderEngineKey, func(c *service.Container) interface{} {
+		cfg := c.Get(ServiceConfigKey).(*config.Configuration)
 		db := c.Get(ServiceDBKey).(*storm.DB)
 		exchangesManager := c.Get(ServiceExchangeManagerKey).(exchanges.Manager)
 		algorithmsManager := c.Get(ServiceAlgorithmManagerKey).(algorithms.Manager)
+		riskManager := c.Get(ServiceRiskManagerKey).(*risk.Manager)
 		emitter := c.Get(ServiceEventEmitterKey).(eventemitter.EventEmitter)
 
-		return trader.NewEngine(db, exchangesManager, algorithmsManager, emitter)
+		defaults := exchanges.FeeSchedules{
+			"gdax":    gdax.DefaultFeeSchedule(),
+			"kraken":  kraken.DefaultFeeSchedule(),
+			"binance": binance.DefaultFeeSchedule(),
+		}
+
+		// the instances pay the default fees of their type unless their fees are configured
+		fees := exchanges.FeeSchedules{}
+
+		for name, exchange := range cfg.Exchanges {
+			fees[name] = defaults.Get(exchange.Type)
+		}
+
+		for provider, tiers := range cfg.Fees {
+			feeTiers := []exchanges.FeeTier{}
+
+			for _, tier := range tiers {
+				feeTiers = append(feeTiers, exchanges.FeeTier{
+					Volume: tier.Volume,
+					Maker:  tier.Maker,
+					Taker:  tier.Taker,
+				})
+			}
+
+			fees[provider] = exchanges.NewFeeSchedule(feeTiers...)
+		}
+
+		// the paper exchanges pay the fees of their source
+		for name, exchange := range cfg.Exchanges {
+			if _, ok := cfg.Fees[name]; ok == false && exchange.Source != "" {
+				fees[name] = fees.Get(exchange.Source)
+			}
+		}
+
+		return trader.NewEngine(db, exchangesManager, algorithmsManager, riskManager, emitter, &trader.Options{
+			FeedTimeout:       cfg.Engine.FeedTimeout,
+			MaxOrderErrors:    cfg.Engine.MaxOrderErrors,
+			RejectionCooldown: cfg.Engine.RejectionCooldown,
+			Fees:              fees,
+		})
 	})
 
 	container.Set(ServiceRouterKey, func(c *service.Container) interface{} {
@@ -198,6 +376,10 @@ func init() {
 		db := c.Get(ServiceDBKey).(*storm.DB)
 		engine := c.Get(ServiceTraderEngineKey).(*trader.Engine)
 		algorithmsManager := c.Get(ServiceAlgorithmManagerKey).(algorithms.Manager)
+		riskManager := c.Get(ServiceRiskManagerKey).(*risk.Manager)
+		portfolioService := c.Get(ServicePortfolioKey).(*portfolio.Service)
+		ledgerService := c.Get(ServiceLedgerKey).(*ledger.Service)
+		arbitrageService := c.Get(ServiceArbitrageKey).(*arbitrage.Service)
 		emitter := c.Get(ServiceEventEmitterKey).(eventemitter.EventEmitter)
 
 		router := server.NewRouter()
@@ -260,15 +442,55 @@ func init() {
 			})
 		})
 
-		router.AddController(controllers.NewTimeseriesController(engine, emitter))
+		router.AddController(controllers.NewTimeseriesController(engine, algorithmsManager, emitter))
 		router.AddController(controllers.NewCampaignController(db, engine))
 		router.AddController(controllers.NewAlgorithmController(algorithmsManager))
+		router.AddController(controllers.NewBacktestController(engine, algorithmsManager))
+		router.AddController(controllers.NewRiskController(db, riskManager))
+		router.AddController(controllers.NewOrderController(db))
+		router.AddController(controllers.NewEngineController(engine))
+		router.AddController(controllers.NewPortfolioController(portfolioService))
+		router.AddController(controllers.NewPnLController(db, ledgerService))
+		router.AddController(controllers.NewArbitrageController(arbitrageService))
 		router.AddController(controllers.NewUIController())
 
 		return router
 	})
 }
 
+// shutdown the server, the engine and the exchanges, then the database is closed to flush it
+func shutdown(ctx context.Context, srv *http.Server) {
+	db := container.Get(ServiceDBKey).(*storm.DB)
+	exchangesManager := container.Get(ServiceExchangeManagerKey).(exchanges.Manager)
+	engine := container.Get(ServiceTraderEngineKey).(*trader.Engine)
+	portfolioService := container.Get(ServicePortfolioKey).(*portfolio.Service)
+	arbitrageService := container.Get(ServiceArbitrageKey).(*arbitrage.Service)
+	emitter := container.Get(ServiceEventEmitterKey).(eventemitter.EventEmitter)
+
+	// the requests in progress can place orders
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Shutdown server")
+	}
+
+	arbitrageService.Stop()
+	portfolioService.Stop()
+
+	// the orders in progress are completed before the exchanges are closed
+	if err := engine.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Shutdown trader engine")
+	}
+
+	if err := exchangesManager.Close(ctx); err != nil {
+		log.Error().Err(err).Msg("Close exchanges")
+	}
+
+	emitter.Wait()
+
+	if err := db.Close(); err != nil {
+		log.Error().Err(err).Msg("Close database")
+	}
+}
+
 // Run Application
 func Run() {
 	_ = container.Get(ServiceLoggerKey).(zerolog.Logger)
@@ -276,24 +498,64 @@ func Run() {
 
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 
-	emitter := container.Get(ServiceEventEmitterKey).(eventemitter.EventEmitter)
 	router := container.Get(ServiceRouterKey).(*server.Router)
 
 	engine := container.Get(ServiceTraderEngineKey).(*trader.Engine)
+	portfolioService := container.Get(ServicePortfolioKey).(*portfolio.Service)
+	arbitrageService := container.Get(ServiceArbitrageKey).(*arbitrage.Service)
 
 	go func() {
 		log.Info().Msg("Starting trader engine...")
 
-		engine.Start()
-	}()
+		if err := engine.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Start trader engine")
+		}
 
-	log.Info().Msgf("Server running on %s", addr)
+		for provider, products := range cfg.Portfolio.Products {
+			productList := []exchanges.Product{}
 
-	if err := http.ListenAndServe(addr, router); err != nil {
-		log.Fatal().Err(err).Msg("ListenAndServe")
+			for _, product := range products {
+				productList = append(productList, exchanges.NewProductFromString(strings.ToUpper(product)))
+			}
+
+			if err := engine.Subscribe(provider, productList...); err != nil {
+				log.Error().Err(err).Msgf("Subscribe portfolio products on %s", provider)
+			}
+		}
+
+		if cfg.Arbitrage.Enabled {
+			if err := arbitrageService.Start(engine); err != nil {
+				log.Error().Err(err).Msg("Start arbitrage monitor")
+			}
+		}
+
+		portfolioService.Start(cfg.Portfolio.Interval)
+	}()
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: router,
 	}
 
-	emitter.Wait()
+	go func() {
+		log.Info().Msgf("Server running on %s", addr)
+
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Err(err).Msg("ListenAndServe")
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-signals
+
+	log.Info().Msgf("Received %s, shutting down...", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	shutdown(ctx, srv)
 
 	log.Info().Msg("Server exited")
 }
@@ -19,6 +19,7 @@ type TickerObserver func(event *Ticker)
 type TickerService struct {
 	eventsCh  chan *Ticker
 	observers map[string][]TickerObserver
+	doneCh    chan bool
 }
 
 // NewTickerService func
@@ -26,6 +27,7 @@ func NewTickerService() *TickerService {
 	s := &TickerService{
 		eventsCh:  make(chan *Ticker, 100),
 		observers: make(map[string][]TickerObserver),
+		doneCh:    make(chan bool),
 	}
 
 	go s.looper()
@@ -50,11 +52,21 @@ func (s *TickerService) looper() {
 			for _, observer := range observers {
 				observer(e)
 			}
+		case <-s.doneCh:
+			return
 		}
 	}
 }
 
-// Send Ticker event to service
+// Send Ticker event to service, the event is discarded after Stop
 func (s *TickerService) Send(event *Ticker) {
-	s.eventsCh <- event
+	select {
+	case s.eventsCh <- event:
+	case <-s.doneCh:
+	}
+}
+
+// Stop the dispatch of the events
+func (s *TickerService) Stop() {
+	close(s.doneCh)
 }
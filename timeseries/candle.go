@@ -0,0 +1,94 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package timeseries
+
+// Candle struct
+type Candle struct {
+	Time   int64   `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// Candles aggregates the DataPoint of Timeseries by period in seconds.
+// The last candle is still open until a DataPoint of the next period is added.
+func (ts *Timeseries) Candles(period int64) []*Candle {
+	candles := []*Candle{}
+
+	if period <= 0 {
+		return candles
+	}
+
+	var candle *Candle
+
+	ts.RLock()
+	for i, v := range ts.values {
+		t := ts.times[i] - (ts.times[i] % period)
+
+		if candle == nil || candle.Time != t {
+			candle = &Candle{
+				Time:   t,
+				Open:   v,
+				High:   v,
+				Low:    v,
+				Close:  v,
+				Volume: ts.volumes[i],
+			}
+
+			candles = append(candles, candle)
+
+			continue
+		}
+
+		if v > candle.High {
+			candle.High = v
+		}
+
+		if v < candle.Low {
+			candle.Low = v
+		}
+
+		candle.Close = v
+		candle.Volume += ts.volumes[i]
+	}
+	ts.RUnlock()
+
+	return candles
+}
+
+// ClosedCandles returns the candles of Timeseries without the last one which is still open.
+func (ts *Timeseries) ClosedCandles(period int64) []*Candle {
+	candles := ts.Candles(period)
+
+	if len(candles) == 0 {
+		return candles
+	}
+
+	return candles[:len(candles)-1]
+}
+
+// CandlesClose returns the close values of candles
+func CandlesClose(candles []*Candle) []float64 {
+	values := make([]float64, 0, len(candles))
+
+	for _, c := range candles {
+		values = append(values, c.Close)
+	}
+
+	return values
+}
+
+// CandlesVolume returns the volume values of candles
+func CandlesVolume(candles []*Candle) []float64 {
+	values := make([]float64, 0, len(candles))
+
+	for _, c := range candles {
+		values = append(values, c.Volume)
+	}
+
+	return values
+}
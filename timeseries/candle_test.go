@@ -0,0 +1,67 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package timeseries
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeseriesCandles(t *testing.T) {
+	ts := New(10)
+
+	ts.Add(60, 10)
+	ts.Add(70, 14)
+	ts.Add(80, 8)
+	ts.Add(110, 12)
+	ts.Add(120, 13)
+	ts.Add(185, 11)
+
+	assert.Equal(t, []*Candle{
+		{
+			Time:  60,
+			Open:  10,
+			High:  14,
+			Low:   8,
+			Close: 12,
+		},
+		{
+			Time:  120,
+			Open:  13,
+			High:  13,
+			Low:   13,
+			Close: 13,
+		},
+		{
+			Time:  180,
+			Open:  11,
+			High:  11,
+			Low:   11,
+			Close: 11,
+		},
+	}, ts.Candles(60))
+
+	closed := ts.ClosedCandles(60)
+
+	assert.Equal(t, 2, len(closed))
+	assert.Equal(t, []float64{12, 13}, CandlesClose(closed))
+
+	assert.Equal(t, []*Candle{}, ts.Candles(0))
+	assert.Equal(t, []*Candle{}, New(10).ClosedCandles(60))
+}
+
+func TestTimeseriesCandlesVolume(t *testing.T) {
+	ts := New(10)
+
+	ts.AddWithVolume(60, 10, 0.5)
+	ts.AddWithVolume(70, 14, 1.5)
+	ts.AddWithVolume(120, 13, 3)
+
+	candles := ts.Candles(60)
+
+	assert.Equal(t, 2, len(candles))
+	assert.Equal(t, []float64{2, 3}, CandlesVolume(candles))
+}
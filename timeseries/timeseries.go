@@ -30,9 +30,10 @@ type DataPoint struct {
 // Timeseries struct
 type Timeseries struct {
 	*sync.RWMutex
-	size   int
-	times  []int64
-	values []float64
+	size    int
+	times   []int64
+	values  []float64
+	volumes []float64
 }
 
 // New Timeseries
@@ -41,33 +42,58 @@ func New(size int) *Timeseries {
 		RWMutex: &sync.RWMutex{},
 		times:   []int64{},
 		values:  []float64{},
+		volumes: []float64{},
 		size:    size,
 	}
 }
 
 // Size of timeseries
 func (ts *Timeseries) Size() int {
+	ts.RLock()
+	defer ts.RUnlock()
+
 	return len(ts.values)
 }
 
+// Snapshot returns a copy of Timeseries which is not updated by the new DataPoint
+func (ts *Timeseries) Snapshot() *Timeseries {
+	ts.RLock()
+	defer ts.RUnlock()
+
+	snapshot := New(ts.size)
+
+	snapshot.times = append(snapshot.times, ts.times...)
+	snapshot.values = append(snapshot.values, ts.values...)
+	snapshot.volumes = append(snapshot.volumes, ts.volumes...)
+
+	return snapshot
+}
+
 // Add DataPoint to Timeseries
 func (ts *Timeseries) Add(t int64, v float64) {
+	ts.AddWithVolume(t, v, 0)
+}
+
+// AddWithVolume adds DataPoint with the traded volume to Timeseries
+func (ts *Timeseries) AddWithVolume(t int64, v float64, volume float64) {
 	ts.Lock()
 	ts.times = append(ts.times, t)
 	ts.values = append(ts.values, v)
+	ts.volumes = append(ts.volumes, volume)
 
 	length := len(ts.times)
 
 	if length > ts.size {
 		ts.times = ts.times[length-ts.size:]
 		ts.values = ts.values[length-ts.size:]
+		ts.volumes = ts.volumes[length-ts.size:]
 	}
 
 	ts.Unlock()
 }
 
 // Keys slice
-func (ts Timeseries) Keys() []int64 {
+func (ts *Timeseries) Keys() []int64 {
 	keys := []int64{}
 
 	ts.RLock()
@@ -80,7 +106,7 @@ func (ts Timeseries) Keys() []int64 {
 }
 
 // Values slice
-func (ts Timeseries) Values() []float64 {
+func (ts *Timeseries) Values() []float64 {
 	values := []float64{}
 
 	ts.RLock()
@@ -92,8 +118,21 @@ func (ts Timeseries) Values() []float64 {
 	return values
 }
 
+// Volumes slice
+func (ts *Timeseries) Volumes() []float64 {
+	volumes := []float64{}
+
+	ts.RLock()
+	for _, v := range ts.volumes {
+		volumes = append(volumes, v)
+	}
+	ts.RUnlock()
+
+	return volumes
+}
+
 // MaxValue of Timeseries
-func (ts Timeseries) MaxValue() float64 {
+func (ts *Timeseries) MaxValue() float64 {
 	max := float64(0)
 
 	ts.RLock()
@@ -106,7 +145,7 @@ func (ts Timeseries) MaxValue() float64 {
 }
 
 // All DataPoint in Timeseries
-func (ts Timeseries) All() []*DataPoint {
+func (ts *Timeseries) All() []*DataPoint {
 	datas := []*DataPoint{}
 
 	ts.RLock()
@@ -124,22 +163,24 @@ func (ts Timeseries) All() []*DataPoint {
 }
 
 // GetLatestValues by size
-func (ts Timeseries) GetLatestValues(size int) []float64 {
+func (ts *Timeseries) GetLatestValues(size int) []float64 {
+	ts.RLock()
+	defer ts.RUnlock()
+
 	length := len(ts.values)
 
 	if length < size {
-		return ts.Values()
+		size = length
 	}
 
-	ts.RLock()
-	values := ts.values[length-size:]
-	ts.RUnlock()
+	values := make([]float64, size)
+	copy(values, ts.values[length-size:])
 
 	return values
 }
 
 // GetTrending for timeseries
-func (ts Timeseries) GetTrending(size int) (TrendType, error) {
+func (ts *Timeseries) GetTrending(size int) (TrendType, error) {
 	reg, err := regression.NewLinear([]float64{}, ts.GetLatestValues(size))
 	if err != nil {
 		return TrendTypeNeutral, err
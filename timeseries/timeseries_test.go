@@ -100,6 +100,30 @@ func TestTimeseries(t *testing.T) {
 	}, ts.All())
 }
 
+func TestTimeseriesSnapshot(t *testing.T) {
+	ts := New(3)
+
+	ts.AddWithVolume(1, 15, 2)
+	ts.AddWithVolume(2, 23, 3)
+
+	snapshot := ts.Snapshot()
+
+	ts.Add(3, 45)
+	ts.Add(4, 21)
+
+	assert.Equal(t, []int64{1, 2}, snapshot.Keys())
+	assert.Equal(t, []float64{15, 23}, snapshot.Values())
+	assert.Equal(t, []float64{2, 3}, snapshot.Volumes())
+	assert.Equal(t, []float64{23, 45, 21}, ts.Values())
+
+	// the latest values are a copy
+	latest := ts.GetLatestValues(2)
+	latest[0] = 0
+
+	assert.Equal(t, []float64{45, 21}, ts.GetLatestValues(2))
+	assert.Equal(t, []float64{23, 45, 21}, ts.GetLatestValues(10))
+}
+
 func TestTimeseriesTrendingIncreasing(t *testing.T) {
 	ts := New(6)
 
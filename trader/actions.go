@@ -0,0 +1,218 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// Errors of the campaign actions
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrTradingHalted    = errors.New("trading is halted")
+)
+
+// StateError is returned when an action is not allowed in the state of the campaign
+type StateError struct {
+	Action string
+	State  entity.CampaignState
+}
+
+func (e StateError) Error() string {
+	return fmt.Sprintf("cannot %s campaign in state %s", e.Action, e.State)
+}
+
+// PriceError is returned when an order is placed on a product without price
+type PriceError struct {
+	Provider string
+	Product  string
+}
+
+func (e PriceError) Error() string {
+	return fmt.Sprintf("no price received for %s on %s", e.Product, e.Provider)
+}
+
+// action runs fn on the campaign id with the orders of the workers locked,
+// the unsubscriptions queued by fn are sent after the locks are released
+func (e *Engine) action(id int, fn func(campaign *entity.Campaign) error) (*entity.Campaign, error) {
+	defer e.sendUnsubscribes()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	e.orderMu.Lock()
+	defer e.orderMu.Unlock()
+
+	campaign, ok := e.index.Get(id)
+	if ok == false {
+		return nil, ErrCampaignNotFound
+	}
+
+	if err := fn(campaign); err != nil {
+		return nil, err
+	}
+
+	return campaign, nil
+}
+
+// manualEvent at the last price of the campaign product
+func (e *Engine) manualEvent(campaign *entity.Campaign) (*exchanges.TickerEvent, error) {
+	if e.IsHalted() {
+		return nil, ErrTradingHalted
+	}
+
+	last, ok := e.lastPrice(campaign.Provider, campaign.ProductID)
+	if ok == false {
+		return nil, &PriceError{
+			Provider: campaign.Provider,
+			Product:  campaign.ProductID,
+		}
+	}
+
+	return &exchanges.TickerEvent{
+		Product: exchanges.NewProductFromString(campaign.ProductID),
+		Price:   decimal.NewFromFloat(last),
+		Time:    time.Now(),
+	}, nil
+}
+
+// PauseCampaign stops the signals of the algorithms, the position is kept
+func (e *Engine) PauseCampaign(id int) (*entity.Campaign, error) {
+	return e.action(id, func(campaign *entity.Campaign) error {
+		if campaign.IsState(entity.CampaignStateBuy) == false && campaign.IsState(entity.CampaignStateSell) == false {
+			return &StateError{
+				Action: "pause",
+				State:  campaign.State,
+			}
+		}
+
+		campaign.PausedState = campaign.State
+		campaign.State = entity.CampaignStatePaused
+
+		log.Info().Int("campaign", campaign.ID).Msg("Campaign paused")
+
+		return e.saveCampaign(campaign)
+	})
+}
+
+// ResumeCampaign restores the state of the campaign before the pause
+func (e *Engine) ResumeCampaign(id int) (*entity.Campaign, error) {
+	return e.action(id, func(campaign *entity.Campaign) error {
+		if campaign.IsPaused() == false {
+			return &StateError{
+				Action: "resume",
+				State:  campaign.State,
+			}
+		}
+
+		campaign.State = campaign.PausedState
+		campaign.PausedState = ""
+
+		if campaign.State == "" {
+			campaign.State = entity.CampaignStateBuy
+		}
+
+		log.Info().Int("campaign", campaign.ID).Msg("Campaign resumed")
+
+		return e.saveCampaign(campaign)
+	})
+}
+
+// BuyCampaign buys the volume of the campaign at the last price without waiting for the buy signal
+func (e *Engine) BuyCampaign(id int) (*entity.Campaign, error) {
+	return e.action(id, func(campaign *entity.Campaign) error {
+		if campaign.IsState(entity.CampaignStateBuy) == false {
+			return &StateError{
+				Action: "buy",
+				State:  campaign.State,
+			}
+		}
+
+		event, err := e.manualEvent(campaign)
+		if err != nil {
+			return err
+		}
+
+		log.Warn().Int("campaign", campaign.ID).Msg("Manual buy")
+
+		return e.buy(event, campaign)
+	})
+}
+
+// SellCampaign sells the position of the campaign at the last price without waiting for the sell signal
+func (e *Engine) SellCampaign(id int) (*entity.Campaign, error) {
+	return e.action(id, func(campaign *entity.Campaign) error {
+		if campaign.IsState(entity.CampaignStateSell) == false {
+			return &StateError{
+				Action: "sell",
+				State:  campaign.State,
+			}
+		}
+
+		event, err := e.manualEvent(campaign)
+		if err != nil {
+			return err
+		}
+
+		log.Warn().Int("campaign", campaign.ID).Msg("Manual sell")
+
+		return e.sell(event, campaign)
+	})
+}
+
+// CloseCampaign sells the position of the campaign, then stops it and releases its product
+func (e *Engine) CloseCampaign(id int) (*entity.Campaign, error) {
+	return e.action(id, func(campaign *entity.Campaign) error {
+		state := campaign.PositionState()
+
+		if campaign.IsClosed() || state == entity.CampaignStateBuying || state == entity.CampaignStateSelling {
+			return &StateError{
+				Action: "close",
+				State:  campaign.State,
+			}
+		}
+
+		if state == entity.CampaignStateSell {
+			event, err := e.manualEvent(campaign)
+			if err != nil {
+				return err
+			}
+
+			if err := e.sell(event, campaign); err != nil {
+				return err
+			}
+
+			// the last cycle of the campaign closes it
+			if campaign.IsClosed() {
+				return nil
+			}
+		}
+
+		return e.closeCampaign(campaign)
+	})
+}
+
+// closeCampaign stops the campaign and releases its product, the caller sends the queued unsubscription
+func (e *Engine) closeCampaign(campaign *entity.Campaign) error {
+	campaign.State = entity.CampaignStateClosed
+	campaign.PausedState = ""
+
+	if err := e.saveCampaign(campaign); err != nil {
+		return err
+	}
+
+	log.Info().Int("campaign", campaign.ID).Int("cycles", campaign.Cycles).Msgf("Campaign closed with %s", campaign.PnL)
+
+	return e.queueUnsubscribe(campaign.Provider, []exchanges.Product{
+		exchanges.NewProductFromString(campaign.ProductID),
+	})
+}
@@ -0,0 +1,270 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/go-std"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineCampaignActions(t *testing.T) {
+	engine, ticker, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	engine.algorithms.Add(holdAlgorithm{})
+
+	assert.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	campaign := &entity.Campaign{
+		ID:            1,
+		Provider:      "mock",
+		ProductID:     "BTC-EUR",
+		Volume:        decimal.New(1, 0),
+		State:         entity.CampaignStateBuy,
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+	}
+
+	assert.NoError(t, engine.SaveCampaign(campaign))
+
+	_, err := engine.BuyCampaign(1)
+	assert.Equal(t, &PriceError{Provider: "mock", Product: "BTC-EUR"}, err)
+
+	_, err = engine.PauseCampaign(42)
+	assert.Equal(t, ErrCampaignNotFound, err)
+
+	ticker.broadcaster.Broadcast(&exchanges.TickerEvent{
+		Product: exchanges.NewProduct("BTC", "EUR"),
+		Price:   decimal.New(1000, 0),
+		Size:    decimal.New(1, 0),
+		Time:    time.Now(),
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		if _, ok := engine.lastPrice("mock", "BTC-EUR"); ok || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// pause and resume
+	campaign, err = engine.PauseCampaign(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.CampaignStatePaused, campaign.State)
+	assert.Equal(t, entity.CampaignStateBuy, campaign.PausedState)
+
+	_, err = engine.BuyCampaign(1)
+	assert.Equal(t, &StateError{Action: "buy", State: entity.CampaignStatePaused}, err)
+
+	_, err = engine.PauseCampaign(1)
+	assert.Error(t, err)
+
+	campaign, err = engine.ResumeCampaign(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.CampaignStateBuy, campaign.State)
+	assert.Equal(t, entity.CampaignState(""), campaign.PausedState)
+
+	_, err = engine.ResumeCampaign(1)
+	assert.Equal(t, &StateError{Action: "resume", State: entity.CampaignStateBuy}, err)
+
+	// buy and sell now
+	_, err = engine.SellCampaign(1)
+	assert.Error(t, err)
+
+	campaign, err = engine.BuyCampaign(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.CampaignStateSell, campaign.State)
+	assert.True(t, campaign.BuyOrder.Price.Equal(decimal.New(1000, 0)))
+	assert.Equal(t, campaign.BuyOrder.ID, campaign.BuyOrderID)
+	assert.Equal(t, 1, campaign.BuyOrder.CampaignID)
+	assert.Equal(t, entity.OrderStateFilled, campaign.BuyOrder.State)
+	assert.Equal(t, 1, len(engine.risk.State().Positions))
+
+	campaign, err = engine.SellCampaign(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.CampaignStateBuy, campaign.State)
+	assert.NotNil(t, campaign.SellOrder)
+	assert.Equal(t, campaign.SellOrder.ID, campaign.SellOrderID)
+	assert.Equal(t, exchanges.SideTypeSell, campaign.SellOrder.Side)
+	assert.Nil(t, campaign.Orders)
+	assert.Equal(t, 0, len(engine.risk.State().Positions))
+
+	// close the paused position
+	_, err = engine.BuyCampaign(1)
+	assert.NoError(t, err)
+
+	_, err = engine.PauseCampaign(1)
+	assert.NoError(t, err)
+
+	campaign, err = engine.CloseCampaign(1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.CampaignStateClosed, campaign.State)
+	assert.Equal(t, entity.CampaignState(""), campaign.PausedState)
+	assert.Equal(t, 0, len(engine.risk.State().Positions))
+	assert.Equal(t, 0, engine.subscribers("mock", "BTC-EUR"))
+	assert.Equal(t, []exchanges.Product{exchanges.NewProduct("BTC", "EUR")}, ticker.unsubscribed)
+
+	_, err = engine.CloseCampaign(1)
+	assert.Equal(t, &StateError{Action: "close", State: entity.CampaignStateClosed}, err)
+
+	// the closed campaign is deleted without releasing the product again
+	assert.NoError(t, engine.Subscribe("mock", exchanges.NewProduct("BTC", "EUR")))
+	_, err = engine.DeleteCampaign(1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, engine.subscribers("mock", "BTC-EUR"))
+
+	// the manual orders respect the halt
+	campaign = &entity.Campaign{
+		ID:            2,
+		Provider:      "mock",
+		ProductID:     "BTC-EUR",
+		Volume:        decimal.New(1, 0),
+		State:         entity.CampaignStateBuy,
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+	}
+
+	assert.NoError(t, engine.SaveCampaign(campaign))
+
+	_, err = engine.Halt(&HaltRequest{Reason: "test"})
+	assert.NoError(t, err)
+
+	_, err = engine.BuyCampaign(2)
+	assert.Equal(t, ErrTradingHalted, err)
+
+	campaign, err = engine.CloseCampaign(2)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.CampaignStateClosed, campaign.State)
+}
+
+func TestEngineCampaignCycles(t *testing.T) {
+	engine, ticker, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	engine.algorithms.Add(holdAlgorithm{})
+
+	assert.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	campaign := &entity.Campaign{
+		ID:            1,
+		Provider:      "mock",
+		ProductID:     "BTC-EUR",
+		Volume:        decimal.New(1, 0),
+		State:         entity.CampaignStateBuy,
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+		MaxCycles:     2,
+	}
+
+	assert.NoError(t, engine.SaveCampaign(campaign))
+
+	campaign.MaxCycles = -1
+
+	assert.IsType(t, &ValidationError{}, engine.SaveCampaign(campaign))
+
+	ended := &entity.Campaign{
+		ID:            2,
+		Provider:      "mock",
+		ProductID:     "ETH-EUR",
+		Volume:        decimal.New(1, 0),
+		State:         entity.CampaignStateBuy,
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+		EndDate:       std.DateTimeFrom(time.Now().Add(-time.Minute)),
+	}
+
+	assert.NoError(t, engine.SaveCampaign(ended))
+
+	for _, product := range []exchanges.Product{exchanges.NewProduct("BTC", "EUR"), exchanges.NewProduct("ETH", "EUR")} {
+		ticker.broadcaster.Broadcast(&exchanges.TickerEvent{
+			Product: product,
+			Price:   decimal.New(1000, 0),
+			Size:    decimal.New(1, 0),
+			Time:    time.Now(),
+		})
+	}
+
+	// the campaign past its end date is closed by the next tick
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		current, _ := engine.index.Get(2)
+		_, priced := engine.lastPrice("mock", "BTC-EUR")
+
+		if (current.IsClosed() && priced) || time.Now().After(deadline) {
+			assert.Equal(t, entity.CampaignStateClosed, current.State)
+
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, 0, engine.subscribers("mock", "ETH-EUR"))
+
+	for cycle := 1; cycle <= 2; cycle++ {
+		_, err := engine.BuyCampaign(1)
+		assert.NoError(t, err)
+
+		campaign, err = engine.SellCampaign(1)
+		assert.NoError(t, err)
+		assert.Equal(t, cycle, campaign.Cycles)
+	}
+
+	// the last cycle closes the campaign
+	assert.Equal(t, entity.CampaignStateClosed, campaign.State)
+	assert.Equal(t, 0, engine.subscribers("mock", "BTC-EUR"))
+
+	// the counters of the engine are kept on edit and cannot be set by the client
+	edited := &entity.Campaign{
+		ID:            1,
+		Provider:      "mock",
+		ProductID:     "BTC-EUR",
+		Volume:        decimal.New(2, 0),
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+		MaxCycles:     2,
+	}
+
+	assert.NoError(t, engine.SaveCampaign(edited))
+	assert.Equal(t, 2, edited.Cycles)
+	assert.True(t, edited.PnL.Equal(campaign.PnL))
+
+	edited.Cycles = 10
+
+	assert.IsType(t, &ValidationError{}, engine.SaveCampaign(edited))
+
+	edited.Cycles = 2
+	edited.PnL = decimal.New(1000, 0)
+
+	assert.IsType(t, &ValidationError{}, engine.SaveCampaign(edited))
+
+	created := &entity.Campaign{
+		ID:            3,
+		Provider:      "mock",
+		ProductID:     "BTC-EUR",
+		Volume:        decimal.New(1, 0),
+		State:         entity.CampaignStateBuy,
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+		Cycles:        1,
+	}
+
+	assert.IsType(t, &ValidationError{}, engine.SaveCampaign(created))
+
+	_, err := engine.BuyCampaign(1)
+	assert.Error(t, err)
+}
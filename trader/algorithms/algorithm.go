@@ -22,9 +22,26 @@ type Algorithm interface {
 	// Options of Algorithm
 	Options() Options
 
-	// Buy Algorithm
-	Buy(event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries)
+	Signal
+}
+
+// Indicator interface is implemented by algorithms exposing their computed values for charting
+type Indicator interface {
+	// Indicators computed by Algorithm on timeseries
+	Indicators(options Options, ts *timeseries.Timeseries) map[string][]*timeseries.DataPoint
+}
+
+// Signal interface evaluates the decisions of algorithms, the orders are placed by the engine
+type Signal interface {
+	// BuySignal returns true if the algorithm decides to buy
+	BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool
+
+	// SellSignal returns true if the algorithm decides to sell
+	SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool
+}
 
-	// Sell Algorithm
-	Sell(event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries)
+// Validator interface is implemented by algorithms checking their options before a campaign is saved
+type Validator interface {
+	// Validate options of Algorithm
+	Validate(options Options) error
 }
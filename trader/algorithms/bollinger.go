@@ -0,0 +1,217 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/indicators"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	BollingerMode         = "bollinger.mode"
+	BollingerSize         = "bollinger.size"
+	BollingerWidth        = "bollinger.width"
+	BollingerCandlePeriod = "bollinger.candle_period"
+	BollingerSellTarget   = "bollinger.sell_target"
+	BollingerVolumeFactor = "bollinger.volume_factor"
+)
+
+// Errors
+var (
+	ErrBollingerMode  = errors.New("bollinger mode must be reversion or breakout")
+	ErrBollingerSize  = errors.New("bollinger size must be greater than 1")
+	ErrBollingerWidth = errors.New("bollinger width must be positive")
+)
+
+// Bollinger modes
+const (
+	BollingerModeReversion = "reversion"
+	BollingerModeBreakout  = "breakout"
+)
+
+// Bollinger sell targets
+const (
+	BollingerSellTargetMiddle = "middle"
+	BollingerSellTargetUpper  = "upper"
+)
+
+// Bollinger struct
+type Bollinger struct{}
+
+// NewBollinger algorithms
+func NewBollinger() *Bollinger {
+	return &Bollinger{}
+}
+
+// Name implements Algorithm interface
+func (a Bollinger) Name() string {
+	return "bollinger"
+}
+
+// Options implements Algorithm interface
+func (a Bollinger) Options() Options {
+	return Options{
+		BollingerMode:         BollingerModeReversion,
+		BollingerSize:         20,
+		BollingerWidth:        2.0,
+		BollingerCandlePeriod: 60,
+		BollingerSellTarget:   BollingerSellTargetMiddle,
+		BollingerVolumeFactor: 1.5,
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Bollinger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Options())
+}
+
+// Validate implements Validator interface
+func (a Bollinger) Validate(options Options) error {
+	o := a.Options()
+	o.Merge(options)
+
+	switch o.GetString(BollingerMode) {
+	case BollingerModeReversion, BollingerModeBreakout:
+	default:
+		return ErrBollingerMode
+	}
+
+	if o.GetInt(BollingerSize) <= 1 {
+		return ErrBollingerSize
+	}
+
+	if o.GetFloat(BollingerWidth) <= 0 {
+		return ErrBollingerWidth
+	}
+
+	return nil
+}
+
+// bands returns the closed candles and the Bollinger Bands aligned on the last candle
+func (a Bollinger) bands(options Options, ts *timeseries.Timeseries) ([]*timeseries.Candle, *indicators.Bands) {
+	candles := ts.ClosedCandles(int64(options.GetInt(BollingerCandlePeriod)))
+
+	return candles, indicators.Bollinger(timeseries.CandlesClose(candles), options.GetInt(BollingerSize), options.GetFloat(BollingerWidth))
+}
+
+// isVolumeConfirmed returns true if the volume of the last candle is above the average volume
+// of the previous candles multiplied by the volume factor
+func (a Bollinger) isVolumeConfirmed(options Options, candles []*timeseries.Candle) bool {
+	size := options.GetInt(BollingerSize)
+
+	volumes := timeseries.CandlesVolume(candles)
+
+	if len(volumes) < size+1 {
+		return false
+	}
+
+	averages := indicators.SMA(volumes[:len(volumes)-1], size)
+
+	return volumes[len(volumes)-1] >= averages[len(averages)-1]*options.GetFloat(BollingerVolumeFactor)
+}
+
+// BuySignal implements Signal interface, it returns true if the last closed candle matches the entry of the mode
+func (a Bollinger) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	o := a.Options()
+	o.Merge(options)
+
+	candles, bands := a.bands(o, ts)
+
+	length := len(bands.Middle)
+
+	if length == 0 {
+		log.Debug().Msg("there are not enough candles in the time series")
+
+		return false
+	}
+
+	last := candles[len(candles)-1]
+
+	switch o.GetString(BollingerMode) {
+	case BollingerModeReversion:
+		return last.Close < bands.Lower[length-1]
+	case BollingerModeBreakout:
+		return last.Close > bands.Upper[length-1] && a.isVolumeConfirmed(o, candles)
+	default:
+		log.Error().Msgf("bollinger mode (%s) invalid", o.GetString(BollingerMode))
+
+		return false
+	}
+}
+
+// SellSignal implements Signal interface, it returns true if the last closed candle matches the exit of the mode,
+// the breakout mode exits when the close falls back below the middle band
+func (a Bollinger) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	o := a.Options()
+	o.Merge(options)
+
+	candles, bands := a.bands(o, ts)
+
+	length := len(bands.Middle)
+
+	if length == 0 {
+		log.Debug().Msg("there are not enough candles in the time series")
+
+		return false
+	}
+
+	last := candles[len(candles)-1]
+
+	switch o.GetString(BollingerMode) {
+	case BollingerModeReversion:
+		if o.GetString(BollingerSellTarget) == BollingerSellTargetUpper {
+			return last.Close >= bands.Upper[length-1]
+		}
+
+		return last.Close >= bands.Middle[length-1]
+	case BollingerModeBreakout:
+		return last.Close < bands.Middle[length-1]
+	default:
+		log.Error().Msgf("bollinger mode (%s) invalid", o.GetString(BollingerMode))
+
+		return false
+	}
+}
+
+// Indicators implements Indicator interface
+func (a Bollinger) Indicators(options Options, ts *timeseries.Timeseries) map[string][]*timeseries.DataPoint {
+	o := a.Options()
+	o.Merge(options)
+
+	candles, bands := a.bands(o, ts)
+
+	candles = candles[len(candles)-len(bands.Middle):]
+
+	result := map[string][]*timeseries.DataPoint{
+		"upper":  {},
+		"middle": {},
+		"lower":  {},
+	}
+
+	for i, candle := range candles {
+		result["upper"] = append(result["upper"], &timeseries.DataPoint{
+			Time:  candle.Time,
+			Value: bands.Upper[i],
+		})
+
+		result["middle"] = append(result["middle"], &timeseries.DataPoint{
+			Time:  candle.Time,
+			Value: bands.Middle[i],
+		})
+
+		result["lower"] = append(result["lower"], &timeseries.DataPoint{
+			Time:  candle.Time,
+			Value: bands.Lower[i],
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+var bollingerOptions = Options{
+	BollingerSize:         4,
+	BollingerWidth:        1.0,
+	BollingerCandlePeriod: 1,
+	BollingerVolumeFactor: 2.0,
+}
+
+func newBollingerTimeseries(values []float64, volumes []float64) *timeseries.Timeseries {
+	ts := timeseries.New(100)
+
+	for i, v := range values {
+		ts.AddWithVolume(int64(i), v, volumes[i])
+	}
+
+	return ts
+}
+
+func TestBollingerName(t *testing.T) {
+	algo := NewBollinger()
+
+	assert.Equal(t, "bollinger", algo.Name())
+}
+
+func TestBollingerReversion(t *testing.T) {
+	algo := NewBollinger()
+
+	options := algo.Options()
+	options.Merge(bollingerOptions)
+
+	volumes := []float64{1, 1, 1, 1, 1, 1}
+
+	// the last value is the open candle and is ignored
+	assert.True(t, algo.BuySignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 6, 20}, volumes)))
+	assert.False(t, algo.BuySignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 10, 1}, volumes)))
+	assert.False(t, algo.BuySignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 1}, volumes)))
+
+	assert.True(t, algo.SellSignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 11, 1}, volumes)))
+	assert.False(t, algo.SellSignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 9, 20}, volumes)))
+
+	options.Set(BollingerSellTarget, BollingerSellTargetUpper)
+
+	assert.False(t, algo.SellSignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 11, 1}, volumes)))
+	assert.True(t, algo.SellSignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 14, 1}, volumes)))
+}
+
+func TestBollingerBreakout(t *testing.T) {
+	algo := NewBollinger()
+
+	options := algo.Options()
+	options.Merge(bollingerOptions)
+	options.Set(BollingerMode, BollingerModeBreakout)
+
+	values := []float64{10, 11, 10, 11, 10, 15, 1}
+
+	assert.True(t, algo.BuySignal(nil, nil, options, newBollingerTimeseries(values, []float64{1, 1, 1, 1, 1, 3, 1})))
+	assert.False(t, algo.BuySignal(nil, nil, options, newBollingerTimeseries(values, []float64{1, 1, 1, 1, 1, 1, 1})))
+
+	assert.True(t, algo.SellSignal(nil, nil, options, newBollingerTimeseries([]float64{10, 11, 10, 11, 9, 20}, []float64{1, 1, 1, 1, 1, 1})))
+
+	options.Set(BollingerMode, "foo")
+
+	assert.False(t, algo.BuySignal(nil, nil, options, newBollingerTimeseries(values, []float64{1, 1, 1, 1, 1, 3, 1})))
+}
+
+func TestBollingerValidate(t *testing.T) {
+	algo := NewBollinger()
+
+	assert.NoError(t, algo.Validate(nil))
+	assert.NoError(t, algo.Validate(Options{
+		BollingerMode:  BollingerModeBreakout,
+		BollingerSize:  2,
+		BollingerWidth: 0.5,
+	}))
+
+	assert.Equal(t, ErrBollingerMode, algo.Validate(Options{
+		BollingerMode: "foo",
+	}))
+
+	assert.Equal(t, ErrBollingerSize, algo.Validate(Options{
+		BollingerSize: 1,
+	}))
+
+	assert.Equal(t, ErrBollingerWidth, algo.Validate(Options{
+		BollingerWidth: 0,
+	}))
+
+	assert.Equal(t, ErrBollingerWidth, algo.Validate(Options{
+		BollingerWidth: -2.0,
+	}))
+}
+
+func TestBollingerIndicators(t *testing.T) {
+	algo := NewBollinger()
+
+	result := algo.Indicators(bollingerOptions, newBollingerTimeseries([]float64{10, 11, 10, 11, 10, 1}, []float64{1, 1, 1, 1, 1, 1}))
+
+	assert.Equal(t, 2, len(result["middle"]))
+	assert.Equal(t, int64(4), result["middle"][1].Time)
+	assert.Equal(t, 10.5, result["middle"][1].Value)
+	assert.Equal(t, 11.0, result["upper"][1].Value)
+	assert.Equal(t, 10.0, result["lower"][1].Value)
+}
@@ -0,0 +1,286 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	CompositeOperator   = "composite.operator"
+	CompositeThreshold  = "composite.threshold"
+	CompositeAlgorithms = "composite.algorithms"
+)
+
+// Composite operators
+const (
+	CompositeOperatorAnd  = "and"
+	CompositeOperatorOr   = "or"
+	CompositeOperatorVote = "vote"
+)
+
+// Errors
+var (
+	ErrCompositeCycle    = errors.New("composite algorithm cycle detected")
+	ErrCompositeEmpty    = errors.New("composite algorithm without algorithms")
+	ErrCompositeOperator = errors.New("composite operator invalid")
+	ErrCompositeMember   = errors.New("composite algorithm member invalid")
+)
+
+// CompositeMember struct
+type CompositeMember struct {
+	Name    string  `json:"name"`
+	Weight  float64 `json:"weight"`
+	Options Options `json:"options"`
+}
+
+// Composite struct
+type Composite struct {
+	name     string
+	defaults Options
+	manager  Manager
+}
+
+// NewComposite algorithms, the members are resolved by name in manager
+// and defaults overrides the options of the composite.
+func NewComposite(name string, defaults Options, manager Manager) *Composite {
+	return &Composite{
+		name:     name,
+		defaults: defaults,
+		manager:  manager,
+	}
+}
+
+// Name implements Algorithm interface
+func (a Composite) Name() string {
+	return a.name
+}
+
+// Options implements Algorithm interface
+func (a Composite) Options() Options {
+	options := Options{
+		CompositeOperator:   CompositeOperatorAnd,
+		CompositeThreshold:  0.0,
+		CompositeAlgorithms: []interface{}{},
+	}
+
+	options.Merge(a.defaults)
+
+	return options
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Composite) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Options())
+}
+
+// members decodes the composite algorithms option
+func (a Composite) members(options Options) ([]*CompositeMember, error) {
+	value := options.Get(CompositeAlgorithms)
+	if value == nil {
+		return nil, ErrCompositeEmpty
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []*CompositeMember{}
+
+	if err := json.Unmarshal(b, &members); err != nil {
+		return nil, ErrCompositeMember
+	}
+
+	if len(members) == 0 {
+		return nil, ErrCompositeEmpty
+	}
+
+	for _, member := range members {
+		if member.Name == "" {
+			return nil, ErrCompositeMember
+		}
+
+		if member.Weight == 0 {
+			member.Weight = 1
+		}
+	}
+
+	return members, nil
+}
+
+// resolve the options of composite and push it to the evaluation stack,
+// a composite evaluated twice with the same options is a cycle.
+func (a Composite) resolve(options Options, stack []string) (Options, []string, error) {
+	o := a.Options()
+	o.Merge(options)
+
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := a.name + ":" + string(b)
+
+	for _, k := range stack {
+		if k == key {
+			return nil, nil, ErrCompositeCycle
+		}
+	}
+
+	return o, append(stack[:len(stack):len(stack)], key), nil
+}
+
+// Validate implements Validator interface
+func (a Composite) Validate(options Options) error {
+	return a.validate(options, []string{})
+}
+
+func (a Composite) validate(options Options, stack []string) error {
+	o, stack, err := a.resolve(options, stack)
+	if err != nil {
+		return err
+	}
+
+	switch o.GetString(CompositeOperator) {
+	case CompositeOperatorAnd, CompositeOperatorOr, CompositeOperatorVote:
+	default:
+		return ErrCompositeOperator
+	}
+
+	members, err := a.members(o)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		algo, err := a.manager.Get(member.Name)
+		if err != nil {
+			return fmt.Errorf("composite member %s: %v", member.Name, err)
+		}
+
+		if child, ok := algo.(*Composite); ok {
+			if err := child.validate(member.Options, stack); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if validator, ok := algo.(Validator); ok {
+			if err := validator.Validate(member.Options); err != nil {
+				return fmt.Errorf("composite member %s: %v", member.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a Composite) evaluate(
+	side exchanges.SideType,
+	event *exchanges.TickerEvent,
+	campaign *entity.Campaign,
+	options Options,
+	ts *timeseries.Timeseries,
+	stack []string,
+) (bool, error) {
+	o, stack, err := a.resolve(options, stack)
+	if err != nil {
+		return false, err
+	}
+
+	members, err := a.members(o)
+	if err != nil {
+		return false, err
+	}
+
+	operator := o.GetString(CompositeOperator)
+
+	total := float64(0)
+	score := float64(0)
+
+	for _, member := range members {
+		algo, err := a.manager.Get(member.Name)
+		if err != nil {
+			return false, fmt.Errorf("composite member %s: %v", member.Name, err)
+		}
+
+		result := false
+
+		if child, ok := algo.(*Composite); ok {
+			result, err = child.evaluate(side, event, campaign, member.Options, ts, stack)
+			if err != nil {
+				return false, err
+			}
+		} else if side == exchanges.SideTypeBuy {
+			result = algo.BuySignal(event, campaign, member.Options, ts)
+		} else {
+			result = algo.SellSignal(event, campaign, member.Options, ts)
+		}
+
+		total += member.Weight
+
+		if result {
+			score += member.Weight
+		}
+
+		switch {
+		case operator == CompositeOperatorAnd && result == false:
+			return false, nil
+		case operator == CompositeOperatorOr && result:
+			return true, nil
+		}
+	}
+
+	switch operator {
+	case CompositeOperatorAnd:
+		return true, nil
+	case CompositeOperatorOr:
+		return false, nil
+	case CompositeOperatorVote:
+		threshold := o.GetFloat(CompositeThreshold)
+
+		// without threshold the majority of the weights is required
+		if threshold <= 0 {
+			return score > total/2, nil
+		}
+
+		return score >= threshold, nil
+	default:
+		return false, ErrCompositeOperator
+	}
+}
+
+// BuySignal implements Signal interface
+func (a Composite) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	result, err := a.evaluate(exchanges.SideTypeBuy, event, campaign, options, ts, []string{})
+	if err != nil {
+		log.Error().Err(err).Msgf("Evaluate %s algorithm failed", a.name)
+
+		return false
+	}
+
+	return result
+}
+
+// SellSignal implements Signal interface
+func (a Composite) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	result, err := a.evaluate(exchanges.SideTypeSell, event, campaign, options, ts, []string{})
+	if err != nil {
+		log.Error().Err(err).Msgf("Evaluate %s algorithm failed", a.name)
+
+		return false
+	}
+
+	return result
+}
@@ -0,0 +1,198 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+type SignalAlgo struct {
+	MyAlgo
+	name string
+	buy  bool
+	sell bool
+}
+
+// Name implements Algorithm interface
+func (a SignalAlgo) Name() string {
+	return a.name
+}
+
+// BuySignal implements Signal interface
+func (a SignalAlgo) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return a.buy
+}
+
+// SellSignal implements Signal interface
+func (a SignalAlgo) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return a.sell
+}
+
+func newCompositeManager() Manager {
+	m := NewManager()
+
+	m.Add(&SignalAlgo{name: "up", buy: true, sell: true})
+	m.Add(&SignalAlgo{name: "down", buy: false, sell: false})
+	m.Add(NewComposite("composite", nil, m))
+
+	return m
+}
+
+func compositeOptions(t *testing.T, data string) Options {
+	options := Options{}
+
+	assert.NoError(t, json.Unmarshal([]byte(data), &options))
+
+	return options
+}
+
+func TestCompositeOperators(t *testing.T) {
+	m := newCompositeManager()
+
+	algo, err := m.Get("composite")
+	assert.NoError(t, err)
+
+	composite := algo.(*Composite)
+
+	assert.True(t, composite.BuySignal(nil, nil, compositeOptions(t, `{
+		"composite.algorithms": [{"name": "up"}, {"name": "up"}]
+	}`), nil))
+
+	assert.False(t, composite.BuySignal(nil, nil, compositeOptions(t, `{
+		"composite.algorithms": [{"name": "up"}, {"name": "down"}]
+	}`), nil))
+
+	assert.True(t, composite.SellSignal(nil, nil, compositeOptions(t, `{
+		"composite.operator": "or",
+		"composite.algorithms": [{"name": "down"}, {"name": "up"}]
+	}`), nil))
+
+	assert.False(t, composite.SellSignal(nil, nil, compositeOptions(t, `{
+		"composite.operator": "or",
+		"composite.algorithms": [{"name": "down"}, {"name": "down"}]
+	}`), nil))
+}
+
+func TestCompositeVote(t *testing.T) {
+	m := newCompositeManager()
+
+	composite := m["composite"].(*Composite)
+
+	assert.True(t, composite.BuySignal(nil, nil, compositeOptions(t, `{
+		"composite.operator": "vote",
+		"composite.algorithms": [{"name": "up"}, {"name": "up"}, {"name": "down"}]
+	}`), nil))
+
+	assert.False(t, composite.BuySignal(nil, nil, compositeOptions(t, `{
+		"composite.operator": "vote",
+		"composite.algorithms": [{"name": "up"}, {"name": "down", "weight": 2}]
+	}`), nil))
+
+	assert.True(t, composite.BuySignal(nil, nil, compositeOptions(t, `{
+		"composite.operator": "vote",
+		"composite.threshold": 1,
+		"composite.algorithms": [{"name": "up"}, {"name": "down", "weight": 2}]
+	}`), nil))
+
+	assert.False(t, composite.BuySignal(nil, nil, compositeOptions(t, `{
+		"composite.operator": "vote",
+		"composite.threshold": 2,
+		"composite.algorithms": [{"name": "up"}, {"name": "down"}, {"name": "down"}]
+	}`), nil))
+
+	// the threshold of the yaml configuration is an int
+	options := compositeOptions(t, `{
+		"composite.operator": "vote",
+		"composite.algorithms": [{"name": "up"}, {"name": "up"}, {"name": "down"}]
+	}`)
+	options.Set(CompositeThreshold, 3)
+
+	assert.False(t, composite.BuySignal(nil, nil, options, nil))
+}
+
+func TestCompositeNested(t *testing.T) {
+	m := newCompositeManager()
+
+	composite := m["composite"].(*Composite)
+
+	options := compositeOptions(t, `{
+		"composite.algorithms": [
+			{"name": "up"},
+			{"name": "composite", "options": {
+				"composite.operator": "or",
+				"composite.algorithms": [{"name": "down"}, {"name": "up"}]
+			}}
+		]
+	}`)
+
+	assert.NoError(t, composite.Validate(options))
+	assert.True(t, composite.BuySignal(nil, nil, options, nil))
+}
+
+func TestCompositeNamed(t *testing.T) {
+	m := newCompositeManager()
+
+	m.Add(NewComposite("always", compositeOptions(t, `{
+		"composite.operator": "or",
+		"composite.algorithms": [{"name": "up"}]
+	}`), m))
+
+	composite := m["composite"].(*Composite)
+
+	options := compositeOptions(t, `{
+		"composite.algorithms": [{"name": "always"}, {"name": "always"}]
+	}`)
+
+	assert.NoError(t, composite.Validate(options))
+	assert.True(t, composite.BuySignal(nil, nil, options, nil))
+}
+
+func TestCompositeCycle(t *testing.T) {
+	m := newCompositeManager()
+
+	m.Add(NewComposite("a", compositeOptions(t, `{
+		"composite.algorithms": [{"name": "b"}, {"name": "up"}]
+	}`), m))
+
+	m.Add(NewComposite("b", compositeOptions(t, `{
+		"composite.algorithms": [{"name": "a", "options": {"composite.operator": "or"}}]
+	}`), m))
+
+	a := m["a"].(*Composite)
+
+	assert.Equal(t, ErrCompositeCycle, a.Validate(nil))
+
+	_, err := a.evaluate(exchanges.SideTypeBuy, nil, nil, nil, nil, []string{})
+	assert.Equal(t, ErrCompositeCycle, err)
+
+	assert.False(t, a.BuySignal(nil, nil, nil, nil))
+}
+
+func TestCompositeValidate(t *testing.T) {
+	m := newCompositeManager()
+
+	composite := m["composite"].(*Composite)
+
+	assert.Equal(t, ErrCompositeEmpty, composite.Validate(nil))
+
+	assert.Equal(t, ErrCompositeOperator, composite.Validate(compositeOptions(t, `{
+		"composite.operator": "xor",
+		"composite.algorithms": [{"name": "up"}]
+	}`)))
+
+	assert.Equal(t, ErrCompositeMember, composite.Validate(compositeOptions(t, `{
+		"composite.algorithms": [{"weight": 2}]
+	}`)))
+
+	assert.EqualError(t, composite.Validate(compositeOptions(t, `{
+		"composite.algorithms": [{"name": "foo"}]
+	}`)), "composite member foo: algorithm not found")
+}
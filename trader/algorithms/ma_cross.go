@@ -0,0 +1,209 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/indicators"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MACrossType          = "ma_cross.type"
+	MACrossFastSize      = "ma_cross.fast_size"
+	MACrossSlowSize      = "ma_cross.slow_size"
+	MACrossCandlePeriod  = "ma_cross.candle_period"
+	MACrossMinSeparation = "ma_cross.min_separation"
+)
+
+// Errors
+var (
+	ErrMACrossType  = errors.New("ma_cross type must be sma or ema")
+	ErrMACrossSizes = errors.New("ma_cross fast size must be positive and lower than the slow size")
+)
+
+// CrossType type
+type CrossType int
+
+// CrossType enum
+const (
+	CrossTypeDeath CrossType = -1
+	CrossTypeNone  CrossType = 0
+	CrossTypeGold  CrossType = 1
+)
+
+// MACross struct
+type MACross struct{}
+
+// NewMACross algorithms
+func NewMACross() *MACross {
+	return &MACross{}
+}
+
+// Name implements Algorithm interface
+func (a MACross) Name() string {
+	return "ma_cross"
+}
+
+// Options implements Algorithm interface
+func (a MACross) Options() Options {
+	return Options{
+		MACrossType:          string(indicators.MovingAverageTypeSMA),
+		MACrossFastSize:      12,
+		MACrossSlowSize:      26,
+		MACrossCandlePeriod:  60,
+		MACrossMinSeparation: 0.1,
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a MACross) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Options())
+}
+
+// averages returns the fast and slow moving averages on candle closes, aligned on the last candle
+func (a MACross) averages(options Options, candles []*timeseries.Candle) ([]float64, []float64) {
+	t := indicators.MovingAverageType(options.GetString(MACrossType))
+	values := timeseries.CandlesClose(candles)
+
+	fast := indicators.MovingAverage(t, values, options.GetInt(MACrossFastSize))
+	slow := indicators.MovingAverage(t, values, options.GetInt(MACrossSlowSize))
+
+	if len(fast) > len(slow) {
+		fast = fast[len(fast)-len(slow):]
+	} else {
+		slow = slow[len(slow)-len(fast):]
+	}
+
+	return fast, slow
+}
+
+// Validate implements Validator interface
+func (a MACross) Validate(options Options) error {
+	o := a.Options()
+	o.Merge(options)
+
+	switch indicators.MovingAverageType(o.GetString(MACrossType)) {
+	case indicators.MovingAverageTypeSMA, indicators.MovingAverageTypeEMA:
+	default:
+		return ErrMACrossType
+	}
+
+	if fast := o.GetInt(MACrossFastSize); fast < 1 || fast >= o.GetInt(MACrossSlowSize) {
+		return ErrMACrossSizes
+	}
+
+	return nil
+}
+
+// Cross returns the last crossover of the fast and slow moving averages on the closed candle
+// where their separation reaches the minimum for the first time since the crossover
+func (a MACross) Cross(options Options, ts *timeseries.Timeseries) CrossType {
+	fast, slow := a.averages(options, ts.ClosedCandles(int64(options.GetInt(MACrossCandlePeriod))))
+
+	length := len(fast)
+
+	if length < 2 {
+		log.Debug().Msg("there are not enough candles in the time series")
+
+		return CrossTypeNone
+	}
+
+	last := length - 1
+
+	cross := CrossTypeNone
+
+	switch {
+	case fast[last] > slow[last]:
+		cross = CrossTypeGold
+	case fast[last] < slow[last]:
+		cross = CrossTypeDeath
+	default:
+		return CrossTypeNone
+	}
+
+	// the candle of the last crossover, the averages keep the same side after it
+	i := last
+
+	for i > 0 && (fast[i-1]-slow[i-1])*float64(cross) > 0 {
+		i--
+	}
+
+	if i == 0 {
+		return CrossTypeNone
+	}
+
+	min := options.GetFloat(MACrossMinSeparation)
+
+	separation := func(i int) float64 {
+		return math.Abs(fast[i]-slow[i]) / slow[i] * 100
+	}
+
+	if separation(last) < min {
+		return CrossTypeNone
+	}
+
+	// the signal is sent once, on the first candle reaching the separation
+	for ; i < last; i++ {
+		if separation(i) >= min {
+			return CrossTypeNone
+		}
+	}
+
+	return cross
+}
+
+// BuySignal implements Signal interface
+func (a MACross) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	o := a.Options()
+	o.Merge(options)
+
+	return a.Cross(o, ts) == CrossTypeGold
+}
+
+// SellSignal implements Signal interface
+func (a MACross) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	o := a.Options()
+	o.Merge(options)
+
+	return a.Cross(o, ts) == CrossTypeDeath
+}
+
+// Indicators implements Indicator interface
+func (a MACross) Indicators(options Options, ts *timeseries.Timeseries) map[string][]*timeseries.DataPoint {
+	o := a.Options()
+	o.Merge(options)
+
+	candles := ts.ClosedCandles(int64(o.GetInt(MACrossCandlePeriod)))
+
+	fast, slow := a.averages(o, candles)
+
+	candles = candles[len(candles)-len(fast):]
+
+	result := map[string][]*timeseries.DataPoint{
+		"fast": {},
+		"slow": {},
+	}
+
+	for i, candle := range candles {
+		result["fast"] = append(result["fast"], &timeseries.DataPoint{
+			Time:  candle.Time,
+			Value: fast[i],
+		})
+
+		result["slow"] = append(result["slow"], &timeseries.DataPoint{
+			Time:  candle.Time,
+			Value: slow[i],
+		})
+	}
+
+	return result
+}
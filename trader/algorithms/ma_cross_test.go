@@ -0,0 +1,111 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMACrossTimeseries(values ...float64) *timeseries.Timeseries {
+	ts := timeseries.New(100)
+
+	for i, v := range values {
+		ts.Add(int64(i), v)
+	}
+
+	return ts
+}
+
+var maCrossOptions = Options{
+	MACrossFastSize:      2,
+	MACrossSlowSize:      4,
+	MACrossCandlePeriod:  1,
+	MACrossMinSeparation: 0.0,
+}
+
+func TestMACrossName(t *testing.T) {
+	algo := NewMACross()
+
+	assert.Equal(t, "ma_cross", algo.Name())
+}
+
+func TestMACrossCross(t *testing.T) {
+	algo := NewMACross()
+
+	options := algo.Options()
+	options.Merge(maCrossOptions)
+
+	// the last value is the open candle and is ignored
+	assert.Equal(t, CrossTypeGold, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 12, 1)))
+	assert.Equal(t, CrossTypeDeath, algo.Cross(options, newMACrossTimeseries(6, 7, 8, 9, 10, 4, 20)))
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 5, 4)))
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(10, 9, 8)))
+
+	options.Set(MACrossType, "ema")
+
+	assert.Equal(t, CrossTypeGold, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 12, 1)))
+}
+
+func TestMACrossMinSeparation(t *testing.T) {
+	algo := NewMACross()
+
+	options := algo.Options()
+	options.Merge(maCrossOptions)
+	options.Set(MACrossMinSeparation, 50.0)
+
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 12, 1)))
+
+	// the crossover is signaled on the first candle reaching the separation
+	options.Set(MACrossMinSeparation, 10.0)
+
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 6.6, 7.2, 0)))
+	assert.Equal(t, CrossTypeGold, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 6.6, 7.2, 9, 0)))
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(10, 9, 8, 7, 6, 6.6, 7.2, 9, 10, 0)))
+
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(6, 7, 8, 9, 10, 9.4, 8.8, 0)))
+	assert.Equal(t, CrossTypeDeath, algo.Cross(options, newMACrossTimeseries(6, 7, 8, 9, 10, 9.4, 8.8, 7, 0)))
+	assert.Equal(t, CrossTypeNone, algo.Cross(options, newMACrossTimeseries(6, 7, 8, 9, 10, 9.4, 8.8, 7, 6, 0)))
+}
+
+func TestMACrossValidate(t *testing.T) {
+	algo := NewMACross()
+
+	assert.NoError(t, algo.Validate(nil))
+	assert.NoError(t, algo.Validate(Options{
+		MACrossType: "ema",
+	}))
+
+	assert.Equal(t, ErrMACrossType, algo.Validate(Options{
+		MACrossType: "wma",
+	}))
+
+	assert.Equal(t, ErrMACrossSizes, algo.Validate(Options{
+		MACrossFastSize: 26,
+	}))
+
+	assert.Equal(t, ErrMACrossSizes, algo.Validate(Options{
+		MACrossFastSize: 30,
+	}))
+
+	assert.Equal(t, ErrMACrossSizes, algo.Validate(Options{
+		MACrossFastSize: 0,
+	}))
+}
+
+func TestMACrossIndicators(t *testing.T) {
+	algo := NewMACross()
+
+	result := algo.Indicators(maCrossOptions, newMACrossTimeseries(10, 9, 8, 7, 6, 12, 1))
+
+	assert.Equal(t, 3, len(result["fast"]))
+	assert.Equal(t, 3, len(result["slow"]))
+
+	assert.Equal(t, int64(5), result["fast"][2].Time)
+	assert.Equal(t, float64(9), result["fast"][2].Value)
+	assert.Equal(t, 8.25, result["slow"][2].Value)
+}
@@ -32,14 +32,14 @@ func (a MyAlgo) MarshalJSON() ([]byte, error) {
 	return json.Marshal(a.Options())
 }
 
-// Buy implements Algorithm interface
-func (a *MyAlgo) Buy(event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries) {
-
+// BuySignal implements Signal interface
+func (a MyAlgo) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return false
 }
 
-// Sell implements Algorithm interface
-func (a *MyAlgo) Sell(event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries) {
-
+// SellSignal implements Signal interface
+func (a MyAlgo) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return false
 }
 
 func TestManagerNotInit(t *testing.T) {
@@ -23,6 +23,15 @@ func (o Options) Set(key string, value interface{}) {
 	o[strings.ToLower(key)] = value
 }
 
+// Get value
+func (o Options) Get(key string) interface{} {
+	if o == nil {
+		return nil
+	}
+
+	return o[strings.ToLower(key)]
+}
+
 // GetString value
 func (o Options) GetString(key string) string {
 	if o == nil {
@@ -64,14 +73,19 @@ func (o Options) GetInt(key string) int {
 	return 0
 }
 
-// GetFloat value
+// GetFloat value, the integers of the yaml configuration are converted
 func (o Options) GetFloat(key string) float64 {
 	if o == nil {
 		return 0
 	}
 
-	if value, ok := o[strings.ToLower(key)].(float64); ok {
+	switch value := o[strings.ToLower(key)].(type) {
+	case float64:
 		return value
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
 	}
 
 	return 0
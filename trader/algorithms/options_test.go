@@ -94,3 +94,13 @@ func TestOptionsMerge(t *testing.T) {
 	assert.Equal(t, 150.00, defaultOptions.GetFloat("Trend.max_price"))
 	assert.Equal(t, false, defaultOptions.GetBool("status"))
 }
+
+func TestOptionsGetFloatFromInt(t *testing.T) {
+	options := Options{
+		"composite.threshold": 2,
+		"trend.max_price":     int64(150),
+	}
+
+	assert.Equal(t, 2.0, options.GetFloat("composite.threshold"))
+	assert.Equal(t, 150.0, options.GetFloat("Trend.max_price"))
+}
@@ -0,0 +1,270 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/expression"
+	"github.com/euskadi31/cryptotrader/indicators"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ScriptBuy          = "script.buy"
+	ScriptSell         = "script.sell"
+	ScriptCandlePeriod = "script.candle_period"
+)
+
+// Errors
+var (
+	ErrScriptEmpty   = errors.New("script without buy or sell expression")
+	ErrNotEnoughData = errors.New("there are not enough elements in the time series")
+)
+
+// scriptDefinitions of the variables and functions available in scripts
+var scriptDefinitions = &expression.Definitions{
+	Variables: map[string]bool{
+		"price":     true,
+		"volume":    true,
+		"buy_price": true,
+		"margin":    true,
+	},
+	Functions: map[string]int{
+		"sma":      1,
+		"ema":      1,
+		"rsi":      1,
+		"stddev":   1,
+		"bb_upper": 2,
+		"bb_lower": 2,
+		"highest":  1,
+		"lowest":   1,
+		"trend":    1,
+	},
+}
+
+// Script struct
+type Script struct {
+	mu       sync.Mutex
+	programs map[string]*expression.Program
+}
+
+// NewScript algorithms
+func NewScript() *Script {
+	return &Script{
+		programs: make(map[string]*expression.Program),
+	}
+}
+
+// Name implements Algorithm interface
+func (a *Script) Name() string {
+	return "script"
+}
+
+// Options implements Algorithm interface
+func (a *Script) Options() Options {
+	return Options{
+		ScriptBuy:          "",
+		ScriptSell:         "",
+		ScriptCandlePeriod: 60,
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a *Script) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Options())
+}
+
+// compile the source once, the programs are cached by source
+func (a *Script) compile(source string) (*expression.Program, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if program, ok := a.programs[source]; ok {
+		return program, nil
+	}
+
+	program, err := expression.Compile(source, scriptDefinitions)
+	if err != nil {
+		return nil, err
+	}
+
+	if program.Type() != expression.TypeBool {
+		return nil, fmt.Errorf("expression %q is not a condition", source)
+	}
+
+	a.programs[source] = program
+
+	return program, nil
+}
+
+// Validate implements Validator interface
+func (a *Script) Validate(options Options) error {
+	o := a.Options()
+	o.Merge(options)
+
+	sources := map[string]string{
+		ScriptBuy:  o.GetString(ScriptBuy),
+		ScriptSell: o.GetString(ScriptSell),
+	}
+
+	if sources[ScriptBuy] == "" && sources[ScriptSell] == "" {
+		return ErrScriptEmpty
+	}
+
+	for key, source := range sources {
+		if source == "" {
+			continue
+		}
+
+		if _, err := a.compile(source); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *Script) evaluate(key string, event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	o := a.Options()
+	o.Merge(options)
+
+	source := o.GetString(key)
+	if source == "" {
+		return false
+	}
+
+	program, err := a.compile(source)
+	if err != nil {
+		log.Error().Err(err).Msgf("Compile %s failed", key)
+
+		return false
+	}
+
+	result, err := program.Bool(&scriptEnv{
+		event:    event,
+		campaign: campaign,
+		ts:       ts,
+		period:   int64(o.GetInt(ScriptCandlePeriod)),
+	})
+	if err != nil {
+		log.Debug().Err(err).Msgf("Evaluate %s failed", key)
+
+		return false
+	}
+
+	return result
+}
+
+// BuySignal implements Signal interface
+func (a *Script) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return a.evaluate(ScriptBuy, event, campaign, options, ts)
+}
+
+// SellSignal implements Signal interface
+func (a *Script) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return a.evaluate(ScriptSell, event, campaign, options, ts)
+}
+
+// scriptEnv implements expression.Env on the ticker event and the candle closes of timeseries
+type scriptEnv struct {
+	event    *exchanges.TickerEvent
+	campaign *entity.Campaign
+	ts       *timeseries.Timeseries
+	period   int64
+	closes   []float64
+}
+
+func (e *scriptEnv) values() []float64 {
+	if e.closes == nil {
+		e.closes = timeseries.CandlesClose(e.ts.ClosedCandles(e.period))
+	}
+
+	return e.closes
+}
+
+// Variable implements expression.Env
+func (e *scriptEnv) Variable(name string) (float64, error) {
+	switch name {
+	case "price":
+		return e.event.Price.InexactFloat64(), nil
+	case "volume":
+		return e.event.Size.InexactFloat64(), nil
+	case "buy_price":
+		if e.campaign == nil || e.campaign.BuyOrder == nil {
+			return 0, nil
+		}
+
+		return e.campaign.BuyOrder.GetBuyingMarketPrice().InexactFloat64(), nil
+	case "margin":
+		if e.campaign == nil || e.campaign.BuyOrder == nil {
+			return 0, nil
+		}
+
+		return e.campaign.BuyOrder.GetMarginInPercent(e.event.Price).InexactFloat64(), nil
+	default:
+		return 0, fmt.Errorf("unknown variable %s", name)
+	}
+}
+
+func last(values []float64) (float64, error) {
+	if len(values) == 0 {
+		return 0, ErrNotEnoughData
+	}
+
+	return values[len(values)-1], nil
+}
+
+// Call implements expression.Env
+func (e *scriptEnv) Call(name string, args ...float64) (float64, error) {
+	size := int(args[0])
+
+	switch name {
+	case "sma":
+		return last(indicators.SMA(e.values(), size))
+	case "ema":
+		return last(indicators.EMA(e.values(), size))
+	case "rsi":
+		return last(indicators.RSI(e.values(), size))
+	case "stddev":
+		return last(indicators.StdDev(e.values(), size))
+	case "bb_upper":
+		return last(indicators.Bollinger(e.values(), size, args[1]).Upper)
+	case "bb_lower":
+		return last(indicators.Bollinger(e.values(), size, args[1]).Lower)
+	case "highest", "lowest":
+		values := e.values()
+
+		if size <= 0 || len(values) < size {
+			return 0, ErrNotEnoughData
+		}
+
+		result := values[len(values)-size]
+
+		for _, v := range values[len(values)-size:] {
+			if (name == "highest" && v > result) || (name == "lowest" && v < result) {
+				result = v
+			}
+		}
+
+		return result, nil
+	case "trend":
+		if size <= 0 || e.ts.Size() < size {
+			return 0, ErrNotEnoughData
+		}
+
+		trend, err := e.ts.GetTrending(size)
+
+		return float64(trend), err
+	default:
+		return 0, fmt.Errorf("unknown function %s", name)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package algorithms
+
+import (
+	"testing"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptName(t *testing.T) {
+	algo := NewScript()
+
+	assert.Equal(t, "script", algo.Name())
+}
+
+func TestScriptValidate(t *testing.T) {
+	algo := NewScript()
+
+	assert.Equal(t, ErrScriptEmpty, algo.Validate(nil))
+
+	assert.NoError(t, algo.Validate(Options{
+		ScriptBuy: "rsi(14) < 30 && price < sma(50) * 0.97",
+	}))
+
+	assert.EqualError(t, algo.Validate(Options{
+		ScriptSell: "rsi(14) < ",
+	}), "script.sell: unexpected end of expression at position 10")
+
+	assert.EqualError(t, algo.Validate(Options{
+		ScriptBuy: "sma(14) * 2",
+	}), `script.buy: expression "sma(14) * 2" is not a condition`)
+}
+
+func TestScriptSignal(t *testing.T) {
+	algo := NewScript()
+
+	ts := newMACrossTimeseries(10, 9, 8, 7, 6, 12, 1)
+
+	options := Options{
+		ScriptBuy:          "price < sma(2) && lowest(3) == 6",
+		ScriptSell:         "margin >= 10 && highest(3) > buy_price",
+		ScriptCandlePeriod: 1,
+	}
+
+	event := &exchanges.TickerEvent{
+		Price: decimal.New(8, 0),
+	}
+
+	assert.True(t, algo.BuySignal(event, &entity.Campaign{}, options, ts))
+
+	event.Price = decimal.New(10, 0)
+
+	assert.False(t, algo.BuySignal(event, &entity.Campaign{}, options, ts))
+
+	campaign := &entity.Campaign{
+		BuyOrder: &entity.Order{
+			Size:  decimal.New(1, 0),
+			Price: decimal.New(9, 0),
+		},
+	}
+
+	assert.True(t, algo.SellSignal(event, campaign, options, ts))
+
+	event.Price = decimal.RequireFromString("9.5")
+
+	assert.False(t, algo.SellSignal(event, campaign, options, ts))
+
+	// not enough data
+	options.Set(ScriptBuy, "rsi(50) < 30")
+
+	assert.False(t, algo.BuySignal(event, &entity.Campaign{}, options, ts))
+}
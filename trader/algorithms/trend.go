@@ -9,7 +9,6 @@ import (
 
 	"github.com/euskadi31/cryptotrader/database/entity"
 	"github.com/euskadi31/cryptotrader/exchanges"
-	"github.com/euskadi31/cryptotrader/services"
 	"github.com/euskadi31/cryptotrader/timeseries"
 	"github.com/rs/zerolog/log"
 )
@@ -20,17 +19,11 @@ const (
 )
 
 // Trend struct
-type Trend struct {
-	campaignService services.CampaignServiceSave
-	orderService    services.OrderServiceSave
-}
+type Trend struct{}
 
 // NewTrend algorithms
-func NewTrend(campaignService services.CampaignServiceSave, orderService services.OrderServiceSave) *Trend {
-	return &Trend{
-		campaignService: campaignService,
-		orderService:    orderService,
-	}
+func NewTrend() *Trend {
+	return &Trend{}
 }
 
 // Name implements Algorithm interface
@@ -51,85 +44,46 @@ func (a Trend) MarshalJSON() ([]byte, error) {
 	return json.Marshal(a.Options())
 }
 
-// Buy implements Algorithm interface
-func (a *Trend) Buy(event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries) {
-	if event.Price >= campaign.BuyLimit {
-		return
-	}
-
-	if event.Price >= campaign.BuyLimit {
-		return
-	}
-
-	options := a.Options()
-	options.Merge(campaign.BuyAlgorithmOptions)
-
-	campaign.State = entity.CampaignStateBuying
-
-	if err := a.campaignService.Save(campaign); err != nil {
-		log.Error().Err(err).Msg("Save Campaign")
-	}
-
-	log.Warn().Msgf("Buying %f %s at %f %s", campaign.Volume, event.Product.From, event.Price, event.Product.To)
-
-	// emulate buying start ----
-	order := &entity.Order{
-		Provider:  campaign.Provider,
-		Side:      exchanges.SideTypeBuy,
-		ProductID: campaign.ProductID,
-		Size:      campaign.Volume,
-		Price:     campaign.Volume * event.Price,
-	}
-
-	if err := a.orderService.Save(order); err != nil {
-		log.Error().Err(err).Msg("save order failed")
-
-		return
-	}
-
-	campaign.State = entity.CampaignStateSell
-
-	campaign.BuyOrder = order
-	// campaign.AddOrder(order)
+// BuySignal implements Signal interface
+func (a Trend) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	return event.Price.LessThan(campaign.BuyLimit)
+}
 
-	if err := a.campaignService.Save(campaign); err != nil {
-		log.Error().Err(err).Msg("Save Campaign")
+// SellSignal implements Signal interface
+func (a Trend) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options Options, ts *timeseries.Timeseries) bool {
+	if campaign.BuyOrder == nil {
+		return false
 	}
-	// end emulate
-
-}
 
-// Sell implements Algorithm interface
-func (a *Trend) Sell(event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries) {
 	switch campaign.SellLimitUnit {
 	case "percent":
 		log.Debug().Msgf("Current Price: %v", event.Price)
 		log.Debug().
-			Float64("buy_price", campaign.BuyOrder.GetBuyingMarketPrice()).
-			Float64("current_price", campaign.BuyOrder.GetCurrentPrice(event.Price)).
+			Str("buy_price", campaign.BuyOrder.GetBuyingMarketPrice().String()).
+			Str("current_price", campaign.BuyOrder.GetCurrentPrice(event.Price).String()).
 			Msgf("Margin in %%: %v", campaign.BuyOrder.GetMarginInPercent(event.Price))
 
-		if campaign.BuyOrder.GetMarginInPercent(event.Price) < campaign.SellLimit {
-			return
+		if campaign.BuyOrder.GetMarginInPercent(event.Price).LessThan(campaign.SellLimit) {
+			return false
 		}
 	case "currency":
 		log.Debug().Msgf("Current Price: %v", event.Price)
 		log.Debug().Msgf("Margin in €: %v", campaign.BuyOrder.GetMarginInCurrency(event.Price))
 
-		if campaign.BuyOrder.GetMarginInCurrency(event.Price) < campaign.SellLimit {
-			return
+		if campaign.BuyOrder.GetMarginInCurrency(event.Price).LessThan(campaign.SellLimit) {
+			return false
 		}
 	default:
 		log.Error().Msgf("campaign sell limit unit (%s) invalid", campaign.SellLimitUnit)
 
-		return
+		return false
 	}
 
-	options := a.Options()
-	options.Merge(campaign.SellAlgorithmOptions)
+	o := a.Options()
+	o.Merge(options)
 
-	longTrendSize := options.GetInt(TrendSellingLongTrendSize)
-	shortTrendSize := options.GetInt(TrendSellingShortTrendSize)
+	longTrendSize := o.GetInt(TrendSellingLongTrendSize)
+	shortTrendSize := o.GetInt(TrendSellingShortTrendSize)
 
 	historyMaxSize := longTrendSize
 
@@ -140,40 +94,28 @@ func (a *Trend) Sell(event *exchanges.TickerEvent, campaign *entity.Campaign, ts
 	if ts.Size() < historyMaxSize {
 		log.Debug().Msg("there are not enough elements in the time series")
 
-		return
+		return false
 	}
 
 	longTrend, err := ts.GetTrending(longTrendSize)
 	if err != nil {
 		log.Error().Err(err).Msg("GetTrending failed")
 
-		return
+		return false
 	}
 
 	shortTrend, err := ts.GetTrending(shortTrendSize)
 	if err != nil {
 		log.Error().Err(err).Msg("GetTrending failed")
 
-		return
+		return false
 	}
 
 	if longTrend != timeseries.TrendTypeIncreasing && shortTrend != timeseries.TrendTypeDecreasing {
 		log.Debug().Msg("Not match trend model")
 
-		return
+		return false
 	}
 
-	campaign.State = entity.CampaignStateSelling
-
-	if err := a.campaignService.Save(campaign); err != nil {
-		log.Error().Err(err).Msg("Save Campaign")
-	}
-
-	log.Warn().Msgf("Selling %f %s at %f %s", campaign.Volume, event.Product.From, event.Price, event.Product.To)
-
-	campaign.State = entity.CampaignStateBuy
-
-	if err := a.campaignService.Save(campaign); err != nil {
-		log.Error().Err(err).Msg("Save Campaign")
-	}
+	return true
 }
@@ -11,13 +11,13 @@ import (
 )
 
 func TestTrendName(t *testing.T) {
-	algo := NewTrend(nil, nil)
+	algo := NewTrend()
 
 	assert.Equal(t, "trend", algo.Name())
 }
 
 func TestTrendOptions(t *testing.T) {
-	algo := NewTrend(nil, nil)
+	algo := NewTrend()
 
 	assert.Equal(t, Options{
 		"trend.selling.long_trend_size":  150,
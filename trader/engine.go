@@ -5,41 +5,88 @@
 package trader
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/asdine/storm"
 	"github.com/asdine/storm/q"
+	"github.com/euskadi31/cryptotrader/database"
 	"github.com/euskadi31/cryptotrader/database/entity"
 	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/risk"
 	"github.com/euskadi31/cryptotrader/timeseries"
 	"github.com/euskadi31/cryptotrader/trader/algorithms"
 	"github.com/euskadi31/go-eventemitter"
 	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
 )
 
-// RunTickerEvent struct
-type RunTickerEvent struct {
-	Provider string
+const defaultAlgorithm = "trend"
+
+// Errors
+var (
+	// ErrEngineStopped is returned when a product is subscribed after Shutdown
+	ErrEngineStopped = errors.New("trader engine is stopped")
+	// ErrCampaignPositionOpen is returned when a campaign holding a position is deleted without force
+	ErrCampaignPositionOpen = errors.New("campaign has an open position, close it or force the deletion")
+	// ErrCampaignWithoutBuyOrder is returned when a campaign without buy order is sold
+	ErrCampaignWithoutBuyOrder = errors.New("cannot sell campaign without buy order")
+)
+
+// Options of Engine
+type Options struct {
+	// FeedTimeout halts the trading when a feed does not receive events during this duration
+	FeedTimeout time.Duration
+	// MaxOrderErrors halts the trading after this number of consecutive order errors
+	MaxOrderErrors int
+	// Fees schedules by provider
+	Fees exchanges.FeeSchedules
+	// RejectionCooldown records again an unchanged rejection of a campaign after this duration,
+	// 0 records it once until an order of the campaign is accepted
+	RejectionCooldown time.Duration
 }
 
-// SubscribeProductEvent struct
-type SubscribeProductEvent struct {
-	Provider string
-	Products []exchanges.Product
+// rejectionMark of the last rejection recorded for a campaign side
+type rejectionMark struct {
+	rule string
+	at   time.Time
 }
 
 // Engine struct
 type Engine struct {
+	// mu is read locked by the workers while trading and locked by halt and resume
+	mu sync.RWMutex
+	// orderMu serializes the risk checks and the orders of the workers, it protects rejections
+	orderMu     sync.Mutex
+	rejections  map[string]*rejectionMark
+	haltMu      sync.RWMutex
+	feedMu      sync.Mutex
+	options     *Options
+	halt        HaltState
+	lastEvents  map[string]time.Time
+	orderErrors int
 	db          *storm.DB
 	providers   exchanges.Manager
 	algorithms  algorithms.Manager
+	risk        *risk.Manager
 	emitter     eventemitter.EventEmitter
-	tickers     map[string]exchanges.TickerProvider
-	timeseries  map[string]*timeseries.Timeseries
-	runTickerCh chan *RunTickerEvent
-	productsCh  chan *SubscribeProductEvent
-	doneCh      chan bool
+	index       *campaignIndex
+	orders      *database.OrderRepository
+	volumes     *volumeTracker
+	// stateMu protects the feeds, the workers, the references on the products,
+	// the queued unsubscriptions and stopped
+	stateMu      sync.RWMutex
+	feeds        map[string]*feed
+	workers      map[string]*worker
+	refs         map[string]int
+	unsubscribes []func()
+	stopped      bool
+	doneCh       chan bool
+	stopOnce     sync.Once
+	wg           sync.WaitGroup
 }
 
 // NewEngine trader
@@ -47,296 +94,355 @@ func NewEngine(
 	db *storm.DB,
 	providers exchanges.Manager,
 	algorithms algorithms.Manager,
+	riskManager *risk.Manager,
 	emitter eventemitter.EventEmitter,
+	options *Options,
 ) *Engine {
-	return &Engine{
-		db:          db,
-		providers:   providers,
-		algorithms:  algorithms,
-		emitter:     emitter,
-		tickers:     make(map[string]exchanges.TickerProvider),
-		timeseries:  make(map[string]*timeseries.Timeseries),
-		runTickerCh: make(chan *RunTickerEvent),
-		productsCh:  make(chan *SubscribeProductEvent),
-		doneCh:      make(chan bool),
+	if options == nil {
+		options = &Options{}
 	}
-}
-
-/*
-func (e *Engine) tradeBuying(provider string, event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries) {
-	if event.Price < campaign.BuyLimit {
-		campaign.State = entity.CampaignStateBuying
 
-		if err := e.db.Save(campaign); err != nil {
-			log.Error().Err(err).Msg("Save Campaign")
-		}
-
-		log.Warn().Msgf("Buying %f %s at %f %s", campaign.Volume, event.Product.From, event.Price, event.Product.To)
-
-		// emulate buying start ----
-		order := &entity.Order{
-			Provider:  campaign.Provider,
-			Side:      exchanges.SideTypeBuy,
-			ProductID: campaign.ProductID,
-			Size:      campaign.Volume,
-			Price:     campaign.Volume * event.Price,
-		}
-
-		if err := e.db.Save(order); err != nil {
-			log.Error().Err(err).Msg("save order failed")
+	orders := database.NewOrderRepository(db)
 
-			return
-		}
-
-		campaign.State = entity.CampaignStateSell
-
-		campaign.BuyOrder = order
-		// campaign.AddOrder(order)
-
-		if err := e.db.Save(campaign); err != nil {
-			log.Error().Err(err).Msg("Save Campaign")
-		}
-		// end emulate
+	return &Engine{
+		options:    options,
+		lastEvents: make(map[string]time.Time),
+		rejections: make(map[string]*rejectionMark),
+		db:         db,
+		providers:  providers,
+		algorithms: algorithms,
+		risk:       riskManager,
+		emitter:    emitter,
+		index:      newCampaignIndex(),
+		orders:     orders,
+		volumes:    newVolumeTracker(orders, 30*24*time.Hour),
+		feeds:      make(map[string]*feed),
+		workers:    make(map[string]*worker),
+		refs:       make(map[string]int),
+		doneCh:     make(chan bool),
 	}
 }
 
-func (e *Engine) tradeSelling(provider string, event *exchanges.TickerEvent, campaign *entity.Campaign, ts *timeseries.Timeseries) {
-	switch campaign.SellLimitUnit {
-	case "percent":
-		log.Debug().Msgf("Current Price: %v", event.Price)
-		log.Debug().Msgf("Margin: %v", campaign.BuyOrder.GetMarginInPercent(event.Price))
-
-		if campaign.BuyOrder.GetMarginInPercent(event.Price) < campaign.SellLimit {
-			return
-		}
-
-		if ts.Size() < 150 {
-			log.Debug().Msg("there are not enough elements in the time series")
-
-			return
-		}
-
-		a, err := ts.GetTrending(150)
-		if err != nil {
-			log.Error().Err(err).Msg("GetTrending failed")
-
-			return
-		}
-
-		b, err := ts.GetTrending(10)
-		if err != nil {
-			log.Error().Err(err).Msg("GetTrending failed")
-
-			return
-		}
-
-		if a != timeseries.TrendTypeIncreasing && b != timeseries.TrendTypeDecreasing {
-			log.Debug().Msg("Not match trend model")
-
-			return
-		}
-
-		campaign.State = entity.CampaignStateSelling
-
-		if err := e.db.Save(campaign); err != nil {
-			log.Error().Err(err).Msg("Save Campaign")
-		}
-
-		log.Warn().Msgf("Selling %f %s at %f %s", campaign.Volume, event.Product.From, event.Price, event.Product.To)
-
-		campaign.State = entity.CampaignStateBuy
-
-		if err := e.db.Save(campaign); err != nil {
-			log.Error().Err(err).Msg("Save Campaign")
-		}
-	default:
-		log.Error().Msgf("campaign sell limit unit (%s) invalid", campaign.SellLimitUnit)
+// getAlgorithm by name, the trend algorithm is used when the campaign does not define one
+func (e *Engine) getAlgorithm(name string) (algorithms.Algorithm, error) {
+	if name == "" {
+		name = defaultAlgorithm
 	}
+
+	return e.algorithms.Get(name)
 }
-*/
 
+// trade runs the campaigns of the event product, the workers trade their products concurrently
 func (e *Engine) trade(provider string, event *exchanges.TickerEvent, ts *timeseries.Timeseries) {
-	query := e.db.Select(
-		q.Eq("Provider", provider),
-		q.Eq("ProductID", event.Product.String()),
-		q.In("State", []entity.CampaignState{
-			entity.CampaignStateBuy,
-			entity.CampaignStateSell,
-		}),
-	)
-
-	var campaigns []*entity.Campaign
-
-	if err := query.Find(&campaigns); err != nil && err != storm.ErrNotFound {
-		log.Error().Err(err).Msg("Find campaigns")
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 
+	if e.IsHalted() {
 		return
 	}
 
+	campaigns := e.index.Find(provider, event.Product.String(), entity.CampaignStateBuy, entity.CampaignStateSell)
+
 	for _, campaign := range campaigns {
-		campaign.Orders = []*entity.Order{}
+		if campaign.IsState(entity.CampaignStateBuy) {
+			// the campaign past its end date does not start a new cycle
+			if campaign.Finished(time.Now()) {
+				e.orderMu.Lock()
 
-		// todo populate order into campaign
+				if current, ok := e.index.Get(campaign.ID); ok && current.IsState(entity.CampaignStateBuy) {
+					if err := e.closeCampaign(current); err != nil {
+						log.Error().Err(err).Msg("Close Campaign")
+					}
+				}
 
-		if campaign.IsState(entity.CampaignStateBuy) {
-			// @TODO: use campaign.BuyAlgorithm for get algo
-			algo, err := e.algorithms.Get("trend")
+				e.orderMu.Unlock()
+
+				e.sendUnsubscribes()
+
+				continue
+			}
+
+			algo, err := e.getAlgorithm(campaign.BuyAlgorithm)
 			if err != nil {
 				log.Error().Err(err).Msg("Get algo failed")
 
 				continue
 			}
 
-			algo.Buy(event, campaign, ts)
-			// e.tradeBuying(provider, event, campaign, ts)
+			if algo.BuySignal(event, campaign, campaign.BuyAlgorithmOptions, ts) {
+				e.orderMu.Lock()
+
+				// the campaign can be paused or bought by an action since the lookup
+				if current, ok := e.index.Get(campaign.ID); ok && current.IsState(entity.CampaignStateBuy) {
+					e.buy(event, current)
+				}
+
+				e.orderMu.Unlock()
+			}
 		} else {
-			// @TODO: use campaign.SellAlgorithm for get algo
-			algo, err := e.algorithms.Get("trend")
+			algo, err := e.getAlgorithm(campaign.SellAlgorithm)
 			if err != nil {
 				log.Error().Err(err).Msg("Get algo failed")
 
 				continue
 			}
 
-			algo.Sell(event, campaign, ts)
+			if algo.SellSignal(event, campaign, campaign.SellAlgorithmOptions, ts) {
+				e.orderMu.Lock()
+
+				if current, ok := e.index.Get(campaign.ID); ok && current.IsState(entity.CampaignStateSell) {
+					e.sell(event, current)
+				}
+
+				e.orderMu.Unlock()
 
-			// e.tradeSelling(provider, event, campaign, ts)
+				// the campaign is closed after its last sell
+				e.sendUnsubscribes()
+			}
 		}
 	}
 
 	msg := log.Info().
 		Str("side", string(event.Side)).
 		Int("campaigns", len(campaigns)).
-		Float64("volume", event.Size) /*.
+		Str("volume", event.Size.String()) /*.
 		Time("time", event.Time)*/
 
 	if event.Side == exchanges.SideTypeSell {
-		msg.Msgf("%s Price: %f ↘", event.Product.From, event.Price)
+		msg.Msgf("%s Price: %s ↘", event.Product.From, event.Price)
 	} else {
-		msg.Msgf("%s Price: %f ↗", event.Product.From, event.Price)
+		msg.Msgf("%s Price: %s ↗", event.Product.From, event.Price)
 	}
 }
 
-func (e *Engine) processEventChannel() {
-	for {
-		select {
-		case evt := <-e.runTickerCh:
-			log.Debug().Msgf("Run %s Ticker", evt.Provider)
+// validateCampaign checks the campaign limits, its algorithms and their options
+func (e *Engine) validateCampaign(campaign *entity.Campaign) error {
+	if campaign.MaxCycles < 0 {
+		return &ValidationError{
+			Err: errors.New("max cycles must be positive"),
+		}
+	}
 
-			go func() {
-				ticker := e.tickers[evt.Provider]
+	if campaign.ProfitTarget.IsNegative() {
+		return &ValidationError{
+			Err: errors.New("profit target must be positive"),
+		}
+	}
 
-				for event := range ticker.Channel() {
-					if event.Time.IsZero() {
-						continue
-					}
+	algos := []struct {
+		name    string
+		options algorithms.Options
+	}{
+		{campaign.BuyAlgorithm, campaign.BuyAlgorithmOptions},
+		{campaign.SellAlgorithm, campaign.SellAlgorithmOptions},
+	}
 
-					key := fmt.Sprintf("%s-%s", evt.Provider, event.Product.String())
+	for _, a := range algos {
+		algo, err := e.getAlgorithm(a.name)
+		if err != nil {
+			return &ValidationError{
+				Err: fmt.Errorf("algorithm %s: %v", a.name, err),
+			}
+		}
 
-					ts, ok := e.timeseries[key]
-					if ok == false {
-						log.Error().Msgf("Cannot get timeserie for %s", key)
+		validator, ok := algo.(algorithms.Validator)
+		if ok == false {
+			continue
+		}
 
-						continue
-					}
+		if err := validator.Validate(a.options); err != nil {
+			return &ValidationError{
+				Err: fmt.Errorf("algorithm %s: %v", algo.Name(), err),
+			}
+		}
+	}
 
-					ts.Add(event.Time.Unix(), event.Price)
+	return e.validateProduct(campaign)
+}
 
-					e.trade(evt.Provider, event, ts)
+// validateProduct checks the campaign volume against the trading rules of its product
+func (e *Engine) validateProduct(campaign *entity.Campaign) error {
+	product, err := e.providers.Product(campaign.Provider, campaign.ProductID)
 
-					e.emitter.Dispatch(fmt.Sprintf("ticker-%s", key), event)
-				}
-			}()
+	switch err {
+	case nil:
+	case exchanges.ErrProductsNotSupported:
+		return nil
+	case exchanges.ErrProductNotFound, exchanges.ErrProviderNotFound:
+		return &ValidationError{
+			Err: fmt.Errorf("product %s on %s: %v", campaign.ProductID, campaign.Provider, err),
+		}
+	default:
+		return err
+	}
 
-		case evt := <-e.productsCh:
-			productsList := []string{}
+	if err := product.ValidateSize(product.RoundSize(campaign.Volume)); err != nil {
+		return &ValidationError{
+			Err: err,
+		}
+	}
 
-			for _, product := range evt.Products {
-				productsList = append(productsList, product.String())
+	return nil
+}
 
-				key := fmt.Sprintf("%s-%s", evt.Provider, product.String())
+// SaveCampaign to engine, the campaign holds a reference on its product until it is closed
+func (e *Engine) SaveCampaign(campaign *entity.Campaign) error {
+	if err := e.validateCampaign(campaign); err != nil {
+		return err
+	}
 
-				if _, ok := e.timeseries[key]; ok == false {
-					e.timeseries[key] = timeseries.New(5000)
-				}
-			}
+	// the released product is unsubscribed after the locks
+	defer e.sendUnsubscribes()
 
-			log.Debug().Msgf("Subscribe to product %s on %s exchange", strings.Join(productsList, ", "), evt.Provider)
+	// the campaign is not saved while a worker or an action is changing it
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 
-			if ticker, ok := e.tickers[evt.Provider]; ok {
-				if err := ticker.Subscribe(evt.Products...); err != nil {
-					log.Error().Err(err).Msgf("Subscribe to product %v", strings.Join(productsList, ", "))
-				}
-			}
+	e.orderMu.Lock()
+	defer e.orderMu.Unlock()
+
+	previous, edit := e.index.Get(campaign.ID)
+
+	// the cycles and the pnl of the campaign are counted by the engine
+	cycles := 0
+	pnl := decimal.Zero
 
-		case <-e.doneCh:
-			return
+	if edit {
+		cycles = previous.Cycles
+		pnl = previous.PnL
+	}
+
+	if (campaign.Cycles != 0 && campaign.Cycles != cycles) || (campaign.PnL.IsZero() == false && campaign.PnL.Equal(pnl) == false) {
+		return &ValidationError{
+			Err: errors.New("cycles and pnl are counted by the engine"),
 		}
 	}
-}
 
-func (e *Engine) initProvider(name string) error {
-	// provider already init
-	if _, ok := e.tickers[name]; ok {
-		return nil
+	campaign.Cycles = cycles
+	campaign.PnL = pnl
+
+	// the orders of the campaign are not edited
+	campaign.Orders = nil
+	campaign.BuyOrder = nil
+	campaign.BuyOrderID = 0
+	campaign.SellOrder = nil
+	campaign.SellOrderID = 0
+
+	// the state and the orders of the campaign are owned by the engine, they change only through the actions
+	// and a new campaign starts by buying
+	if edit {
+		campaign.State = previous.State
+		campaign.PausedState = previous.PausedState
+		campaign.BuyOrder = previous.BuyOrder
+		campaign.BuyOrderID = previous.BuyOrderID
+		campaign.SellOrder = previous.SellOrder
+		campaign.SellOrderID = previous.SellOrderID
+	} else {
+		campaign.State = entity.CampaignStateBuy
+		campaign.PausedState = ""
 	}
 
-	exchange, err := e.providers.Get(name)
-	if err != nil {
+	if err := e.saveCampaign(campaign); err != nil {
 		return err
 	}
 
-	e.tickers[name] = exchange.Ticker()
+	held := edit && previous.IsClosed() == false
 
-	e.runTickerCh <- &RunTickerEvent{
-		Provider: name,
+	if held && campaign.IsClosed() == false && previous.Provider == campaign.Provider && previous.ProductID == campaign.ProductID {
+		return nil
 	}
 
-	return nil
-}
-
-func (e *Engine) subscribeProduct(name string, products []exchanges.Product) error {
-	// provider already init
-	if _, ok := e.tickers[name]; ok == false {
-		if err := e.initProvider(name); err != nil {
+	if campaign.IsClosed() == false {
+		if err := e.subscribeProduct(campaign.Provider, []exchanges.Product{
+			exchanges.NewProductFromString(campaign.ProductID),
+		}); err != nil {
 			return err
 		}
 	}
 
-	e.productsCh <- &SubscribeProductEvent{
-		Provider: name,
-		Products: products,
+	if held {
+		return e.queueUnsubscribe(previous.Provider, []exchanges.Product{
+			exchanges.NewProductFromString(previous.ProductID),
+		})
 	}
 
 	return nil
 }
 
-// SaveCampaign to engine
-func (e *Engine) SaveCampaign(campaign *entity.Campaign) error {
-	edit := false
-
-	if campaign.ID > 0 {
-		edit = true
+// saveCampaign persists the campaign when it changed and updates the index,
+// the campaign references its orders which are stored in their own bucket
+func (e *Engine) saveCampaign(campaign *entity.Campaign) error {
+	if e.index.Changed(campaign) == false {
+		return nil
 	}
 
-	if err := e.db.Save(campaign); err != nil {
+	row := campaign.Row()
+
+	if err := e.db.Save(row); err != nil {
 		return err
 	}
 
-	if edit == false {
-		if err := e.subscribeProduct(campaign.Provider, []exchanges.Product{
-			exchanges.NewProductFromString(campaign.ProductID),
-		}); err != nil {
+	campaign.ID = row.ID
+
+	e.index.Put(campaign)
+
+	return nil
+}
+
+// Campaigns of the engine with their current buy and sell orders, sorted by id
+func (e *Engine) Campaigns() []*entity.Campaign {
+	return e.index.ByState(
+		entity.CampaignStateBuy,
+		entity.CampaignStateBuying,
+		entity.CampaignStateSell,
+		entity.CampaignStateSelling,
+		entity.CampaignStatePaused,
+		entity.CampaignStateClosed,
+	)
+}
+
+// DeleteCampaign from engine and release its product, a campaign holding a position is deleted only with force
+func (e *Engine) DeleteCampaign(id int, force bool) (*entity.Campaign, error) {
+	return e.action(id, func(campaign *entity.Campaign) error {
+		if campaign.HasPosition() && force == false {
+			return ErrCampaignPositionOpen
+		}
+
+		if err := e.db.DeleteStruct(campaign); err != nil {
 			return err
 		}
-	}
 
-	return nil
+		e.index.Remove(campaign.ID)
+
+		if campaign.IsClosed() {
+			return nil
+		}
+
+		return e.queueUnsubscribe(campaign.Provider, []exchanges.Product{
+			exchanges.NewProductFromString(campaign.ProductID),
+		})
+	})
 }
 
 // Start engine
 func (e *Engine) Start() error {
-	go e.processEventChannel()
+	if err := e.loadHalt(); err != nil {
+		return err
+	}
+
+	e.stateMu.Lock()
+
+	if e.stopped {
+		e.stateMu.Unlock()
+
+		return ErrEngineStopped
+	}
+
+	e.wg.Add(1)
+
+	go e.watchFeeds()
+
+	e.stateMu.Unlock()
 
 	var campaigns []*entity.Campaign
 
@@ -344,45 +450,120 @@ func (e *Engine) Start() error {
 		return err
 	}
 
-	providers := map[string]map[string]exchanges.Product{}
+	if err := e.orders.Resolve(campaigns...); err != nil {
+		return err
+	}
+
+	// each campaign holds a reference on its product until it is closed
+	providers := map[string][]exchanges.Product{}
 
 	for _, campaign := range campaigns {
-		if _, ok := providers[campaign.Provider]; ok == false {
-			providers[campaign.Provider] = map[string]exchanges.Product{}
+		e.index.Put(campaign)
+
+		if state := campaign.PositionState(); campaign.BuyOrder != nil && (state == entity.CampaignStateSell || state == entity.CampaignStateSelling) {
+			e.risk.Restore(campaign.BuyOrder)
 		}
 
-		if _, ok := providers[campaign.Provider][campaign.ProductID]; ok {
+		if campaign.IsClosed() {
 			continue
 		}
 
-		providers[campaign.Provider][campaign.ProductID] = exchanges.NewProductFromString(campaign.ProductID)
+		providers[campaign.Provider] = append(providers[campaign.Provider], exchanges.NewProductFromString(campaign.ProductID))
+	}
+
+	if err := e.restoreLosses(); err != nil {
+		return err
 	}
 
+	// a provider which cannot be subscribed does not prevent the others to start
+	var failed error
+
 	for provider, products := range providers {
-		productList := []exchanges.Product{}
+		if err := e.subscribeProduct(provider, products); err != nil {
+			log.Error().Err(err).Msgf("Subscribe to the products of %s", provider)
 
-		for _, product := range products {
-			productList = append(productList, product)
+			if failed == nil {
+				failed = err
+			}
 		}
+	}
+
+	return failed
+}
+
+// restoreLosses rebuilds the daily losses of the risk manager from the round trips closed today
+func (e *Engine) restoreLosses() error {
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var roundTrips []*entity.RoundTrip
 
-		e.subscribeProduct(provider, productList)
+	if err := e.db.Select(q.Gte("ClosedAt", day.Unix())).Find(&roundTrips); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	for _, roundTrip := range roundTrips {
+		if roundTrip.BuyOrder == nil || roundTrip.SellOrder == nil {
+			continue
+		}
+
+		e.risk.RestoreLoss(roundTrip.BuyOrder, roundTrip.SellOrder, time.Unix(roundTrip.ClosedAt, 0))
 	}
 
 	return nil
 }
 
+// isStopped reports whether Shutdown was called
+func (e *Engine) isStopped() bool {
+	select {
+	case <-e.doneCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown stops the tickers and waits for the orders in progress until ctx is done
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() {
+		e.stateMu.Lock()
+		e.stopped = true
+		close(e.doneCh)
+		e.stateMu.Unlock()
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		e.wg.Wait()
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Stop engine
 func (e *Engine) Stop() error {
-	e.doneCh <- true
+	return e.Shutdown(context.Background())
+}
 
-	return nil
+// Subscribe to the products ticker on provider without campaign, each call holds a reference on the products
+func (e *Engine) Subscribe(provider string, products ...exchanges.Product) error {
+	return e.subscribeProduct(provider, products)
 }
 
-// GetTimeserie from key {provider}-{product}
-func (e *Engine) GetTimeserie(key string) (*timeseries.Timeseries, error) {
-	if ts, ok := e.timeseries[key]; ok {
-		return ts, nil
-	}
+// Unsubscribe releases a reference on the products ticker taken by Subscribe
+func (e *Engine) Unsubscribe(provider string, products ...exchanges.Product) error {
+	return e.unsubscribeProduct(provider, products)
+}
 
-	return nil, fmt.Errorf("timeserie %s not found", key)
+// Fees schedules of providers
+func (e *Engine) Fees() exchanges.FeeSchedules {
+	return e.options.Fees
 }
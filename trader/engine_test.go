@@ -0,0 +1,653 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/exchanges/paper"
+	"github.com/euskadi31/cryptotrader/risk"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// tickerMock broadcasts the events sent by the test
+type tickerMock struct {
+	mu           sync.Mutex
+	subscribed   []exchanges.Product
+	unsubscribed []exchanges.Product
+	broadcaster  *exchanges.Broadcaster
+	// err is returned by Subscribe
+	err error
+	// unsubscribing receives the calls of Unsubscribe, they are blocked until the test sends on it
+	unsubscribing chan struct{}
+}
+
+func (t *tickerMock) Subscribe(products ...exchanges.Product) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return t.err
+	}
+
+	t.subscribed = append(t.subscribed, products...)
+
+	return nil
+}
+
+func (t *tickerMock) Unsubscribe(products ...exchanges.Product) error {
+	if t.unsubscribing != nil {
+		t.unsubscribing <- struct{}{}
+		<-t.unsubscribing
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.unsubscribed = append(t.unsubscribed, products...)
+
+	return nil
+}
+
+func (t *tickerMock) Channel() <-chan *exchanges.TickerEvent {
+	return t.Listen(nil).C
+}
+
+func (t *tickerMock) Listen(options *exchanges.ListenOptions) *exchanges.Listener {
+	return t.broadcaster.Listen(options)
+}
+
+type providerMock struct {
+	ticker *tickerMock
+}
+
+func (p *providerMock) Name() string {
+	return "mock"
+}
+
+func (p *providerMock) Ticker() exchanges.TickerProvider {
+	return p.ticker
+}
+
+func (p *providerMock) Balance() exchanges.BalanceProvider {
+	return nil
+}
+
+func (p *providerMock) Products() *exchanges.ProductCatalog {
+	return nil
+}
+
+// emitterMock counts the dispatched events
+type emitterMock struct {
+	mu     sync.Mutex
+	events map[string]int
+}
+
+func (e *emitterMock) Subscribe(name string, fn interface{}) {}
+
+func (e *emitterMock) Unsubscribe(name string, fn interface{}) {}
+
+func (e *emitterMock) Dispatch(name string, args ...interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.events[name]++
+}
+
+func (e *emitterMock) Wait() {}
+
+func newTestEngine(t testing.TB) (*Engine, *tickerMock, func()) {
+	dir, err := ioutil.TempDir("", "cryptotrader")
+	assert.NoError(t, err)
+
+	db, err := storm.Open(filepath.Join(dir, "cryptotrader.db"))
+	assert.NoError(t, err)
+	assert.NoError(t, database.Migrate(db))
+
+	ticker := &tickerMock{
+		broadcaster: exchanges.NewBroadcaster(),
+	}
+
+	providers := exchanges.NewManager()
+	providers.Add(&providerMock{
+		ticker: ticker,
+	})
+
+	engine := NewEngine(db, providers, algorithms.NewManager(), risk.NewManager(nil), &emitterMock{
+		events: make(map[string]int),
+	}, nil)
+
+	return engine, ticker, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestEngineConcurrentFeedAndAPI(t *testing.T) {
+	engine, ticker, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	assert.NoError(t, engine.Start())
+
+	products := []exchanges.Product{
+		exchanges.NewProduct("BTC", "EUR"),
+		exchanges.NewProduct("ETH", "EUR"),
+		exchanges.NewProduct("LTC", "EUR"),
+	}
+
+	var wg sync.WaitGroup
+
+	for _, product := range products {
+		wg.Add(1)
+
+		go func(product exchanges.Product) {
+			defer wg.Done()
+
+			assert.NoError(t, engine.Subscribe("mock", product))
+		}(product)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 3, len(ticker.subscribed))
+
+	const count = 200
+
+	start := time.Now()
+
+	for _, product := range append(products, exchanges.NewProduct("XRP", "EUR")) {
+		wg.Add(2)
+
+		go func(product exchanges.Product) {
+			defer wg.Done()
+
+			for i := 0; i < count; i++ {
+				ticker.broadcaster.Broadcast(&exchanges.TickerEvent{
+					Product: product,
+					Price:   decimal.New(int64(1000+i), 0),
+					Size:    decimal.New(1, 0),
+					Time:    start.Add(time.Duration(i) * time.Second),
+				})
+			}
+		}(product)
+
+		go func(product exchanges.Product) {
+			defer wg.Done()
+
+			for i := 0; i < count; i++ {
+				if ts, err := engine.GetTimeserie("mock-" + product.String()); err == nil {
+					assert.Equal(t, len(ts.Keys()), len(ts.Values()))
+				}
+
+				engine.LastPrices("mock")
+				engine.HaltState()
+			}
+		}(product)
+	}
+
+	wg.Wait()
+
+	// the workers process the queued events, the events dropped for a late worker are counted
+	deadline := time.Now().Add(5 * time.Second)
+
+	for _, product := range products {
+		w, ok := engine.worker("mock", product.String())
+		assert.True(t, ok)
+
+		for {
+			ts, err := engine.GetTimeserie("mock-" + product.String())
+			assert.NoError(t, err)
+
+			dropped := int(atomic.LoadUint64(&w.dropped))
+
+			if ts.Size()+dropped == count || time.Now().After(deadline) {
+				assert.Equal(t, count, ts.Size()+dropped)
+
+				break
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	_, err := engine.GetTimeserie("mock-XRP-EUR")
+	assert.Error(t, err)
+
+	prices := engine.LastPrices("mock")
+	assert.Equal(t, 3, len(prices))
+	assert.Equal(t, float64(1000+count-1), prices["BTC-EUR"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, engine.Shutdown(ctx))
+	assert.Equal(t, 0, ticker.broadcaster.Len())
+	assert.Equal(t, ErrEngineStopped, engine.Subscribe("mock", exchanges.NewProduct("XRP", "EUR")))
+}
+
+func TestEngineProductReferences(t *testing.T) {
+	engine, ticker, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	engine.algorithms.Add(holdAlgorithm{})
+
+	assert.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	btceur := exchanges.NewProduct("BTC", "EUR")
+	etheur := exchanges.NewProduct("ETH", "EUR")
+
+	newCampaign := func(id int, product string) *entity.Campaign {
+		return &entity.Campaign{
+			ID:            id,
+			Provider:      "mock",
+			ProductID:     product,
+			Volume:        decimal.New(1, 0),
+			State:         entity.CampaignStateBuy,
+			BuyAlgorithm:  "hold",
+			SellAlgorithm: "hold",
+		}
+	}
+
+	first := newCampaign(1, "BTC-EUR")
+	second := newCampaign(2, "BTC-EUR")
+
+	assert.NoError(t, engine.SaveCampaign(first))
+	assert.NoError(t, engine.SaveCampaign(second))
+	assert.NoError(t, engine.Subscribe("mock", btceur))
+
+	assert.Equal(t, []exchanges.Product{btceur}, ticker.subscribed)
+	assert.Equal(t, 3, engine.subscribers("mock", "BTC-EUR"))
+
+	// the product of the edited campaign is subscribed
+	second = newCampaign(2, "ETH-EUR")
+
+	assert.NoError(t, engine.SaveCampaign(second))
+	assert.Equal(t, []exchanges.Product{btceur, etheur}, ticker.subscribed)
+	assert.Equal(t, 2, engine.subscribers("mock", "BTC-EUR"))
+	assert.Equal(t, 1, engine.subscribers("mock", "ETH-EUR"))
+
+	// the edit without product change keeps the references
+	second.Volume = decimal.New(2, 0)
+
+	assert.NoError(t, engine.SaveCampaign(second))
+	assert.Equal(t, 1, engine.subscribers("mock", "ETH-EUR"))
+
+	_, err := engine.DeleteCampaign(2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []exchanges.Product{etheur}, ticker.unsubscribed)
+	assert.Equal(t, 0, engine.subscribers("mock", "ETH-EUR"))
+
+	_, err = engine.GetTimeserie("mock-ETH-EUR")
+	assert.Error(t, err)
+
+	// the state of the campaign is not edited
+	first.State = entity.CampaignStateSell
+
+	assert.NoError(t, engine.SaveCampaign(first))
+	assert.Equal(t, entity.CampaignStateBuy, first.State)
+
+	// the campaign holding a position requires force
+	current, _ := engine.index.Get(1)
+	current.State = entity.CampaignStateSell
+
+	engine.index.Put(current)
+
+	_, err = engine.DeleteCampaign(1, false)
+	assert.Equal(t, ErrCampaignPositionOpen, err)
+	assert.Equal(t, 2, engine.subscribers("mock", "BTC-EUR"))
+
+	_, err = engine.DeleteCampaign(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, engine.subscribers("mock", "BTC-EUR"))
+	assert.Equal(t, []exchanges.Product{etheur}, ticker.unsubscribed)
+
+	// the product is unsubscribed when its last consumer releases it
+	assert.NoError(t, engine.Unsubscribe("mock", btceur))
+	assert.NoError(t, engine.Unsubscribe("mock", btceur))
+	assert.Equal(t, []exchanges.Product{etheur, btceur}, ticker.unsubscribed)
+	assert.Equal(t, 0, engine.subscribers("mock", "BTC-EUR"))
+
+	assert.NoError(t, engine.Subscribe("mock", btceur))
+	assert.Equal(t, []exchanges.Product{btceur, etheur, btceur}, ticker.subscribed)
+}
+
+func TestEngineUnsubscribeUnlocked(t *testing.T) {
+	engine, ticker, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	engine.algorithms.Add(holdAlgorithm{})
+
+	assert.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	for id, product := range []string{"BTC-EUR", "ETH-EUR"} {
+		assert.NoError(t, engine.SaveCampaign(&entity.Campaign{
+			ID:            id + 1,
+			Provider:      "mock",
+			ProductID:     product,
+			Volume:        decimal.New(1, 0),
+			BuyAlgorithm:  "hold",
+			SellAlgorithm: "hold",
+		}))
+	}
+
+	ticker.unsubscribing = make(chan struct{})
+
+	closed := make(chan error)
+
+	go func() {
+		_, err := engine.CloseCampaign(1)
+
+		closed <- err
+	}()
+
+	select {
+	case <-ticker.unsubscribing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the product of the closed campaign is not unsubscribed")
+	}
+
+	// the stalled unsubscription does not lock the orders of the other campaigns
+	paused := make(chan error)
+
+	go func() {
+		_, err := engine.PauseCampaign(2)
+
+		paused <- err
+	}()
+
+	select {
+	case err := <-paused:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pause waited for the unsubscription")
+	}
+
+	ticker.unsubscribing <- struct{}{}
+
+	assert.NoError(t, <-closed)
+	assert.Equal(t, []exchanges.Product{exchanges.NewProduct("BTC", "EUR")}, ticker.unsubscribed)
+}
+
+func TestEngineCreateCampaignState(t *testing.T) {
+	engine, _, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	engine.algorithms.Add(holdAlgorithm{})
+
+	assert.NoError(t, engine.Start())
+	defer engine.Stop()
+
+	// the state and the orders sent with a new campaign are ignored
+	campaign := &entity.Campaign{
+		Provider:      "mock",
+		ProductID:     "BTC-EUR",
+		Volume:        decimal.New(1, 0),
+		State:         entity.CampaignStatePaused,
+		PausedState:   entity.CampaignStateSell,
+		BuyAlgorithm:  "hold",
+		SellAlgorithm: "hold",
+		BuyOrderID:    5,
+		BuyOrder: &entity.Order{
+			ID:    5,
+			Price: decimal.New(1000, 0),
+		},
+		SellOrderID: 6,
+	}
+
+	assert.NoError(t, engine.SaveCampaign(campaign))
+	assert.Equal(t, entity.CampaignStateBuy, campaign.State)
+	assert.Equal(t, entity.CampaignState(""), campaign.PausedState)
+	assert.Nil(t, campaign.BuyOrder)
+	assert.Equal(t, 0, campaign.BuyOrderID)
+	assert.Equal(t, 0, campaign.SellOrderID)
+
+	stored := &entity.Campaign{}
+
+	assert.NoError(t, engine.db.One("ID", campaign.ID, stored))
+	assert.Equal(t, entity.CampaignStateBuy, stored.State)
+	assert.Equal(t, 0, stored.BuyOrderID)
+	assert.False(t, stored.HasPosition())
+}
+
+func TestEngineSubscribeFailure(t *testing.T) {
+	engine, ticker, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	failure := errors.New("subscribe failed")
+
+	assert.NoError(t, engine.db.Save(&entity.Campaign{
+		Provider:  "mock",
+		ProductID: "BTC-EUR",
+		State:     entity.CampaignStateBuy,
+	}))
+
+	ticker.err = failure
+
+	// the campaigns are started but the error of the subscription is returned
+	assert.Equal(t, failure, engine.Start())
+	defer engine.Stop()
+
+	assert.Equal(t, 0, engine.subscribers("mock", "BTC-EUR"))
+
+	_, ok := engine.worker("mock", "BTC-EUR")
+	assert.False(t, ok)
+
+	btceur := exchanges.NewProduct("BTC", "EUR")
+	etheur := exchanges.NewProduct("ETH", "EUR")
+
+	ticker.mu.Lock()
+	ticker.err = nil
+	ticker.mu.Unlock()
+
+	assert.NoError(t, engine.Subscribe("mock", etheur))
+
+	// the references of a failed subscription are released
+	ticker.mu.Lock()
+	ticker.err = failure
+	ticker.mu.Unlock()
+
+	assert.Equal(t, failure, engine.Subscribe("mock", btceur, etheur))
+	assert.Equal(t, 0, engine.subscribers("mock", "BTC-EUR"))
+	assert.Equal(t, 1, engine.subscribers("mock", "ETH-EUR"))
+
+	_, ok = engine.worker("mock", "BTC-EUR")
+	assert.False(t, ok)
+
+	_, ok = engine.worker("mock", "ETH-EUR")
+	assert.True(t, ok)
+
+	ticker.mu.Lock()
+	ticker.err = nil
+	ticker.mu.Unlock()
+
+	assert.NoError(t, engine.Subscribe("mock", btceur))
+	assert.Equal(t, 1, engine.subscribers("mock", "BTC-EUR"))
+	assert.Equal(t, []exchanges.Product{etheur, btceur}, ticker.subscribed)
+}
+
+func TestEngineRejectionCooldown(t *testing.T) {
+	engine, _, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	emitter := engine.emitter.(*emitterMock)
+
+	campaign := &entity.Campaign{
+		ID:        1,
+		Provider:  "mock",
+		ProductID: "BTC-EUR",
+	}
+
+	newOrder := func(side exchanges.SideType) *entity.Order {
+		return &entity.Order{
+			Provider:  "mock",
+			ProductID: "BTC-EUR",
+			Side:      side,
+		}
+	}
+
+	exposure := &risk.Error{Rule: risk.RuleMaxExposure, Reason: "exposure"}
+	loss := &risk.Error{Rule: risk.RuleMaxDailyLoss, Reason: "loss"}
+
+	// the repeated rejection is recorded once
+	engine.reject(campaign, newOrder(exchanges.SideTypeBuy), exposure)
+	engine.reject(campaign, newOrder(exchanges.SideTypeBuy), exposure)
+	assert.Equal(t, 1, emitter.events["risk-rejection"])
+
+	// the other side and the other rule are recorded
+	engine.reject(campaign, newOrder(exchanges.SideTypeSell), exposure)
+	engine.reject(campaign, newOrder(exchanges.SideTypeBuy), loss)
+	assert.Equal(t, 3, emitter.events["risk-rejection"])
+
+	// the accepted order ends the rejections
+	engine.accept(campaign, newOrder(exchanges.SideTypeBuy))
+	engine.reject(campaign, newOrder(exchanges.SideTypeBuy), loss)
+	assert.Equal(t, 4, emitter.events["risk-rejection"])
+
+	// the rejection is recorded again after the cooldown
+	engine.options.RejectionCooldown = time.Minute
+
+	engine.reject(campaign, newOrder(exchanges.SideTypeBuy), loss)
+	assert.Equal(t, 4, emitter.events["risk-rejection"])
+
+	engine.rejections["1-buy"].at = time.Now().Add(-time.Minute)
+
+	engine.reject(campaign, newOrder(exchanges.SideTypeBuy), loss)
+	assert.Equal(t, 5, emitter.events["risk-rejection"])
+}
+
+func TestEnginePaperFills(t *testing.T) {
+	engine, _, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	engine.providers.Add(paper.NewPaper("paper", nil, map[string]decimal.Decimal{
+		"EUR": decimal.New(1000, 0),
+	}))
+
+	engine.options.Fees = exchanges.FeeSchedules{
+		"paper": exchanges.NewFeeSchedule(exchanges.FeeTier{Taker: decimal.RequireFromString("0.01")}),
+	}
+
+	newCampaign := func() *entity.Campaign {
+		campaign := &entity.Campaign{
+			Provider:  "paper",
+			ProductID: "BTC-EUR",
+			Volume:    decimal.RequireFromString("0.1"),
+			State:     entity.CampaignStateBuy,
+		}
+
+		assert.NoError(t, engine.db.Save(campaign))
+
+		return campaign
+	}
+
+	newEvent := func(price int64) *exchanges.TickerEvent {
+		return &exchanges.TickerEvent{
+			Product: exchanges.NewProductFromString("BTC-EUR"),
+			Price:   decimal.New(price, 0),
+		}
+	}
+
+	balance := func(currency string) string {
+		amount, err := engine.providers.Balance("paper", currency)
+		assert.NoError(t, err)
+
+		return amount.String()
+	}
+
+	// the buy pays its cost including the fee
+	first := newCampaign()
+
+	assert.NoError(t, engine.buy(newEvent(5000), first))
+	assert.Equal(t, entity.CampaignStateSell, first.State)
+	assert.Equal(t, "495", balance("EUR"))
+	assert.Equal(t, "0.1", balance("BTC"))
+
+	// the buy the balance cannot cover is rejected
+	second := newCampaign()
+
+	assert.Equal(t, paper.ErrInsufficientBalance, engine.buy(newEvent(5000), second))
+	assert.Equal(t, entity.CampaignStateBuy, second.State)
+	assert.Nil(t, second.BuyOrder)
+	assert.Equal(t, 1, engine.emitter.(*emitterMock).events["risk-rejection"])
+	assert.Equal(t, "495", balance("EUR"))
+
+	// the sell receives its proceeds excluding the fee
+	assert.NoError(t, engine.sell(newEvent(6000), first))
+	assert.Equal(t, entity.CampaignStateBuy, first.State)
+	assert.Equal(t, "1089", balance("EUR"))
+	assert.Equal(t, "0", balance("BTC"))
+}
+
+func TestWorkerPush(t *testing.T) {
+	w := newWorker("mock", exchanges.NewProduct("BTC", "EUR"))
+
+	for i := 0; i <= workerBuffer; i++ {
+		w.push(&exchanges.TickerEvent{
+			Price: decimal.New(int64(i), 0),
+		})
+	}
+
+	// the late worker keeps the last events
+	assert.Equal(t, workerBuffer, len(w.events))
+	assert.Equal(t, uint64(1), w.dropped)
+
+	first := <-w.events
+	assert.Equal(t, "1", first.Price.String())
+}
+
+func TestFeedSendOrder(t *testing.T) {
+	f := newFeed(&tickerMock{
+		broadcaster: exchanges.NewBroadcaster(),
+	})
+
+	first := f.ticket()
+	second := f.ticket()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	sent := []uint64{}
+
+	send := func(ticket uint64) {
+		defer wg.Done()
+
+		f.send(ticket, func() error {
+			mu.Lock()
+			sent = append(sent, ticket)
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	// the second subscription waits for the first one
+	wg.Add(2)
+
+	go send(second)
+
+	time.Sleep(10 * time.Millisecond)
+
+	go send(first)
+
+	wg.Wait()
+
+	assert.Equal(t, []uint64{first, second}, sent)
+}
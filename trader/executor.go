@@ -0,0 +1,313 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/ledger"
+	"github.com/euskadi31/cryptotrader/risk"
+	"github.com/euskadi31/go-std"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+// reject records the order refused by the risk manager
+func (e *Engine) reject(campaign *entity.Campaign, order *entity.Order, err error) {
+	rejection := &entity.Rejection{
+		CampaignID: campaign.ID,
+		Provider:   order.Provider,
+		ProductID:  order.ProductID,
+		Side:       order.Side,
+		Size:       order.Size,
+		Price:      order.Price,
+		Reason:     err.Error(),
+		CreatedAt:  std.DateTimeFrom(time.Now().UTC()),
+	}
+
+	if riskErr, ok := err.(*risk.Error); ok {
+		rejection.Rule = riskErr.Rule
+		rejection.Reason = riskErr.Reason
+	}
+
+	// the same rejection repeated on each tick is recorded once per cooldown
+	key := fmt.Sprintf("%d-%s", campaign.ID, order.Side)
+	now := time.Now()
+
+	if last, ok := e.rejections[key]; ok && last.rule == rejection.Rule && (e.options.RejectionCooldown <= 0 || now.Sub(last.at) < e.options.RejectionCooldown) {
+		log.Debug().
+			Int("campaign", campaign.ID).
+			Str("rule", rejection.Rule).
+			Msgf("Order %s %s %s rejected again: %s", order.Side, order.Size, order.ProductID, rejection.Reason)
+
+		return
+	}
+
+	e.rejections[key] = &rejectionMark{
+		rule: rejection.Rule,
+		at:   now,
+	}
+
+	log.Warn().
+		Int("campaign", campaign.ID).
+		Str("rule", rejection.Rule).
+		Msgf("Order %s %s %s rejected: %s", order.Side, order.Size, order.ProductID, rejection.Reason)
+
+	if err := e.db.Save(rejection); err != nil {
+		log.Error().Err(err).Msg("Save Rejection")
+	}
+
+	e.emitter.Dispatch("risk-rejection", rejection)
+}
+
+// accept ends the rejections of the campaign side, the next rejection is recorded
+func (e *Engine) accept(campaign *entity.Campaign, order *entity.Order) {
+	delete(e.rejections, fmt.Sprintf("%d-%s", campaign.ID, order.Side))
+}
+
+// recordFill updates the virtual balances of the paper exchanges with the cost or the proceeds of order,
+// the fill the balances cannot cover is returned
+func (e *Engine) recordFill(order *entity.Order) error {
+	exchange, err := e.providers.Get(order.Provider)
+	if err != nil {
+		return nil
+	}
+
+	recorder, ok := exchange.Balance().(exchanges.FillRecorder)
+	if ok == false {
+		return nil
+	}
+
+	value := order.GetProceeds()
+	if order.Side == exchanges.SideTypeBuy {
+		value = order.GetCost()
+	}
+
+	return recorder.Fill(order.Side, exchanges.NewProductFromString(order.ProductID), order.Size, value)
+}
+
+// roundSize of order to the base increment of its product and checks that it can be placed
+func (e *Engine) roundSize(order *entity.Order, price decimal.Decimal) error {
+	product, err := e.providers.Product(order.Provider, order.ProductID)
+	if err == exchanges.ErrProductsNotSupported {
+		product = &exchanges.ProductInfo{
+			Status: exchanges.ProductStatusOnline,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	order.Size = product.RoundSize(order.Size)
+	order.Price = order.Size.Mul(price)
+
+	return product.ValidateSize(order.Size)
+}
+
+// buy the volume of campaign at the event price, the order refused by the risk manager is returned
+func (e *Engine) buy(event *exchanges.TickerEvent, campaign *entity.Campaign) error {
+	order := &entity.Order{
+		Provider:   campaign.Provider,
+		CampaignID: campaign.ID,
+		Side:       exchanges.SideTypeBuy,
+		State:      entity.OrderStatePending,
+		ProductID:  campaign.ProductID,
+		Size:       campaign.Volume,
+		CreatedAt:  std.DateTimeFrom(time.Now().UTC()),
+	}
+
+	if err := e.roundSize(order, event.Price); err != nil {
+		e.reject(campaign, order, err)
+
+		return err
+	}
+
+	e.applyFee(order)
+
+	if err := e.risk.Check(order); err != nil {
+		e.reject(campaign, order, err)
+
+		return err
+	}
+
+	if err := e.recordFill(order); err != nil {
+		e.reject(campaign, order, err)
+
+		return err
+	}
+
+	e.accept(campaign, order)
+
+	campaign.State = entity.CampaignStateBuying
+
+	if err := e.saveCampaign(campaign); err != nil {
+		log.Error().Err(err).Msg("Save Campaign")
+	}
+
+	log.Warn().Msgf("Buying %s %s at %s %s", order.Size, event.Product.From, event.Price, event.Product.To)
+
+	// emulate buying start ----
+	order.State = entity.OrderStateFilled
+
+	if err := e.orders.Save(order); err != nil {
+		e.orderFailed(err)
+
+		return err
+	}
+
+	e.orderSucceeded()
+
+	e.risk.Filled(order)
+	e.volumes.Add(order)
+
+	campaign.State = entity.CampaignStateSell
+
+	campaign.SetBuyOrder(order)
+
+	if err := e.saveCampaign(campaign); err != nil {
+		log.Error().Err(err).Msg("Save Campaign")
+	}
+	// end emulate
+
+	return nil
+}
+
+// tradedVolume of provider in quote currency during the last 30 days
+func (e *Engine) tradedVolume(provider string) decimal.Decimal {
+	volume, err := e.volumes.Volume(provider)
+	if err != nil {
+		log.Error().Err(err).Msg("Find orders")
+	}
+
+	return volume
+}
+
+// applyFee of provider schedule on order, the emulated orders are market orders paying the taker fee
+func (e *Engine) applyFee(order *entity.Order) {
+	schedule := e.options.Fees.Get(order.Provider)
+	if schedule == nil {
+		return
+	}
+
+	order.FeeRate = schedule.Rate(e.tradedVolume(order.Provider), false)
+	order.Fee = order.Price.Mul(order.FeeRate)
+}
+
+func (e *Engine) newSellOrder(campaign *entity.Campaign, price decimal.Decimal) *entity.Order {
+	order := &entity.Order{
+		Provider:   campaign.Provider,
+		CampaignID: campaign.ID,
+		Side:       exchanges.SideTypeSell,
+		State:      entity.OrderStatePending,
+		ProductID:  campaign.ProductID,
+		Size:       campaign.BuyOrder.Size,
+		Price:      campaign.BuyOrder.Size.Mul(price),
+		CreatedAt:  std.DateTimeFrom(time.Now().UTC()),
+	}
+
+	e.applyFee(order)
+
+	return order
+}
+
+// sell the position of campaign at the event price, the order refused by the risk manager is returned
+func (e *Engine) sell(event *exchanges.TickerEvent, campaign *entity.Campaign) error {
+	if campaign.BuyOrder == nil {
+		log.Error().Int("campaign", campaign.ID).Msg("Cannot sell campaign without buy order")
+
+		return ErrCampaignWithoutBuyOrder
+	}
+
+	order := e.newSellOrder(campaign, event.Price)
+
+	if err := e.risk.Check(order); err != nil {
+		e.reject(campaign, order, err)
+
+		return err
+	}
+
+	if err := e.recordFill(order); err != nil {
+		e.reject(campaign, order, err)
+
+		return err
+	}
+
+	e.accept(campaign, order)
+
+	if err := e.placeSell(campaign, order, event.Price); err != nil {
+		e.orderFailed(err)
+
+		return err
+	}
+
+	e.orderSucceeded()
+
+	return nil
+}
+
+// placeSell closes the position of campaign with order already recorded on the virtual balances
+func (e *Engine) placeSell(campaign *entity.Campaign, order *entity.Order, price decimal.Decimal) error {
+	product := exchanges.NewProductFromString(campaign.ProductID)
+
+	campaign.State = entity.CampaignStateSelling
+
+	if err := e.saveCampaign(campaign); err != nil {
+		log.Error().Err(err).Msg("Save Campaign")
+	}
+
+	log.Warn().Msgf("Selling %s %s at %s %s", order.Size, product.From, price, product.To)
+
+	// emulate selling start ----
+	order.State = entity.OrderStateFilled
+
+	if err := e.orders.Save(order); err != nil {
+		return err
+	}
+
+	e.risk.Filled(order)
+	e.risk.Closed(campaign.BuyOrder, order)
+	e.volumes.Add(order)
+
+	now := time.Now()
+
+	roundTrip := ledger.NewRoundTrip(campaign, campaign.BuyOrder, order, now)
+
+	campaign.Cycles++
+	campaign.PnL = campaign.PnL.Add(roundTrip.PnL)
+
+	roundTrip.Cycle = campaign.Cycles
+
+	if err := e.db.Save(roundTrip); err != nil {
+		log.Error().Err(err).Msg("Save RoundTrip")
+	}
+
+	log.Info().
+		Int("campaign", campaign.ID).
+		Str("pnl_percent", roundTrip.PnLPercent.StringFixed(2)).
+		Int("cycle", roundTrip.Cycle).
+		Msgf("Position closed with %s %s", roundTrip.PnL, roundTrip.Quote)
+
+	campaign.SetSellOrder(order)
+
+	// the campaign starts a new cycle until it is finished
+	if campaign.Finished(now) {
+		if err := e.closeCampaign(campaign); err != nil {
+			log.Error().Err(err).Msg("Close Campaign")
+		}
+
+		return nil
+	}
+
+	campaign.State = entity.CampaignStateBuy
+
+	if err := e.saveCampaign(campaign); err != nil {
+		log.Error().Err(err).Msg("Save Campaign")
+	}
+	// end emulate
+
+	return nil
+}
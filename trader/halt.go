@@ -0,0 +1,331 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	haltBucket = "engine"
+	haltKey    = "halt"
+)
+
+// HaltState of Engine, persisted to survive a restart
+type HaltState struct {
+	Halted   bool      `json:"halted"`
+	Auto     bool      `json:"auto"`
+	Reason   string    `json:"reason"`
+	HaltedAt time.Time `json:"halted_at"`
+}
+
+// HaltRequest struct
+type HaltRequest struct {
+	Reason string `json:"reason"`
+	// Cancel the orders in progress
+	Cancel bool `json:"cancel_orders"`
+	// Flatten sells all open positions at market price
+	Flatten bool `json:"flatten_positions"`
+}
+
+// HaltResult struct
+type HaltResult struct {
+	State     HaltState `json:"state"`
+	Canceled  int       `json:"canceled"`
+	Flattened int       `json:"flattened"`
+}
+
+func (e *Engine) loadHalt() error {
+	state := HaltState{}
+
+	if err := e.db.Get(haltBucket, haltKey, &state); err != nil && err != storm.ErrNotFound {
+		return err
+	}
+
+	e.haltMu.Lock()
+	e.halt = state
+	e.haltMu.Unlock()
+
+	if state.Halted {
+		log.Warn().Msgf("Trading is halted since %s: %s", state.HaltedAt.Format(time.RFC3339), state.Reason)
+	}
+
+	return nil
+}
+
+// setHalt updates and persists the halt state
+func (e *Engine) setHalt(state HaltState) error {
+	e.haltMu.Lock()
+	e.halt = state
+	e.haltMu.Unlock()
+
+	if err := e.db.Set(haltBucket, haltKey, &state); err != nil {
+		return err
+	}
+
+	if state.Halted {
+		e.emitter.Dispatch("engine-halt", state)
+	} else {
+		e.emitter.Dispatch("engine-resume", state)
+	}
+
+	return nil
+}
+
+// IsHalted returns true if the new orders are stopped
+func (e *Engine) IsHalted() bool {
+	e.haltMu.RLock()
+	defer e.haltMu.RUnlock()
+
+	return e.halt.Halted
+}
+
+// HaltState of Engine
+func (e *Engine) HaltState() HaltState {
+	e.haltMu.RLock()
+	defer e.haltMu.RUnlock()
+
+	return e.halt
+}
+
+// Halt stops the new orders, optionally cancels the orders in progress and flattens the positions
+func (e *Engine) Halt(req *HaltRequest) (*HaltResult, error) {
+	// the products of the campaigns closed by the flatten are unsubscribed after the lock
+	defer e.sendUnsubscribes()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if req.Reason == "" {
+		req.Reason = "manual halt"
+	}
+
+	state := e.HaltState()
+
+	if state.Halted == false {
+		state = HaltState{
+			Halted:   true,
+			Reason:   req.Reason,
+			HaltedAt: time.Now().UTC(),
+		}
+
+		log.Warn().Msgf("Halt trading: %s", req.Reason)
+
+		if err := e.setHalt(state); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &HaltResult{
+		State: state,
+	}
+
+	if req.Cancel {
+		count, err := e.cancelOrders()
+		if err != nil {
+			return nil, err
+		}
+
+		result.Canceled = count
+	}
+
+	if req.Flatten {
+		count, err := e.flattenPositions()
+		if err != nil {
+			return nil, err
+		}
+
+		result.Flattened = count
+	}
+
+	return result, nil
+}
+
+// Resume trading after a halt
+func (e *Engine) Resume() (*HaltState, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state := HaltState{}
+
+	if err := e.setHalt(state); err != nil {
+		return nil, err
+	}
+
+	e.feedMu.Lock()
+	e.orderErrors = 0
+
+	for provider := range e.lastEvents {
+		e.lastEvents[provider] = time.Now()
+	}
+	e.feedMu.Unlock()
+
+	log.Warn().Msg("Resume trading")
+
+	return &state, nil
+}
+
+// autoHalt stops the new orders without touching the positions,
+// it must not wait for the engine lock because it is called while trading.
+func (e *Engine) autoHalt(reason string) {
+	if e.IsHalted() {
+		return
+	}
+
+	log.Error().Msgf("Auto halt trading: %s", reason)
+
+	if err := e.setHalt(HaltState{
+		Halted:   true,
+		Auto:     true,
+		Reason:   reason,
+		HaltedAt: time.Now().UTC(),
+	}); err != nil {
+		log.Error().Err(err).Msg("Save halt state")
+	}
+}
+
+// cancelOrders returns the campaigns with an order in progress to their previous state
+func (e *Engine) cancelOrders() (int, error) {
+	campaigns := e.index.ByState(entity.CampaignStateBuying, entity.CampaignStateSelling)
+
+	for _, campaign := range campaigns {
+		if campaign.IsBuying() {
+			campaign.State = entity.CampaignStateBuy
+		} else {
+			campaign.State = entity.CampaignStateSell
+		}
+
+		if err := e.saveCampaign(campaign); err != nil {
+			return 0, err
+		}
+
+		log.Warn().Int("campaign", campaign.ID).Msg("Order canceled by halt")
+	}
+
+	return len(campaigns), nil
+}
+
+// flattenPositions sells the open positions at the last known price, bypassing the risk manager,
+// the paused campaigns included
+func (e *Engine) flattenPositions() (int, error) {
+	campaigns := e.index.ByState(entity.CampaignStateSell, entity.CampaignStatePaused)
+
+	count := 0
+
+	for _, campaign := range campaigns {
+		if campaign.BuyOrder == nil || campaign.PositionState() != entity.CampaignStateSell {
+			continue
+		}
+
+		paused := campaign.IsPaused()
+
+		last, ok := e.lastPrice(campaign.Provider, campaign.ProductID)
+		if ok == false {
+			log.Error().Int("campaign", campaign.ID).Msgf("Cannot flatten position without price for %s", workerKey(campaign.Provider, campaign.ProductID))
+
+			continue
+		}
+
+		price := decimal.NewFromFloat(last)
+		order := e.newSellOrder(campaign, price)
+
+		if err := e.recordFill(order); err != nil {
+			return count, err
+		}
+
+		if err := e.placeSell(campaign, order, price); err != nil {
+			return count, err
+		}
+
+		// the paused campaign stays paused without position
+		if paused && campaign.IsClosed() == false {
+			campaign.PausedState = campaign.State
+			campaign.State = entity.CampaignStatePaused
+
+			if err := e.saveCampaign(campaign); err != nil {
+				return count, err
+			}
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// orderFailed halts the trading after too many consecutive order errors
+func (e *Engine) orderFailed(err error) {
+	log.Error().Err(err).Msg("save order failed")
+
+	e.feedMu.Lock()
+	e.orderErrors++
+	errors := e.orderErrors
+	e.feedMu.Unlock()
+
+	if max := e.options.MaxOrderErrors; max > 0 && errors >= max {
+		e.autoHalt(fmt.Sprintf("%d consecutive order errors, last: %v", errors, err))
+	}
+}
+
+func (e *Engine) orderSucceeded() {
+	e.feedMu.Lock()
+	e.orderErrors = 0
+	e.feedMu.Unlock()
+}
+
+// feedReceived records the time of the last event of provider feed
+func (e *Engine) feedReceived(provider string) {
+	e.feedMu.Lock()
+	e.lastEvents[provider] = time.Now()
+	e.feedMu.Unlock()
+}
+
+// feedClosed halts the trading when the ticker channel of provider is closed
+func (e *Engine) feedClosed(provider string) {
+	e.feedMu.Lock()
+	delete(e.lastEvents, provider)
+	e.feedMu.Unlock()
+
+	e.autoHalt(fmt.Sprintf("%s feed disconnected", provider))
+}
+
+// watchFeeds halts the trading when a provider feed does not receive events during FeedTimeout
+func (e *Engine) watchFeeds() {
+	defer e.wg.Done()
+
+	if e.options.FeedTimeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(e.options.FeedTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			e.feedMu.Lock()
+			stale := []string{}
+
+			for provider, last := range e.lastEvents {
+				if now.Sub(last) > e.options.FeedTimeout {
+					stale = append(stale, provider)
+				}
+			}
+			e.feedMu.Unlock()
+
+			for _, provider := range stale {
+				e.autoHalt(fmt.Sprintf("%s feed stale for more than %s", provider, e.options.FeedTimeout))
+			}
+		case <-e.doneCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+)
+
+// campaignIndex of the campaigns by provider and product, it avoids a database query by ticker event.
+// The index keeps its own copies, the campaigns returned can be modified until they are put back.
+// The copies hold the current buy and sell orders, not the history of the orders.
+type campaignIndex struct {
+	mu        sync.RWMutex
+	campaigns map[string]map[int]*entity.Campaign
+	keys      map[int]string
+}
+
+func newCampaignIndex() *campaignIndex {
+	return &campaignIndex{
+		campaigns: make(map[string]map[int]*entity.Campaign),
+		keys:      make(map[int]string),
+	}
+}
+
+// Put a copy of the campaign in the index
+func (i *campaignIndex) Put(campaign *entity.Campaign) {
+	key := workerKey(campaign.Provider, campaign.ProductID)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// the provider or the product of the campaign can be edited
+	if previous, ok := i.keys[campaign.ID]; ok {
+		delete(i.campaigns[previous], campaign.ID)
+	}
+
+	if _, ok := i.campaigns[key]; ok == false {
+		i.campaigns[key] = make(map[int]*entity.Campaign)
+	}
+
+	i.campaigns[key][campaign.ID] = copyCampaign(campaign)
+	i.keys[campaign.ID] = key
+}
+
+// Get a copy of the indexed campaign
+func (i *campaignIndex) Get(id int) (*entity.Campaign, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	campaign, ok := i.campaigns[i.keys[id]][id]
+	if ok == false {
+		return nil, false
+	}
+
+	return copyCampaign(campaign), true
+}
+
+// Changed reports whether the campaign differs from the indexed campaign
+func (i *campaignIndex) Changed(campaign *entity.Campaign) bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	current, ok := i.campaigns[i.keys[campaign.ID]][campaign.ID]
+	if ok == false {
+		return true
+	}
+
+	return reflect.DeepEqual(current, campaign) == false
+}
+
+// Remove the campaign from the index
+func (i *campaignIndex) Remove(id int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	key, ok := i.keys[id]
+	if ok == false {
+		return
+	}
+
+	delete(i.campaigns[key], id)
+	delete(i.keys, id)
+}
+
+// Find copies of the campaigns of product on provider in states, sorted by id
+func (i *campaignIndex) Find(provider string, product string, states ...entity.CampaignState) []*entity.Campaign {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return collect(i.campaigns[workerKey(provider, product)], states)
+}
+
+// ByState returns copies of the campaigns in states, sorted by id
+func (i *campaignIndex) ByState(states ...entity.CampaignState) []*entity.Campaign {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	campaigns := []*entity.Campaign{}
+
+	for _, indexed := range i.campaigns {
+		campaigns = append(campaigns, collect(indexed, states)...)
+	}
+
+	sort.Slice(campaigns, func(a, b int) bool {
+		return campaigns[a].ID < campaigns[b].ID
+	})
+
+	return campaigns
+}
+
+// collect copies of the campaigns in states
+func collect(indexed map[int]*entity.Campaign, states []entity.CampaignState) []*entity.Campaign {
+	campaigns := []*entity.Campaign{}
+
+	for _, campaign := range indexed {
+		for _, state := range states {
+			if campaign.State == state {
+				campaigns = append(campaigns, copyCampaign(campaign))
+
+				break
+			}
+		}
+	}
+
+	sort.Slice(campaigns, func(a, b int) bool {
+		return campaigns[a].ID < campaigns[b].ID
+	})
+
+	return campaigns
+}
+
+// copyCampaign without the history of its orders, the history is loaded from the order repository
+func copyCampaign(campaign *entity.Campaign) *entity.Campaign {
+	c := *campaign
+
+	c.Orders = nil
+
+	return &c
+}
@@ -0,0 +1,177 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/euskadi31/cryptotrader/trader/algorithms"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignIndex(t *testing.T) {
+	index := newCampaignIndex()
+
+	index.Put(&entity.Campaign{ID: 2, Provider: "gdax", ProductID: "BTC-EUR", State: entity.CampaignStateSell})
+	index.Put(&entity.Campaign{ID: 1, Provider: "gdax", ProductID: "BTC-EUR", State: entity.CampaignStateBuy})
+	index.Put(&entity.Campaign{ID: 3, Provider: "gdax", ProductID: "BTC-EUR", State: entity.CampaignStateBuying})
+	index.Put(&entity.Campaign{ID: 4, Provider: "gdax", ProductID: "ETH-EUR", State: entity.CampaignStateBuy})
+
+	campaigns := index.Find("gdax", "BTC-EUR", entity.CampaignStateBuy, entity.CampaignStateSell)
+	assert.Equal(t, 2, len(campaigns))
+	assert.Equal(t, 1, campaigns[0].ID)
+	assert.Equal(t, 2, campaigns[1].ID)
+
+	// the campaigns found are copies
+	campaigns[0].State = entity.CampaignStateBuying
+	assert.True(t, index.Changed(campaigns[0]))
+	assert.Equal(t, 2, len(index.Find("gdax", "BTC-EUR", entity.CampaignStateBuy, entity.CampaignStateSell)))
+
+	index.Put(campaigns[0])
+	assert.False(t, index.Changed(campaigns[0]))
+	assert.Equal(t, 1, len(index.Find("gdax", "BTC-EUR", entity.CampaignStateBuy, entity.CampaignStateSell)))
+
+	// the campaign moves with its product
+	index.Put(&entity.Campaign{ID: 2, Provider: "kraken", ProductID: "BTC-EUR", State: entity.CampaignStateSell})
+	assert.Equal(t, 0, len(index.Find("gdax", "BTC-EUR", entity.CampaignStateSell)))
+	assert.Equal(t, 1, len(index.Find("kraken", "BTC-EUR", entity.CampaignStateSell)))
+
+	buying := index.ByState(entity.CampaignStateBuying)
+	assert.Equal(t, 2, len(buying))
+	assert.Equal(t, 1, buying[0].ID)
+	assert.Equal(t, 3, buying[1].ID)
+
+	// the history of the orders is not indexed
+	index.Put(&entity.Campaign{ID: 5, Provider: "gdax", ProductID: "BTC-EUR", Orders: []*entity.Order{{ID: 1}}})
+
+	campaign, _ := index.Get(5)
+	assert.Nil(t, campaign.Orders)
+
+	index.Remove(3)
+	index.Remove(3)
+	assert.Equal(t, 1, len(index.ByState(entity.CampaignStateBuying)))
+	assert.True(t, index.Changed(&entity.Campaign{ID: 3}))
+	assert.Equal(t, 0, len(index.Find("binance", "BTC-EUR", entity.CampaignStateBuy)))
+}
+
+// holdAlgorithm never trades
+type holdAlgorithm struct{}
+
+func (holdAlgorithm) MarshalJSON() ([]byte, error) {
+	return []byte(`{"name":"hold"}`), nil
+}
+
+func (holdAlgorithm) Name() string {
+	return "hold"
+}
+
+func (holdAlgorithm) Options() algorithms.Options {
+	return algorithms.Options{}
+}
+
+func (holdAlgorithm) BuySignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options algorithms.Options, ts *timeseries.Timeseries) bool {
+	return false
+}
+
+func (holdAlgorithm) SellSignal(event *exchanges.TickerEvent, campaign *entity.Campaign, options algorithms.Options, ts *timeseries.Timeseries) bool {
+	return false
+}
+
+// newBenchmarkEngine with campaigns spread on products, the campaigns of BTC-EUR are processed by the ticks
+func newBenchmarkEngine(b *testing.B, products int, campaigns int) (*Engine, func()) {
+	engine, _, cleanup := newTestEngine(b)
+
+	engine.algorithms.Add(holdAlgorithm{})
+
+	for i := 0; i < campaigns; i++ {
+		campaign := &entity.Campaign{
+			ID:            i + 1,
+			Provider:      "mock",
+			ProductID:     fmt.Sprintf("C%d-EUR", i%products),
+			Volume:        decimal.New(1, 0),
+			State:         entity.CampaignStateBuy,
+			BuyAlgorithm:  "hold",
+			SellAlgorithm: "hold",
+		}
+
+		if i%products == 0 {
+			campaign.ProductID = "BTC-EUR"
+		}
+
+		if err := engine.db.Save(campaign); err != nil {
+			b.Fatal(err)
+		}
+
+		engine.index.Put(campaign)
+	}
+
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	return engine, func() {
+		zerolog.SetGlobalLevel(level)
+		cleanup()
+	}
+}
+
+func benchmarkTick() *exchanges.TickerEvent {
+	return &exchanges.TickerEvent{
+		Product: exchanges.NewProduct("BTC", "EUR"),
+		Price:   decimal.New(5000, 0),
+		Size:    decimal.New(1, 0),
+		Time:    time.Now(),
+	}
+}
+
+// BenchmarkEngineTradeIndex processes a tick with the campaigns of the in-memory index
+func BenchmarkEngineTradeIndex(b *testing.B) {
+	engine, cleanup := newBenchmarkEngine(b, 50, 1000)
+	defer cleanup()
+
+	event := benchmarkTick()
+	ts := timeseries.New(timeseriesSize)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		engine.trade("mock", event, ts)
+	}
+}
+
+// BenchmarkEngineTradeQuery finds the campaigns of a tick with the storm query used before the index
+func BenchmarkEngineTradeQuery(b *testing.B) {
+	engine, cleanup := newBenchmarkEngine(b, 50, 1000)
+	defer cleanup()
+
+	event := benchmarkTick()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		query := engine.db.Select(
+			q.Eq("Provider", "mock"),
+			q.Eq("ProductID", event.Product.String()),
+			q.In("State", []entity.CampaignState{
+				entity.CampaignStateBuy,
+				entity.CampaignStateSell,
+			}),
+		)
+
+		var campaigns []*entity.Campaign
+
+		if err := query.Find(&campaigns); err != nil && err != storm.ErrNotFound {
+			b.Fatal(err)
+		}
+	}
+}
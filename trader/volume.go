@@ -0,0 +1,126 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database"
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/shopspring/decimal"
+)
+
+// volumeFill of an order counted in the traded volume
+type volumeFill struct {
+	at     time.Time
+	amount decimal.Decimal
+}
+
+// volumeTracker caches the traded volume of the providers during the trailing window,
+// the filled orders of a provider are loaded once then added on fill
+type volumeTracker struct {
+	mu     sync.Mutex
+	window time.Duration
+	orders *database.OrderRepository
+	fills  map[string][]volumeFill
+	totals map[string]decimal.Decimal
+	now    func() time.Time
+}
+
+func newVolumeTracker(orders *database.OrderRepository, window time.Duration) *volumeTracker {
+	return &volumeTracker{
+		window: window,
+		orders: orders,
+		fills:  make(map[string][]volumeFill),
+		totals: make(map[string]decimal.Decimal),
+		now:    time.Now,
+	}
+}
+
+// load the filled orders of provider during the window, the lock is held by the caller
+func (t *volumeTracker) load(provider string) error {
+	if _, ok := t.fills[provider]; ok {
+		return nil
+	}
+
+	orders, err := t.orders.Find(&database.OrderFilter{
+		Provider: provider,
+		State:    entity.OrderStateFilled,
+		From:     t.now().Add(-t.window),
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.Time.Before(orders[j].CreatedAt.Time)
+	})
+
+	fills := []volumeFill{}
+	total := decimal.Zero
+
+	for _, order := range orders {
+		fills = append(fills, volumeFill{
+			at:     order.CreatedAt.Time,
+			amount: order.Price,
+		})
+
+		total = total.Add(order.Price)
+	}
+
+	t.fills[provider] = fills
+	t.totals[provider] = total
+
+	return nil
+}
+
+// prune the fills of provider older than the window, the lock is held by the caller
+func (t *volumeTracker) prune(provider string) {
+	since := t.now().Add(-t.window)
+	fills := t.fills[provider]
+
+	i := 0
+
+	for i < len(fills) && fills[i].at.Before(since) {
+		t.totals[provider] = t.totals[provider].Sub(fills[i].amount)
+
+		i++
+	}
+
+	t.fills[provider] = fills[i:]
+}
+
+// Volume of provider in quote currency during the window
+func (t *volumeTracker) Volume(provider string) (decimal.Decimal, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.load(provider); err != nil {
+		return decimal.Zero, err
+	}
+
+	t.prune(provider)
+
+	return t.totals[provider], nil
+}
+
+// Add the filled order to the volume of its provider, the volume not loaded yet reads it from the repository
+func (t *volumeTracker) Add(order *entity.Order) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.fills[order.Provider]; ok == false {
+		return
+	}
+
+	t.fills[order.Provider] = append(t.fills[order.Provider], volumeFill{
+		at:     order.CreatedAt.Time,
+		amount: order.Price,
+	})
+
+	t.totals[order.Provider] = t.totals[order.Provider].Add(order.Price)
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/euskadi31/cryptotrader/database/entity"
+	"github.com/euskadi31/go-std"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeTracker(t *testing.T) {
+	engine, _, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	now := time.Date(2017, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	tracker := newVolumeTracker(engine.orders, time.Hour)
+	tracker.now = func() time.Time {
+		return now
+	}
+
+	newOrder := func(provider string, price int64, at time.Time) *entity.Order {
+		return &entity.Order{
+			Provider:  provider,
+			State:     entity.OrderStateFilled,
+			Price:     decimal.New(price, 0),
+			CreatedAt: std.DateTimeFrom(at),
+		}
+	}
+
+	// the fill of a provider not loaded is read from the repository
+	tracker.Add(newOrder("mock", 100, now))
+
+	volume, err := tracker.Volume("mock")
+	assert.NoError(t, err)
+	assert.True(t, volume.IsZero())
+
+	tracker.Add(newOrder("mock", 100, now.Add(-50*time.Minute)))
+	tracker.Add(newOrder("mock", 200, now))
+	tracker.Add(newOrder("other", 500, now))
+
+	volume, err = tracker.Volume("mock")
+	assert.NoError(t, err)
+	assert.Equal(t, "300", volume.String())
+
+	// the fills leave the window
+	now = now.Add(15 * time.Minute)
+
+	volume, err = tracker.Volume("mock")
+	assert.NoError(t, err)
+	assert.Equal(t, "200", volume.String())
+	assert.Equal(t, 1, len(tracker.fills["mock"]))
+}
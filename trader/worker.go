@@ -0,0 +1,462 @@
+// Copyright 2017 Axel Etcheverry. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package trader
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/euskadi31/cryptotrader/exchanges"
+	"github.com/euskadi31/cryptotrader/timeseries"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// timeseriesSize is the number of DataPoint kept by product
+	timeseriesSize = 5000
+	// workerBuffer is the number of ticker events queued by product worker
+	workerBuffer = 100
+)
+
+// feed of a provider, the events are routed to the workers of the products
+type feed struct {
+	ticker   exchanges.TickerProvider
+	listener *exchanges.Listener
+	// next ticket of the subscriptions, it is protected by stateMu
+	next uint64
+	// mu protects the turn of the subscription sent to the exchange
+	mu   sync.Mutex
+	cond *sync.Cond
+	turn uint64
+}
+
+func newFeed(ticker exchanges.TickerProvider) *feed {
+	f := &feed{
+		ticker:   ticker,
+		listener: ticker.Listen(nil),
+	}
+
+	f.cond = sync.NewCond(&f.mu)
+
+	return f
+}
+
+// ticket reserves the turn of a subscription, the caller holds stateMu
+func (f *feed) ticket() uint64 {
+	ticket := f.next
+
+	f.next++
+
+	return ticket
+}
+
+// send the subscription of ticket to the exchange after the subscriptions of the previous tickets,
+// the exchange receives the subscriptions in the order of the references
+func (f *feed) send(ticket uint64, fn func() error) error {
+	f.mu.Lock()
+
+	for f.turn != ticket {
+		f.cond.Wait()
+	}
+
+	f.mu.Unlock()
+
+	err := fn()
+
+	f.mu.Lock()
+	f.turn++
+	f.cond.Broadcast()
+	f.mu.Unlock()
+
+	return err
+}
+
+// worker processes the events of a product on a provider, it is the only writer of the timeseries
+type worker struct {
+	provider string
+	product  string
+	key      string
+	ts       *timeseries.Timeseries
+	events   chan *exchanges.TickerEvent
+	done     chan struct{}
+	// dropped is the number of events dropped by the feed, it is updated atomically
+	dropped uint64
+}
+
+func newWorker(provider string, product exchanges.Product) *worker {
+	return &worker{
+		provider: provider,
+		product:  product.String(),
+		key:      workerKey(provider, product.String()),
+		ts:       timeseries.New(timeseriesSize),
+		events:   make(chan *exchanges.TickerEvent, workerBuffer),
+		done:     make(chan struct{}),
+	}
+}
+
+// push the event to the worker without blocking the feed, the oldest event is dropped when the worker is late.
+// The feed is the only sender of the worker.
+func (w *worker) push(event *exchanges.TickerEvent) {
+	select {
+	case w.events <- event:
+		return
+	default:
+	}
+
+	select {
+	case <-w.events:
+	default:
+	}
+
+	select {
+	case w.events <- event:
+	default:
+	}
+
+	if dropped := atomic.AddUint64(&w.dropped, 1); dropped%workerBuffer == 1 {
+		log.Warn().Uint64("dropped", dropped).Msgf("Worker %s is late, the oldest ticker event is dropped", w.key)
+	}
+}
+
+// workerKey of product on provider
+func workerKey(provider string, product string) string {
+	return fmt.Sprintf("%s-%s", provider, product)
+}
+
+// subscribeProduct takes a reference on products of provider, the feed and the workers are started
+// and the ticker is subscribed for the products which were not referenced yet.
+// The references are released when the subscription fails.
+func (e *Engine) subscribeProduct(name string, products []exchanges.Product) error {
+	e.stateMu.Lock()
+
+	if e.stopped {
+		e.stateMu.Unlock()
+
+		return ErrEngineStopped
+	}
+
+	f, ok := e.feeds[name]
+	if ok == false {
+		exchange, err := e.providers.Get(name)
+		if err != nil {
+			e.stateMu.Unlock()
+
+			return err
+		}
+
+		f = newFeed(exchange.Ticker())
+
+		e.feeds[name] = f
+
+		e.feedReceived(name)
+
+		e.wg.Add(1)
+
+		go e.route(name, f.listener)
+	}
+
+	subscribes := []exchanges.Product{}
+	productsList := []string{}
+
+	for _, product := range products {
+		key := workerKey(name, product.String())
+
+		e.refs[key]++
+
+		if e.refs[key] > 1 {
+			continue
+		}
+
+		subscribes = append(subscribes, product)
+		productsList = append(productsList, product.String())
+
+		w := newWorker(name, product)
+
+		e.workers[key] = w
+
+		e.wg.Add(1)
+
+		go e.work(w)
+	}
+
+	if len(subscribes) == 0 {
+		e.stateMu.Unlock()
+
+		return nil
+	}
+
+	ticket := f.ticket()
+
+	e.stateMu.Unlock()
+
+	log.Debug().Msgf("Subscribe to product %s on %s exchange", strings.Join(productsList, ", "), name)
+
+	if err := f.send(ticket, func() error {
+		return f.ticker.Subscribe(subscribes...)
+	}); err != nil {
+		log.Error().Err(err).Msgf("Subscribe to product %v", strings.Join(productsList, ", "))
+
+		e.releaseProduct(name, products)
+
+		return err
+	}
+
+	return nil
+}
+
+// releaseProduct undoes the references taken on products of provider by a failed subscription,
+// the workers of the products which are no longer referenced are stopped
+func (e *Engine) releaseProduct(name string, products []exchanges.Product) {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	for _, product := range products {
+		key := workerKey(name, product.String())
+
+		if e.refs[key] == 0 {
+			continue
+		}
+
+		e.refs[key]--
+
+		if e.refs[key] > 0 {
+			continue
+		}
+
+		delete(e.refs, key)
+
+		if w, ok := e.workers[key]; ok {
+			close(w.done)
+
+			delete(e.workers, key)
+		}
+	}
+}
+
+// unsubscribeProduct releases a reference on products of provider, the workers are stopped
+// and the ticker is unsubscribed for the products which are no longer referenced
+func (e *Engine) unsubscribeProduct(name string, products []exchanges.Product) error {
+	if err := e.queueUnsubscribe(name, products); err != nil {
+		return err
+	}
+
+	e.sendUnsubscribes()
+
+	return nil
+}
+
+// queueUnsubscribe releases a reference on products of provider, the workers are stopped and the unsubscription
+// of the products which are no longer referenced is queued, the caller holding the engine locks
+// does not wait for the exchange and sends the queued unsubscriptions after releasing them
+func (e *Engine) queueUnsubscribe(name string, products []exchanges.Product) error {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if e.stopped {
+		return ErrEngineStopped
+	}
+
+	f, ok := e.feeds[name]
+	if ok == false {
+		return nil
+	}
+
+	unsubscribes := []exchanges.Product{}
+	productsList := []string{}
+
+	for _, product := range products {
+		key := workerKey(name, product.String())
+
+		if e.refs[key] == 0 {
+			continue
+		}
+
+		e.refs[key]--
+
+		if e.refs[key] > 0 {
+			continue
+		}
+
+		delete(e.refs, key)
+
+		unsubscribes = append(unsubscribes, product)
+		productsList = append(productsList, product.String())
+
+		if w, ok := e.workers[key]; ok {
+			close(w.done)
+
+			delete(e.workers, key)
+		}
+	}
+
+	if len(unsubscribes) == 0 {
+		return nil
+	}
+
+	ticket := f.ticket()
+
+	e.unsubscribes = append(e.unsubscribes, func() {
+		log.Debug().Msgf("Unsubscribe from product %s on %s exchange", strings.Join(productsList, ", "), name)
+
+		if err := f.send(ticket, func() error {
+			return f.ticker.Unsubscribe(unsubscribes...)
+		}); err != nil {
+			log.Error().Err(err).Msgf("Unsubscribe from product %v", strings.Join(productsList, ", "))
+		}
+	})
+
+	return nil
+}
+
+// sendUnsubscribes to the exchanges, it is called without the engine locks because a queued ticket
+// holds the turn of the next subscriptions of its feed until it is sent
+func (e *Engine) sendUnsubscribes() {
+	e.stateMu.Lock()
+	unsubscribes := e.unsubscribes
+	e.unsubscribes = nil
+	e.stateMu.Unlock()
+
+	for _, send := range unsubscribes {
+		send()
+	}
+}
+
+// subscribers returns the number of references on product of provider
+func (e *Engine) subscribers(provider string, product string) int {
+	e.stateMu.RLock()
+	defer e.stateMu.RUnlock()
+
+	return e.refs[workerKey(provider, product)]
+}
+
+// worker of product on provider
+func (e *Engine) worker(provider string, product string) (*worker, bool) {
+	e.stateMu.RLock()
+	defer e.stateMu.RUnlock()
+
+	w, ok := e.workers[workerKey(provider, product)]
+
+	return w, ok
+}
+
+// route the events of provider feed to the workers until the engine is stopped
+func (e *Engine) route(provider string, listener *exchanges.Listener) {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case event, ok := <-listener.C:
+			if ok == false {
+				if e.isStopped() == false {
+					e.feedClosed(provider)
+				}
+
+				return
+			}
+
+			if event.Time.IsZero() {
+				continue
+			}
+
+			e.feedReceived(provider)
+
+			// the feed is shared with the other consumers of the provider
+			w, ok := e.worker(provider, event.Product.String())
+			if ok == false {
+				continue
+			}
+
+			// a late worker does not block the other products of the feed
+			w.push(event)
+		case <-e.doneCh:
+			listener.Close()
+
+			return
+		}
+	}
+}
+
+// work runs the campaigns of the worker product one event at a time until the product is unsubscribed or the engine is stopped
+func (e *Engine) work(w *worker) {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case event := <-w.events:
+			// the engine stops before the pending events
+			if e.isStopped() {
+				return
+			}
+
+			w.ts.AddWithVolume(event.Time.Unix(), event.Price.InexactFloat64(), event.Size.InexactFloat64())
+
+			e.trade(w.provider, event, w.ts)
+
+			e.emitter.Dispatch(fmt.Sprintf("ticker-%s", w.key), event)
+		case <-w.done:
+			return
+		case <-e.doneCh:
+			return
+		}
+	}
+}
+
+// GetTimeserie from key {provider}-{product}, the snapshot is not updated by the next events
+func (e *Engine) GetTimeserie(key string) (*timeseries.Timeseries, error) {
+	e.stateMu.RLock()
+	w, ok := e.workers[key]
+	e.stateMu.RUnlock()
+
+	if ok == false {
+		return nil, fmt.Errorf("timeserie %s not found", key)
+	}
+
+	return w.ts.Snapshot(), nil
+}
+
+// lastPrice of product on provider
+func (e *Engine) lastPrice(provider string, product string) (float64, bool) {
+	w, ok := e.worker(provider, product)
+	if ok == false {
+		return 0, false
+	}
+
+	values := w.ts.GetLatestValues(1)
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	return values[0], true
+}
+
+// LastPrices of the products followed on provider
+func (e *Engine) LastPrices(provider string) map[string]float64 {
+	e.stateMu.RLock()
+
+	workers := []*worker{}
+
+	for _, w := range e.workers {
+		if w.provider == provider {
+			workers = append(workers, w)
+		}
+	}
+
+	e.stateMu.RUnlock()
+
+	prices := map[string]float64{}
+
+	for _, w := range workers {
+		values := w.ts.GetLatestValues(1)
+		if len(values) == 0 {
+			continue
+		}
+
+		prices[w.product] = values[0]
+	}
+
+	return prices
+}
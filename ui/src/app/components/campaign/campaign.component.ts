@@ -32,7 +32,7 @@ export class CampaignComponent implements OnInit, AfterContentInit, OnDestroy {
     ngOnInit() {
         this.subscriptions.push(
             this.tickerService.ticker('gdax', 'btc-eur').subscribe(ticker => {
-                this.chart.series[0].addPoint([(new Date(ticker.time)).getTime(), ticker.price]);
+                this.chart.series[0].addPoint([(new Date(ticker.time)).getTime(), parseFloat(ticker.price)]);
             })
         );
     }
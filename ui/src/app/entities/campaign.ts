@@ -4,9 +4,9 @@ export class Campaign {
         public id?: number,
         public provider?: string,
         public product_id?: string,
-        public volume?: number,
-        public buy_limit?: number,
-        public sell_limit?: number,
+        public volume?: string,
+        public buy_limit?: string,
+        public sell_limit?: string,
         public sell_limit_unit?: string,
         public created_at?: string,
         public updated_at?: string,